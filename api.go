@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"pump/sim"
+)
+
+// simulation states reported by the API
+const (
+	stateIdle     = "idle"
+	stateRunning  = "running"
+	statePaused   = "paused"
+	stateFinished = "finished"
+)
+
+// apiServer controls a single simulation at a time over HTTP
+type apiServer struct {
+	configPath string
+
+	mu         sync.Mutex
+	simulation *sim.Simulation
+	cancel     context.CancelFunc
+	state      string
+}
+
+// runServe implements the serve subcommand
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", "localhost:8080", "address to listen on")
+	grpcAddr := flags.String("grpc-addr", "", "address to serve the gRPC Simulator service on, off when empty")
+	configPath := flags.String("config", "", "config file runs start from (default $CTC_CONFIG or "+strings.Join(defaultConfigPaths, ", ")+")")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: serve [--addr host:port] [--grpc-addr host:port] [--config path]")
+		fmt.Fprintln(flags.Output(), "Serves an HTTP API to start, stop, pause and inspect simulations, and optionally gRPC.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *grpcAddr != "" {
+		go func() {
+			fmt.Println("Serving the gRPC Simulator service on", *grpcAddr)
+			if err := serveGRPC(*grpcAddr, findConfig(*configPath)); err != nil {
+				fmt.Fprintln(os.Stderr, "Error serving gRPC:", err)
+			}
+		}()
+	}
+
+	server := &apiServer{configPath: findConfig(*configPath), state: stateIdle}
+	fmt.Println("Serving the simulation API on", *addr)
+	if err := http.ListenAndServe(*addr, server.routes()); err != nil {
+		return fmt.Errorf("serving API: %w", err)
+	}
+	return nil
+}
+
+func (a *apiServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /simulation", a.status)
+	mux.HandleFunc("POST /simulation", a.start)
+	mux.HandleFunc("DELETE /simulation", a.stop)
+	mux.HandleFunc("POST /simulation/pause", a.pause)
+	mux.HandleFunc("POST /simulation/resume", a.resume)
+	mux.HandleFunc("PUT /simulation/spawn-rate", a.spawnRate)
+	return mux
+}
+
+// status returns the state of the simulation and its report so far
+func (a *apiServer) status(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	response := struct {
+		State  string      `json:"state"`
+		Report *sim.Report `json:"report,omitempty"`
+	}{State: a.state}
+	if a.simulation != nil {
+		report := a.simulation.Results().Report()
+		response.Report = &report
+	}
+	writeAPIResponse(w, http.StatusOK, response)
+}
+
+// start runs a new simulation of the config file, the optional JSON body holds overrides
+// by config key such as {"cash_register_count": 3, "fuels.gas.station_count": 2}
+func (a *apiServer) start(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.state == stateRunning || a.state == statePaused {
+		writeAPIError(w, http.StatusConflict, errors.New("a simulation is already running"))
+		return
+	}
+
+	config, err := loadConfig(a.configPath)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := applyEnv(config); err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := applyJSONOverrides(config, r); err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := config.Validate(); err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	simulation := sim.New(*config)
+	ctx, cancel := context.WithCancel(context.Background())
+	a.simulation, a.cancel, a.state = simulation, cancel, stateRunning
+
+	go func() {
+		simulation.Run(ctx)
+		cancel()
+
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		if a.simulation == simulation {
+			a.state = stateFinished
+		}
+	}()
+
+	writeAPIResponse(w, http.StatusAccepted, map[string]interface{}{"state": a.state, "config": simulation.Results().Config})
+}
+
+// stop ends the running simulation early, its report stays available
+func (a *apiServer) stop(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.state != stateRunning && a.state != statePaused {
+		writeAPIError(w, http.StatusConflict, errors.New("no simulation is running"))
+		return
+	}
+	if a.state == statePaused {
+		a.simulation.Resume()
+	}
+	a.cancel()
+	a.state = stateFinished
+
+	writeAPIResponse(w, http.StatusOK, map[string]string{"state": a.state})
+}
+
+func (a *apiServer) pause(w http.ResponseWriter, r *http.Request) {
+	a.setPaused(w, stateRunning, statePaused, (*sim.Simulation).Pause)
+}
+
+func (a *apiServer) resume(w http.ResponseWriter, r *http.Request) {
+	a.setPaused(w, statePaused, stateRunning, (*sim.Simulation).Resume)
+}
+
+// setPaused moves the simulation from state from to state to with change
+func (a *apiServer) setPaused(w http.ResponseWriter, from, to string, change func(*sim.Simulation) error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.state != from {
+		writeAPIError(w, http.StatusConflict, fmt.Errorf("simulation is %s", a.state))
+		return
+	}
+	if err := change(a.simulation); err != nil {
+		writeAPIError(w, http.StatusConflict, err)
+		return
+	}
+	a.state = to
+
+	writeAPIResponse(w, http.StatusOK, map[string]string{"state": a.state})
+}
+
+// spawnRate changes car_spawn_chance or arrivals_per_hour of the running simulation,
+// e.g. {"car_spawn_chance": 0.8}
+func (a *apiServer) spawnRate(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.state != stateRunning && a.state != statePaused {
+		writeAPIError(w, http.StatusConflict, errors.New("no simulation is running"))
+		return
+	}
+
+	var body map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	config := a.simulation.Results().Config
+	for key, value := range body {
+		if key != "car_spawn_chance" && key != "arrivals_per_hour" {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("%s: only car_spawn_chance and arrivals_per_hour can be changed", key))
+			return
+		}
+		if err := config.Set(key, string(value)); err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if err := config.Validate(); err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	a.simulation.Reload(config)
+
+	writeAPIResponse(w, http.StatusOK, map[string]interface{}{
+		"car_spawn_chance":  config.CarSpawnChance,
+		"arrivals_per_hour": config.ArrivalsPerHour,
+	})
+}
+
+// applyJSONOverrides sets the config keys of a JSON object body, an empty body changes nothing
+func applyJSONOverrides(config *sim.Config, r *http.Request) error {
+	var overrides map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&overrides); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	// JSON values are valid YAML, which Set decodes
+	for key, value := range overrides {
+		if err := config.Set(key, string(value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeAPIResponse(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeAPIResponse(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"pump/sim"
+)
+
+// batchFile lists the scenarios of a batch run
+type batchFile struct {
+	Scenarios []batchScenario `json:"scenarios" yaml:"scenarios"`
+}
+
+// batchScenario is a config file run with one or more seeds
+type batchScenario struct {
+	Name         string                 `json:"name" yaml:"name"`
+	Config       string                 `json:"config" yaml:"config"`             // relative to the batch file
+	Set          map[string]interface{} `json:"set" yaml:"set"`                   // overrides by config key, like --set
+	Seeds        []int64                `json:"seeds" yaml:"seeds"`               // run once per seed
+	Replications int                    `json:"replications" yaml:"replications"` // consecutive seeds when no seeds are given
+}
+
+// batchJob is a single run of a scenario
+type batchJob struct {
+	scenario int
+	config   sim.Config
+}
+
+// runBatch implements the batch subcommand
+func runBatch(args []string) error {
+	flags := flag.NewFlagSet("batch", flag.ExitOnError)
+	parallel := flags.Int("parallel", 1, "runs simulated at the same time")
+	pprofAddr := flags.String("pprof", "", "serve net/http/pprof profiles on this address, e.g. :6060, while the batch runs")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: batch [--parallel N] [--pprof addr] batch.yaml")
+		fmt.Fprintln(flags.Output(), "Runs every scenario of the batch file and prints a summary table of their key metrics.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errUsage
+	}
+	if *pprofAddr != "" {
+		if err := servePprof(*pprofAddr); err != nil {
+			return invalidf("serving pprof: %w", err)
+		}
+	}
+
+	batch, err := loadBatch(flags.Arg(0))
+	if err != nil {
+		return invalidf("reading batch file: %w", err)
+	}
+
+	var jobs []batchJob
+	for i, scenario := range batch.Scenarios {
+		config, err := scenarioConfig(filepath.Dir(flags.Arg(0)), scenario)
+		if err != nil {
+			return invalidf("invalid scenario %s:\n%w", scenario.Name, err)
+		}
+		for _, seed := range scenarioSeeds(scenario, config.RandomSeed) {
+			job := batchJob{scenario: i, config: *config}
+			job.config.RandomSeed = seed
+			jobs = append(jobs, job)
+		}
+	}
+
+	results, runErrs := runJobs(jobs, *parallel)
+
+	// failed runs are left out of the summary and reported after it
+	var errs []error
+	byScenario := make([][]sim.Results, len(batch.Scenarios))
+	for i, job := range jobs {
+		if runErrs[i] != nil {
+			errs = append(errs, fmt.Errorf("scenario %s with seed %d: %w", batch.Scenarios[job.scenario].Name, job.config.RandomSeed, runErrs[i]))
+			continue
+		}
+		byScenario[job.scenario] = append(byScenario[job.scenario], results[i])
+	}
+	printBatchSummary(batch.Scenarios, byScenario)
+	if len(errs) > 0 {
+		return fmt.Errorf("%d of %d runs failed:\n%w", len(errs), len(jobs), errors.Join(errs...))
+	}
+	return nil
+}
+
+func loadBatch(path string) (*batchFile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var batch batchFile
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &batch)
+	default:
+		err = json.Unmarshal(content, &batch)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(batch.Scenarios) == 0 {
+		return nil, fmt.Errorf("no scenarios in %s", path)
+	}
+
+	for i := range batch.Scenarios {
+		if batch.Scenarios[i].Name == "" {
+			batch.Scenarios[i].Name = strings.TrimSuffix(filepath.Base(batch.Scenarios[i].Config), filepath.Ext(batch.Scenarios[i].Config))
+		}
+	}
+	return &batch, nil
+}
+
+// scenarioConfig loads the config of the scenario and applies its overrides
+func scenarioConfig(dir string, scenario batchScenario) (*sim.Config, error) {
+	path := scenario.Config
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	config, err := loadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range scenario.Set {
+		encoded, err := yaml.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if err := config.Set(key, string(encoded)); err != nil {
+			return nil, err
+		}
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// scenarioSeeds returns the seeds the scenario runs with, consecutive ones from the config seed unless listed
+func scenarioSeeds(scenario batchScenario, seed int64) []int64 {
+	if len(scenario.Seeds) > 0 {
+		return scenario.Seeds
+	}
+
+	n := scenario.Replications
+	if n < 1 {
+		n = 1
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	seeds := make([]int64, n)
+	for i := range seeds {
+		seeds[i] = seed + int64(i)
+	}
+	return seeds
+}
+
+// runJobs simulates the jobs on virtual time with up to parallel of them at a time, returning the
+// results and the errors of the runs in job order
+func runJobs(jobs []batchJob, parallel int) ([]sim.Results, []error) {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	results := make([]sim.Results, len(jobs))
+	errs := make([]error, len(jobs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				config := jobs[i].config
+				config.Realtime = false // a batch of realtime scenarios would take hours
+				simulation := sim.New(config)
+				errs[i] = simulation.Run(context.Background())
+				results[i] = simulation.Results()
+			}
+		}()
+	}
+
+	for i := range jobs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	return results, errs
+}
+
+func printBatchSummary(scenarios []batchScenario, results [][]sim.Results) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	header := []string{"scenario", "runs"}
+	for _, metric := range sim.KeyMetrics {
+		header = append(header, metric.Column)
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t")+"\t")
+
+	for i, scenario := range scenarios {
+		row := []string{scenario.Name, fmt.Sprint(len(results[i]))}
+		if len(results[i]) == 0 {
+			fmt.Fprintln(w, strings.Join(row, "\t")+"\t")
+			continue
+		}
+		for _, e := range sim.Summarize(results[i]) {
+			if len(results[i]) > 1 {
+				row = append(row, fmt.Sprintf("%.2f ± %.2f", e.Mean, e.HalfWidth))
+			} else {
+				row = append(row, fmt.Sprintf("%.2f", e.Mean))
+			}
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t")+"\t")
+	}
+	w.Flush()
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"pump/sim"
+)
+
+func TestRunJobsReportsFailedRuns(t *testing.T) {
+	good := sim.DefaultConfig()
+	good.SimulationLength = sim.Duration(time.Hour)
+	good.RandomSeed = 1
+	good.Realtime = true // a batch runs it on virtual time still
+	bad := good
+	bad.CarSource = &sim.CarSourceConfig{Name: "nowhere"}
+
+	results, errs := runJobs([]batchJob{{config: good}, {scenario: 1, config: bad}}, 2)
+	if errs[0] != nil {
+		t.Errorf("the valid run failed: %v", errs[0])
+	}
+	if results[0].Stats.CarsSpawnedTotal == 0 {
+		t.Error("the valid run spawned no cars")
+	}
+	if errs[1] == nil {
+		t.Error("the run of an unknown car source didn't fail")
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+
+	"pump/sim"
+)
+
+// benchmarkSeed is the seed of --benchmark without --seed, so runs before and after a change to the engine
+// do the same work
+const benchmarkSeed = 42
+
+// benchmarkRun is what a run of --benchmark measured
+type benchmarkRun struct {
+	wall   time.Duration
+	events int
+	cars   int32
+	allocs uint64
+	bytes  uint64
+}
+
+func (r benchmarkRun) String() string {
+	cars := float64(max(r.cars, 1))
+	return fmt.Sprintf("%v wall, %d events, %.0f events/s, %d cars, %.1f allocs and %.0f B per car",
+		r.wall.Round(time.Millisecond), r.events, float64(r.events)/r.wall.Seconds(), r.cars,
+		float64(r.allocs)/cars, float64(r.bytes)/cars)
+}
+
+// runBenchmark runs the config the given times on virtual time with the same seed, so every run does
+// the same work, and prints the wall time, events per second and allocations of each and the fastest
+func runBenchmark(config sim.Config, runs int) error {
+	if config.RandomSeed == 0 {
+		config.RandomSeed = benchmarkSeed
+	}
+	config.Realtime = false // the events are those of the virtual engine
+	fmt.Printf("Benchmarking %v of simulated time with seed %d, %d runs\n", time.Duration(config.SimulationLength), config.RandomSeed, runs)
+	var best benchmarkRun
+	for i := 1; i <= runs; i++ {
+		run, err := measureRun(config)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("run %d: %v\n", i, run)
+		if i == 1 || run.wall < best.wall {
+			best = run
+		}
+	}
+	if runs > 1 {
+		fmt.Printf("fastest: %v\n", best)
+	}
+	return nil
+}
+
+// measureRun runs a simulation of the config without any output
+func measureRun(config sim.Config) (benchmarkRun, error) {
+	simulation := sim.New(config)
+
+	// leave the garbage of the previous run out of the allocations
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	err := simulation.Run(context.Background())
+	wall := time.Since(start)
+	runtime.ReadMemStats(&after)
+	if err != nil {
+		return benchmarkRun{}, err
+	}
+
+	return benchmarkRun{
+		wall:   wall,
+		events: simulation.Events(),
+		cars:   simulation.Results().Stats.CarsSpawnedTotal,
+		allocs: after.Mallocs - before.Mallocs,
+		bytes:  after.TotalAlloc - before.TotalAlloc,
+	}, nil
+}
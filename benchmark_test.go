@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"pump/sim"
+)
+
+func TestBenchmarkRunsDoTheSameWork(t *testing.T) {
+	config := sim.DefaultConfig()
+	config.SimulationLength = sim.Duration(2 * time.Hour)
+	config.RandomSeed = benchmarkSeed
+
+	first, err := measureRun(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := measureRun(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.events == 0 || first.cars == 0 {
+		t.Fatalf("the run processed %d events of %d cars", first.events, first.cars)
+	}
+	if first.events != second.events || first.cars != second.cars {
+		t.Errorf("runs of the same seed differ: %v and %v", first, second)
+	}
+}
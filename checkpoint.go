@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"pump/sim"
+)
+
+// writeCheckpoint replaces the checkpoint file with c, writing it next to it first so an
+// interruption never leaves half a checkpoint behind
+func writeCheckpoint(path string, c *sim.Checkpoint) error {
+	content, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(content)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// readCheckpoint loads a checkpoint written with --checkpoint
+func readCheckpoint(path string) (*sim.Checkpoint, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := new(sim.Checkpoint)
+	if err := json.Unmarshal(content, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"pump/sim"
+)
+
+func TestCheckpointFileResumesTheRun(t *testing.T) {
+	config := sim.DefaultConfig()
+	config.SimulationLength = sim.Duration(2 * time.Hour)
+	config.RandomSeed = 3
+
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	full := sim.New(config)
+	full.CheckpointInterval = time.Hour
+	full.Checkpoints = func(c *sim.Checkpoint) error { return writeCheckpoint(path, c) }
+	if err := full.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	files, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("writing the checkpoint left %d files, expected only the checkpoint", len(files))
+	}
+
+	checkpoint, err := readCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumed, err := sim.FromCheckpoint(checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := resumed.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	golden, err := full.Results().GoldenStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := resumed.Results().CompareGolden(golden); err != nil {
+		t.Errorf("the resumed run has other stats: %v", err)
+	}
+}
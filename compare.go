@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"pump/sim"
+)
+
+// runCompare implements the compare subcommand
+func runCompare(args []string) error {
+	flags := flag.NewFlagSet("compare", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: compare baseline.json other.json...")
+		fmt.Fprintln(flags.Output(), "Prints the key metrics of --output json reports side by side with their change from the first one.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() < 2 {
+		flags.Usage()
+		return errUsage
+	}
+
+	var names []string
+	var scenarios [][]sim.Results
+	for _, path := range flags.Args() {
+		runs, err := readRuns(path)
+		if err != nil {
+			return invalidf("reading %s: %w", path, err)
+		}
+		names = append(names, strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+		scenarios = append(scenarios, runs)
+	}
+	sim.PrintComparison(os.Stdout, names, scenarios)
+	return nil
+}
+
+func readRuns(path string) ([]sim.Results, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return sim.ReadRuns(file)
+}
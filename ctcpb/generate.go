@@ -0,0 +1,6 @@
+// Package ctcpb holds the protobuf messages and gRPC service of the simulation server,
+// generated from simulator.proto. They let other tools and non-Go clients start runs, stream
+// their stats and fetch the results of `pump serve --grpc-addr`.
+package ctcpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative simulator.proto
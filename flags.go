@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"pump/sim"
+)
+
+// shorter names for frequently tweaked config keys
+var flagAliases = map[string]string{
+	"seed":           "random_seed",
+	"cash-registers": "cash_register_count",
+	"sim-length":     "simulation_length",
+}
+
+// override is a config value given on the command line
+type override struct {
+	key, value string
+}
+
+// overrides are applied to the loaded config in command line order
+var overrides []override
+
+// parseOverride splits a key=value flag value into an override of the key
+func parseOverride(value string) (override, error) {
+	key, value, ok := strings.Cut(value, "=")
+	if !ok {
+		return override{}, fmt.Errorf("expected key=value, got %q", key)
+	}
+
+	o := override{key, value}
+	return o, o.check()
+}
+
+// check catches malformed values while parsing flags rather than after loading the config
+func (o override) check() error {
+	var scratch sim.Config
+	return scratch.Set(o.key, o.value)
+}
+
+// configFlag overrides one config key, the flag name is the key with dashes instead of underscores
+type configFlag struct {
+	key    string
+	isBool bool
+}
+
+func (f *configFlag) String() string { return "" }
+
+func (f *configFlag) IsBoolFlag() bool { return f.isBool }
+
+func (f *configFlag) Set(value string) error {
+	o := override{f.key, value}
+	if err := o.check(); err != nil {
+		return err
+	}
+
+	overrides = append(overrides, o)
+	return nil
+}
+
+// setFlag overrides any config key given as key=value, including nested ones
+type setFlag struct{}
+
+func (setFlag) String() string { return "" }
+
+func (setFlag) Set(value string) error {
+	o, err := parseOverride(value)
+	if err != nil {
+		return err
+	}
+
+	overrides = append(overrides, o)
+	return nil
+}
+
+// registerConfigFlags adds a flag for every top level config key and --set for nested keys
+func registerConfigFlags() {
+	flag.Var(setFlag{}, "set", "override any config key as key=value, e.g. fuels.gas.pricing=2.9 (repeatable)")
+
+	flags := make(map[string]*configFlag)
+	for _, key := range sim.ConfigKeys() {
+		f := &configFlag{key: key.Name, isBool: key.Type.Kind() == reflect.Bool}
+		name := strings.ReplaceAll(key.Name, "_", "-")
+		flag.Var(f, name, fmt.Sprintf("override %s from the config file (%v)", key.Name, key.Type))
+		flags[key.Name] = f
+	}
+
+	for alias, key := range flagAliases {
+		flag.Var(flags[key], alias, "alias of --"+strings.ReplaceAll(key, "_", "-"))
+	}
+}
+
+// applyOverrides writes the values, such as the command line ones, over the loaded config in order
+func applyOverrides(config *sim.Config, values []override) error {
+	for _, o := range values {
+		if err := config.Set(o.key, o.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// envPrefix turns a config key into its environment variable, e.g. CTC_CAR_SPAWN_CHANCE
+const envPrefix = "CTC_"
+
+// applyEnv writes values of CTC_<KEY> environment variables over the loaded config,
+// command line overrides are applied afterwards and win
+func applyEnv(config *sim.Config) error {
+	for _, key := range sim.ConfigKeys() {
+		name := envPrefix + strings.ToUpper(key.Name)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := config.Set(key.Name, value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
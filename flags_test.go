@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+func TestParseOverride(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    override
+		wantErr bool
+	}{
+		{value: "cash_register_count=3", want: override{"cash_register_count", "3"}},
+		{value: "fuels.gas.station_count=5", want: override{"fuels.gas.station_count", "5"}},
+		{value: "cash_register_count", wantErr: true},
+		{value: "cash_register_count=many", wantErr: true},
+		{value: "no_such_key=1", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseOverride(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseOverride(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parseOverride(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+
+		// whatif --set takes the same values as the global --set
+		var changes overrideList
+		if err := changes.Set(tt.value); (err != nil) != tt.wantErr {
+			t.Errorf("whatif --set %q error = %v, want error %v", tt.value, err, tt.wantErr)
+		}
+	}
+}
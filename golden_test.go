@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"pump/sim"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of TestGolden with the stats of the runs")
+
+// TestGolden runs the bundled example configs deterministically and compares their stats with
+// their golden files in testdata/golden
+func TestGolden(t *testing.T) {
+	for _, path := range []string{"config.json", "config.yaml", "scenarios/hydrogen.yaml", "scenarios/winter.yaml"} {
+		t.Run(path, func(t *testing.T) {
+			config, err := loadConfig(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			results, err := sim.RunDeterministic(context.Background(), *config, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", "golden", path+".json")
+			if *update {
+				content, err := results.GoldenStats()
+				if err == nil {
+					err = os.MkdirAll(filepath.Dir(golden), 0755)
+				}
+				if err == nil {
+					err = os.WriteFile(golden, content, 0644)
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v, -update writes it", err)
+			}
+			if err := results.CompareGolden(want); err != nil {
+				t.Errorf("stats differ from %s: %v, -update accepts the change", golden, err)
+			}
+		})
+	}
+}
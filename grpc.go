@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"pump/ctcpb"
+	"pump/sim"
+)
+
+// grpcServer runs any number of simulations side by side, addressed by run id
+type grpcServer struct {
+	ctcpb.UnimplementedSimulatorServer
+	configPath string
+
+	mu     sync.Mutex
+	runs   map[string]*grpcRun
+	nextID int
+}
+
+// grpcRun is a simulation started over gRPC, done is closed once it finished
+type grpcRun struct {
+	simulation *sim.Simulation
+	done       chan struct{}
+}
+
+// serveGRPC serves the Simulator service on addr until it fails
+func serveGRPC(addr, configPath string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	server := grpc.NewServer()
+	ctcpb.RegisterSimulatorServer(server, &grpcServer{configPath: configPath, runs: make(map[string]*grpcRun)})
+	return server.Serve(listener)
+}
+
+// StartRun runs a new simulation of the config file with the overrides of the request
+func (g *grpcServer) StartRun(ctx context.Context, req *ctcpb.StartRunRequest) (*ctcpb.StartRunResponse, error) {
+	config, err := loadConfig(g.configPath)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if err := applyEnv(config); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	for key, value := range req.Overrides {
+		if err := config.Set(key, value); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	if err := config.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	run := &grpcRun{simulation: sim.New(*config), done: make(chan struct{})}
+	go func() {
+		run.simulation.Run(context.Background())
+		close(run.done)
+	}()
+
+	g.mu.Lock()
+	g.nextID++
+	id := strconv.Itoa(g.nextID)
+	g.runs[id] = run
+	g.mu.Unlock()
+
+	return &ctcpb.StartRunResponse{RunId: id, RandomSeed: run.simulation.Results().Config.RandomSeed}, nil
+}
+
+// StreamStats sends the stats of the run at the requested interval, ending with its final stats
+func (g *grpcServer) StreamStats(req *ctcpb.StreamStatsRequest, stream ctcpb.Simulator_StreamStatsServer) error {
+	run, err := g.run(req.RunId)
+	if err != nil {
+		return err
+	}
+
+	interval := time.Second
+	if req.IntervalSeconds > 0 {
+		interval = time.Duration(req.IntervalSeconds * float64(time.Second))
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-run.done:
+			return stream.Send(&ctcpb.StatsUpdate{RunId: req.RunId, State: ctcpb.RunState_RUN_STATE_FINISHED, Stats: protoStats(run.simulation.Results().Stats)})
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-ticker.C:
+			update := &ctcpb.StatsUpdate{RunId: req.RunId, State: run.state(), Stats: protoStats(run.simulation.Results().Stats)}
+			if err := stream.Send(update); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// GetResults returns the stats and the JSON report of the run so far
+func (g *grpcServer) GetResults(ctx context.Context, req *ctcpb.GetResultsRequest) (*ctcpb.GetResultsResponse, error) {
+	run, err := g.run(req.RunId)
+	if err != nil {
+		return nil, err
+	}
+
+	// check the state first so the returned stats of a finished run are final
+	state := run.state()
+	results := run.simulation.Results()
+	report, err := json.Marshal(results.Report())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &ctcpb.GetResultsResponse{State: state, Stats: protoStats(results.Stats), ReportJson: string(report)}, nil
+}
+
+func (g *grpcServer) run(id string) (*grpcRun, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	run, ok := g.runs[id]
+	if !ok {
+		return nil, status.Error(codes.NotFound, fmt.Sprintf("no run with id %q", id))
+	}
+	return run, nil
+}
+
+func (r *grpcRun) state() ctcpb.RunState {
+	select {
+	case <-r.done:
+		return ctcpb.RunState_RUN_STATE_FINISHED
+	default:
+		return ctcpb.RunState_RUN_STATE_RUNNING
+	}
+}
+
+// protoStats converts the stats of a simulation into their protobuf message
+func protoStats(stats sim.Stats) *ctcpb.Stats {
+	p := &ctcpb.Stats{
+		CarsSpawnedTotal:    stats.CarsSpawnedTotal,
+		CarsNotServed:       stats.CarsNotServed,
+		CarsInRefuelQueue:   stats.CarsInRefuelQueue,
+		CarsInCheckoutQueue: stats.CarsInCheckoutQueue,
+		RegistersBusy:       stats.RegistersBusy,
+		CheckoutTimeTotal:   stats.CheckoutTimeTotal,
+		TimeBeforeLeaving:   stats.TimeBeforeLeaving,
+		TimeInCheckoutQueue: stats.TimeInCheckoutQueue,
+	}
+	for _, f := range stats.Fuels {
+		p.Fuels = append(p.Fuels, &ctcpb.FuelStats{
+			Name:              f.Name,
+			CarsRefueled:      f.CarsRefueled,
+			CarsCheckedOut:    f.CarsCheckedOut,
+			Cash:              f.Cash,
+			Units:             f.Units,
+			TimeRefueling:     f.TimeRefueling,
+			CarsInRefuelQueue: f.CarsInRefuelQueue,
+			StationsBusy:      f.StationsBusy,
+			StationsInRepair:  f.StationsInRepair,
+			PumpFailures:      f.PumpFailures,
+			Downtime:          f.Downtime,
+		})
+	}
+	return p
+}
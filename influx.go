@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"pump/sim"
+)
+
+// influxEscape escapes the tag values of the line protocol
+var influxEscape = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`)
+
+// influxWriter streams the queue samples of a run in InfluxDB line protocol, to a file or as a
+// datagram per sample to a udp://host:port endpoint
+type influxWriter struct {
+	out   io.WriteCloser
+	start time.Time // samples are stamped with it plus their simulated time
+	run   sim.Metadata
+	err   error // the first write that failed
+}
+
+// newInfluxWriter opens the target, tagging every point with the ID and the seed of the run
+func newInfluxWriter(target string, run sim.Metadata) (*influxWriter, error) {
+	w := &influxWriter{start: time.Now(), run: run}
+	if addr, ok := strings.CutPrefix(target, "udp://"); ok {
+		conn, err := net.Dial("udp", addr)
+		if err != nil {
+			return nil, err
+		}
+		w.out = conn
+		return w, nil
+	}
+
+	file, err := os.Create(target)
+	if err != nil {
+		return nil, err
+	}
+	w.out = file
+	return w, nil
+}
+
+// write sends the points of a sample, the totals and one per fuel type
+func (w *influxWriter) write(s sim.QueueSample) {
+	if w.err != nil {
+		return
+	}
+	ts := w.start.Add(time.Duration(s.Time * float64(time.Second))).UnixNano()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "gas_station,run=%s,seed=%d cars_in_refuel_queue=%di,cars_in_checkout_queue=%di,registers_busy=%di %d\n",
+		w.run.ID, w.run.Seed, s.CarsInRefuelQueue, s.CarsInCheckoutQueue, s.RegistersBusy, ts)
+	// tags sorted by key, as InfluxDB prefers them
+	for _, f := range s.Fuels {
+		fmt.Fprintf(&b, "gas_station_fuel,fuel=%s,run=%s,seed=%d cars_in_refuel_queue=%di,stations_busy=%di,stations_in_repair=%di %d\n",
+			influxEscape.Replace(f.Name), w.run.ID, w.run.Seed, f.CarsInRefuelQueue, f.StationsBusy, f.StationsInRepair, ts)
+	}
+	// unbuffered, so collectors tailing the file such as Telegraf keep up with the run
+	_, w.err = io.WriteString(w.out, b.String())
+}
+
+// Close closes the target and returns the first error writing to it
+func (w *influxWriter) Close() error {
+	err := w.out.Close()
+	if w.err != nil {
+		return w.err
+	}
+	return err
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"pump/sim"
+)
+
+// configDocs are written above each key of a generated YAML config
+var configDocs = map[string]string{
+	"fuels": "fuel types by name, each with the unit used in the report, price per unit,\n" +
+		"chance of a new car using it (all chances sum to 1), tank sizes of its cars,\n" +
+		"seconds a car spends fueling (a full tank takes max, shorter stops dispense\n" +
+		"proportionally less) and number of stations; instead of station_count a list of\n" +
+		"stations can be given, each with a fueling_time_multiplier such as 1.5 for a slow pump;\n" +
+		"optional price_schedule and surge rules multiply pricing by time of day or while\n" +
+		"surge.queue_length cars wait for a station; queue_capacity limits the cars waiting for a\n" +
+		"station, arriving cars leave right away while it is full; fill draws the arrival level of\n" +
+		"a tank and the target level it's filled to, dispensing the difference; charging slows\n" +
+		"sessions between the fill levels down linearly above taper_from to end_rate of full\n" +
+		"power, fueling_time being a full charge without the taper; flow_rate, units per minute\n" +
+		"or kW for kWh, replaces fueling_time so a full tank takes tank_size / flow_rate, also\n" +
+		"settable per station for a high-flow pump; lanes lists the lengths of the lanes the\n" +
+		"stations stand in one behind the other, front first, cars entering from the rear can't\n" +
+		"pass a car at a pump and leave only once the cars in front of them left;\n" +
+		"shared_power makes the stations share site_power, each drawing charger_power at full speed",
+
+	"vehicle_classes":         "kinds of vehicles by name, each with its share of the arrivals (all shares sum to 1),\ntank_size_multiplier and fueling_time_multiplier scaling those of the fuel and the fuels\nit uses; classes on a fuel dedicate its stations to those classes; unset makes every car alike",
+	"cash_register_count":     "cash registers shared by all fuel types",
+	"attendant_count":         "forecourt attendants, a car at an attended station (attended: true for a fuel\nor a single station) waits for one to fuel",
+	"site_capacity":           "cars physically fitting on the site, queueing, fueling and paying together;\narriving cars are turned away while it is full, 0 means no limit",
+	"shifts":                  "daily schedule of the cash registers and attendants on duty, e.g.\n[{from: 0h, cash_registers: 1, attendants: 0}, {from: 6h, cash_registers: 4, attendants: 2}];\na closing register or attendant finishes its current car first; unset keeps everyone on duty",
+	"checkout_queue_capacity": "cars that fit into the checkout queue, refueled cars wait at their pump while it is full",
+	"checkout_policy":         "shared for one checkout queue served by all cash registers, shortest for a queue\nper register with every car joining the one with the fewest cars; not supported with shifts",
+	"checkout_time":           "seconds spent paying at a cash register",
+	"hold_pump":               "refueled cars keep their pump occupied until they paid at a cash register, as\nat stations without room to park; false frees the pump once they join the checkout queue",
+	"prepay":                  "customers pay at a cash register before queueing for a station and drive away\nonce refueled; arriving cars balk while the checkout queue is full",
+	"payment_methods":         "payment methods at the cash registers by name, e.g. cash, card and mobile, each\nwith its share of the customers (all shares sum to 1) and checkout_time replacing the\none above; unset uses checkout_time for everyone",
+	"loyalty":                 "loyalty program, the share of the customers in it and their discount per unit\nof fuel; unset disables it",
+	"pay_at_pump":             "share of the customers paying at the pump instead of a cash register and the\nseconds it takes, keeping the pump occupied; unset sends everyone to the registers",
+	"shop":                    "convenience store, the chance of a customer buying something while paying,\nthe amount spent and the seconds it adds to the checkout; unset disables it",
+	"costs":                   "hourly costs of every pump, split into the amortized capital and the operating\ncost, and of every cash register and attendant on duty; the report subtracts them\nfrom the fuel and shop revenue as the profit, unset reports revenue only",
+	"pump_failures":           "random station breakdowns, mean time between failures of a station and mean\ntime to repair, in seconds or as durations such as 2h; an mtbf of 0 disables them",
+	"car_spawn_chance":        "chance of a car arriving, checked 10 times a simulated second, or a daily\nschedule such as [{from: 0h, chance: 0.1}, {from: 7h, chance: 0.8}]",
+	"arrivals_per_hour":       "mean cars arriving per simulated hour in a Poisson process, replacing the\ncar_spawn_chance ticks; 0 keeps the ticks",
+	"interarrival":            "seconds between two cars drawn from a distribution such as\n{name: exponential, params: {mean: 2}}, replacing the car_spawn_chance ticks",
+	"car_source":              "registered source of the arrivals replacing the spawning, e.g. {name: bursty, params:\n{bursts_per_hour: 4, size: 6, spacing: 15}} or {name: fleet, params: {start: 0, every: 1800,\nsize: 8, spacing: 20}}; unset spawns cars at random",
+	"demand_periods":          "named periods of the day multiplying the arrival rate, e.g. [{name: morning, from: 7h,\nto: 9h, multiplier: 2.5}]; the report breaks the cars down by the period they arrived in",
+	"weather":                 "random weather, each state lasting mean_duration on average before the next is drawn\nby the shares, with a demand multiplier of the arrival rate and a fuel_mix multiplying\nthe chances of fuels by name; unset disables it",
+	"car_wait_time_bias":      "typical seconds a car waits for a free station before leaving,\neach car's patience is between bias / 1.5 and bias * 2",
+	"checkout_wait_time_bias": "typical seconds a refueled car waits in the checkout queue, or at its pump for\nroom in it, before driving off without paying, drawn like car_wait_time_bias; 0 waits forever",
+	"simulation_length":       "simulated time, in seconds, as a duration such as 2h or in days such as 7d",
+	"opening_hours":           "time of day the station opens and closes, e.g. {open: 6h, close: 22h}; no cars\narrive while closed and the ones inside at closing are still served; unset never closes",
+	"warmup":                  "first part of the simulation left out of the stats, letting the queues fill up",
+	"histogram_buckets":       "upper bounds in seconds of the histogram buckets of queue waits, fueling and time at station, empty disables them",
+	"sample_interval":         "how often the queue lengths and busy pumps are sampled for --timeseries, 0 disables sampling",
+	"drain_timeout":           "how long cars still at the station when simulation_length is up may take to finish, 0 cuts them off",
+	"random_seed":             "seed of all random draws, 0 picks a new one every run",
+	"realtime":                "run in wall-clock time instead of on a virtual clock",
+	"time_scale":              "wall-clock seconds per simulated second in realtime mode",
+	"max_car_workers":         "goroutines of a realtime run handling a car each; arrivals wait at the entrance\nwhile all are busy, holding up the ones after them; 0 starts 1024",
+	"live_stats":              "live stats of realtime runs, the wall-clock interval between printouts and the metrics\nshown, from simulated_time, cars_spawned, cars_in_refuel_queue, cars_in_checkout_queue,\ncars_checked_out, cars_not_served, cars_balked, cars_drove_off, stations_busy,\nstations_in_repair, registers_busy, cash, arrivals_per_minute, checkouts_per_minute and\nchecked_out_rate; unset prints the first five every second",
+}
+
+// runInit implements the init subcommand
+func runInit(args []string) error {
+	flags := flag.NewFlagSet("init", flag.ExitOnError)
+	force := flags.Bool("force", false, "overwrite an existing file")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: init [--force] [path]")
+		fmt.Fprintln(flags.Output(), "Writes the default config to path (default config.yaml), JSON files are written without comments.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	path := "config.yaml"
+	if flags.NArg() > 0 {
+		path = flags.Arg(0)
+	}
+
+	if _, err := os.Stat(path); err == nil && !*force {
+		return invalidf("config file already exists, use --force to overwrite it: %s", path)
+	}
+
+	var content []byte
+	var err error
+	switch filepath.Ext(path) {
+	case ".json":
+		content, err = json.MarshalIndent(sim.DefaultConfig(), "", "  ")
+	default:
+		content, err = commentedConfig(sim.DefaultConfig())
+	}
+	if err != nil {
+		return fmt.Errorf("encoding config: %w", err)
+	}
+
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("writing config file: %w", err)
+	}
+	fmt.Println("Wrote default config to", path)
+	return nil
+}
+
+// commentedConfig encodes the config as YAML with every key documented
+func commentedConfig(config sim.Config) ([]byte, error) {
+	var doc yaml.Node
+	if err := doc.Encode(config); err != nil {
+		return nil, err
+	}
+
+	doc.HeadComment = "Gas station simulation config"
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		key, value := doc.Content[i], doc.Content[i+1]
+		key.HeadComment = configDocs[key.Value]
+		setFlowStyle(value)
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// setFlowStyle keeps lists and flat values like time ranges on a single line
+func setFlowStyle(node *yaml.Node) {
+	flat := true
+	for _, child := range node.Content {
+		if child.Kind != yaml.ScalarNode {
+			flat = false
+		}
+	}
+	if node.Kind == yaml.SequenceNode || (node.Kind == yaml.MappingNode && flat) {
+		node.Style = yaml.FlowStyle
+	}
+
+	for _, child := range node.Content {
+		setFlowStyle(child)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"pump/sim"
+)
+
+// kafkaWriter publishes the trace events of a run to a Kafka topic, keyed by car so the events
+// of a car land on one partition in order
+type kafkaWriter struct {
+	writer  *kafka.Writer
+	start   time.Time // messages are stamped with it plus their simulated time
+	headers []kafka.Header
+
+	mu  sync.Mutex
+	err error // the first event or batch that failed
+}
+
+// newKafkaWriter connects to the brokers of a target like host:9092,host2:9092/topic
+func newKafkaWriter(target string, run sim.Metadata) (*kafkaWriter, error) {
+	brokers, topic, ok := strings.Cut(target, "/")
+	if !ok || brokers == "" || topic == "" {
+		return nil, errors.New("expected brokers and a topic like localhost:9092/ctc-events")
+	}
+
+	w := &kafkaWriter{start: time.Now(), headers: []kafka.Header{
+		{Key: "run", Value: []byte(run.ID)},
+		{Key: "seed", Value: []byte(strconv.FormatInt(run.Seed, 10))},
+		{Key: "config_hash", Value: []byte(run.ConfigHash)},
+	}}
+	w.writer = &kafka.Writer{
+		Addr:     kafka.TCP(strings.Split(brokers, ",")...),
+		Topic:    topic,
+		Balancer: &kafka.Hash{},
+		// batched in the background, so the run doesn't wait on the brokers
+		Async:        true,
+		BatchTimeout: 100 * time.Millisecond,
+		Completion:   func(_ []kafka.Message, err error) { w.fail(err) },
+	}
+	return w, nil
+}
+
+// fail keeps the first error publishing
+func (w *kafkaWriter) fail(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+}
+
+// failed tells whether publishing has failed, so the remaining events aren't sent
+func (w *kafkaWriter) failed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err != nil
+}
+
+// write queues an event as a JSON message with the ID, seed and config hash of the run as headers
+func (w *kafkaWriter) write(e sim.TraceEvent) {
+	if w.failed() {
+		return
+	}
+	value, err := json.Marshal(e)
+	if err != nil {
+		w.fail(err)
+		return
+	}
+	// only looking up the partitions of the topic can fail here, the rest fails in Completion
+	err = w.writer.WriteMessages(context.Background(), kafka.Message{
+		Key:     []byte(strconv.Itoa(e.Car)),
+		Value:   value,
+		Time:    w.start.Add(time.Duration(e.Time * float64(time.Second))),
+		Headers: w.headers,
+	})
+	w.fail(err)
+}
+
+// Close sends the queued messages and returns the first error publishing them
+func (w *kafkaWriter) Close() error {
+	err := w.writer.Close()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	return err
+}
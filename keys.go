@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"pump/sim"
+)
+
+// watchKeys pauses the realtime simulation when Enter is pressed and resumes it on the next Enter,
+// the dashboard shows the pause itself, otherwise it is announced on the live stats
+func watchKeys(ctx context.Context, simulation *sim.Simulation) {
+	out := simulation.LiveStats
+	lines := bufio.NewScanner(os.Stdin)
+	for lines.Scan() {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if simulation.Paused() {
+			simulation.Resume()
+			if out != nil {
+				fmt.Fprintln(out, "Resumed")
+			}
+			continue
+		}
+		simulation.Pause()
+		if out != nil {
+			fmt.Fprintln(out, "Paused, press Enter to resume")
+		}
+	}
+}
+
+// stepper prints the events of every step of a virtual run to out and waits for Enter before the next,
+// c and Enter lets the run finish without stopping again
+func stepper(out io.Writer) func([]sim.TraceEvent) {
+	lines := bufio.NewScanner(os.Stdin)
+	stepping := true
+
+	return func(events []sim.TraceEvent) {
+		if !stepping {
+			return
+		}
+
+		for _, e := range events {
+			fmt.Fprintln(out, describeEvent(e))
+		}
+		fmt.Fprint(out, "[Enter] next event, [c Enter] continue to the end ")
+
+		// without input there is nobody to step for
+		if !lines.Scan() || strings.TrimSpace(lines.Text()) == "c" {
+			stepping = false
+		}
+	}
+}
+
+// describeEvent formats a trace event as a line like "  12.250 s  car 7 (diesel) started fueling at station 5"
+func describeEvent(e sim.TraceEvent) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%8.3f s  car %d (%s) %s", e.Time, e.Car, e.Fuel, strings.ReplaceAll(e.Event, "_", " "))
+	if e.Station != nil {
+		fmt.Fprintf(&b, " at station %d", *e.Station)
+	}
+	if e.Register != nil {
+		fmt.Fprintf(&b, " at cash register %d", *e.Register)
+	}
+	if e.Amount != nil {
+		fmt.Fprintf(&b, ", %.2f €", *e.Amount)
+	}
+	return b.String()
+}
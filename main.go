@@ -1,399 +1,530 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"math/rand"
+	"io"
 	"os"
-	"sync"
-	"sync/atomic"
+	"path/filepath"
+	"strings"
 	"time"
-)
 
-type FuelType int
+	"gopkg.in/yaml.v3"
 
-const (
-	Gas FuelType = iota // iota generates sequence of integers for each element
-	Diesel
-	LPG
-	Electric
+	"pump/sim"
 )
 
-var fuelTypes = []FuelType{Gas, Diesel, LPG, Electric}
+// config files looked up in the working directory, in order of preference
+var defaultConfigPaths = []string{"config.json", "config.yaml", "config.yml"}
 
-type TimeRange struct {
-	Min, Max float32
-}
-
-type Config struct {
-	FuelPricing       [4]float32   `json:"fuel_pricing"`
-	FuelTypeChance    [4]float32   `json:"fuel_type_chance"`
-	FuelingTime       [4]TimeRange `json:"fueling_time"`
-	StationCounts     [4]int       `json:"station_counts"`
-	CashRegisterCount int          `json:"cash_register_count"`
+// exit codes, so scripts can tell a config to fix from a run that failed
+const (
+	exitFailed = 1 // running the simulation or writing its output failed
+	exitConfig = 2 // the config, the command line or an input file can't be used, like flag errors
+)
 
-	CheckoutTime TimeRange `json:"checkout_time"`
+// configError is an error of the config, the command line or an input file rather than of the run
+type configError struct{ err error }
 
-	CarSpawnChance  float32 `json:"car_spawn_chance"` // checks 10 times a second
-	CarWaitTimeBias float32 `json:"car_wait_time_bias"`
+func (e configError) Error() string { return e.err.Error() }
+func (e configError) Unwrap() error { return e.err }
 
-	SimulationLength time.Duration `json:"simulation_length"` // in seconds
+// invalidf formats a configError
+func invalidf(format string, args ...interface{}) error {
+	return configError{fmt.Errorf(format, args...)}
 }
 
-var (
-	GasStationCh      = make(chan Station)
-	DieselStationCh   = make(chan Station)
-	LPGStationCh      = make(chan Station)
-	ElectricStationCh = make(chan Station)
-
-	carChannel          = make(chan Car)
-	checkoutChannel     = make(chan Car, 10)
-	cashRegisterChannel = make(chan CashRegister)
-
-	doneCh = make(chan bool) // finish sim channel
-
-	ticker = time.NewTicker(100 * time.Millisecond) // 10 times a second
+// errUsage is returned once the usage of a subcommand has been printed, there is nothing more to tell
+var errUsage = configError{errors.New("invalid usage")}
 
-	stats = new(Stats)
-	mu    = new(sync.Mutex)
-)
-
-func atomicAddFloat32(variable *float32, value float32) {
-	mu.Lock()
-	defer mu.Unlock()
+func main() {
+	err := run()
+	if err == nil {
+		return
+	}
+	if !errors.Is(err, errUsage) {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+	}
+	if errors.As(err, new(configError)) {
+		os.Exit(exitConfig)
+	}
+	os.Exit(exitFailed)
+}
 
-	*variable += value
+// run runs the subcommand or, without one, the simulation of the command line
+func run() error {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "init":
+			return runInit(os.Args[2:])
+		case "batch":
+			return runBatch(os.Args[2:])
+		case "compare":
+			return runCompare(os.Args[2:])
+		case "whatif":
+			return runWhatIf(os.Args[2:])
+		case "optimize":
+			return runOptimize(os.Args[2:])
+		case "market":
+			return runMarket(os.Args[2:])
+		case "serve":
+			return runServe(os.Args[2:])
+		case "runs":
+			return runRuns(os.Args[2:])
+		}
+	}
+	return runSimulation()
 }
 
-var config Config
+// runSimulation runs the config with the flags of the command line
+func runSimulation() (err error) {
+	var configPath string
+	flag.StringVar(&configPath, "config", "", "config file to load (default $CTC_CONFIG or "+strings.Join(defaultConfigPaths, ", ")+")")
+	flag.StringVar(&configPath, "c", "", "shorthand for --config")
+	replications := flag.Int("replications", 1, "run the simulation this many times with consecutive seeds and report the mean and 95% confidence interval of key metrics")
+	output := flag.String("output", "text", "format of the final report, one of "+strings.Join(outputFormats, ", "))
+	outputFile := flag.String("output-file", "", "write the final report to this file instead of stdout")
+	tracePath := flag.String("trace", "", "write every step of every car as NDJSON to this file")
+	var quiet, verbose bool
+	flag.BoolVar(&quiet, "quiet", false, "print the final report only, without the live stats of realtime runs")
+	flag.BoolVar(&quiet, "q", false, "shorthand for --quiet")
+	flag.BoolVar(&verbose, "verbose", false, "also print every event of every car as it happens, like --step without stopping")
+	flag.BoolVar(&verbose, "v", false, "shorthand for --verbose")
+	liveJSONPath := flag.String("live-json", "", "write the live stats of realtime runs as an NDJSON object per printout to this file or named pipe, - for stdout")
+	storePath := flag.String("store", "", "append the run, its final stats, a record of every car and its events to this SQLite database, created if needed, or postgres:// URL")
+	outDir := flag.String("out-dir", "", "collect the report, the trace, the time series, the live stats and the effective config in a new directory of the run under this one")
+	progress := flag.Bool("progress", false, "show the share of the simulated time passed and the estimated time left on stderr")
+	tui := flag.Bool("tui", false, "show a full-screen dashboard instead of the live stats of realtime runs")
+	replayPath := flag.String("replay", "", "replay the arrivals recorded in this --trace file instead of spawning cars at random")
+	step := flag.Bool("step", false, "print the events of a virtual run one at a time, advancing on Enter")
+	receiptsPath := flag.String("receipts", "", "write the receipt of every car that paid to this file, as JSON for .json and CSV otherwise")
+	timeSeriesPath := flag.String("timeseries", "", "write the queue samples taken every sample_interval to this file, as JSON for .json and CSV otherwise")
+	htmlPath := flag.String("html", "", "write a self-contained HTML report with charts to this file")
+	influxTarget := flag.String("influx", "", "stream the queue samples taken every sample_interval in InfluxDB line protocol to this file or udp://host:port")
+	mqttTarget := flag.String("mqtt", "", "publish the queue samples taken every sample_interval to a tree of MQTT topics, as tcp://host:port/topic/root")
+	kafkaTarget := flag.String("kafka", "", "publish every event of every car as JSON to a Kafka topic, as brokers/topic like localhost:9092/ctc-events")
+	pngDir := flag.String("png", "", "render the throughput and, with sample_interval, the queue lengths as PNG charts into this directory")
+	pprofAddr := flag.String("pprof", "", "serve net/http/pprof profiles on this address, e.g. :6060, while the simulation runs")
+	checkpointPath := flag.String("checkpoint", "", "write the state of a virtual run to this file every --checkpoint-interval of simulated time, to go on from with --resume")
+	checkpointInterval := flag.Duration("checkpoint-interval", time.Hour, "simulated time between the checkpoints of --checkpoint")
+	resumePath := flag.String("resume", "", "go on with the run of this --checkpoint file, with the config it was written with")
+	benchmark := flag.Int("benchmark", 0, "run the config this many times on virtual time with the same seed and print the wall time, events per second and allocations instead of the report")
+	registerConfigFlags()
+	flag.Parse()
+
+	if !validOutputFormat(*output) {
+		return invalidf("unknown output format %q, expected one of %s", *output, strings.Join(outputFormats, ", "))
+	}
 
-func main() {
-	config = *loadConfig()
-
-	GasStationCh = make(chan Station, config.StationCounts[0])
-	DieselStationCh = make(chan Station, config.StationCounts[1])
-	LPGStationCh = make(chan Station, config.StationCounts[2])
-	ElectricStationCh = make(chan Station, config.StationCounts[3])
-	cashRegisterChannel = make(chan CashRegister, config.CashRegisterCount)
-
-	go spawnCars()
-	go manageGasStation()
-	go printCurrentStats()
-
-	time.Sleep(config.SimulationLength * time.Second)
-	ticker.Stop()
-	doneCh <- true
-
-	// wait for finishing routines
-	time.Sleep(200 * time.Millisecond)
-
-	fmt.Println("-----------------------------------------------------------------")
-	fmt.Println("Total cars: ", stats.CarsSpawnedTotal)
-	fmt.Println("Cars refueled total: ", sumArray(stats.CarsRefueled))
-	fmt.Println("Cars refueled by fuel type: ", stats.CarsRefueled)
-	fmt.Println("Cars checked out total: ", sumArray(stats.CarsCheckedOut))
-	fmt.Println("Cars not served: ", stats.CarsNotServed)
-	fmt.Printf("Cars checked out rate: %.2f %%\n", sumArray(stats.CarsCheckedOut)/float32(stats.CarsSpawnedTotal)*100)
-	fmt.Printf("Cars not served rate: %.2f %%\n", float32(stats.CarsNotServed)/float32(stats.CarsSpawnedTotal)*100)
-	fmt.Println("-------------------------------")
-	fmt.Printf("Average receipt: %.2f €\n", sumArray(stats.CashPerFuel)/sumArray(stats.CarsCheckedOut))
-	fmt.Printf("Average receipt Gas: %.2f €\n", stats.CashPerFuel[0]/float32(stats.CarsCheckedOut[0]))
-	fmt.Printf("Average receipt Diesel: %.2f €\n", stats.CashPerFuel[1]/float32(stats.CarsCheckedOut[1]))
-	fmt.Printf("Average receipt LPG: %.2f €\n", stats.CashPerFuel[2]/float32(stats.CarsCheckedOut[2]))
-	fmt.Printf("Average receipt Electric: %.2f €\n", stats.CashPerFuel[3]/float32(stats.CarsCheckedOut[3]))
-	fmt.Println("-------------------------------")
-	fmt.Printf("Average units refueled: %.2f\n", sumArray(stats.UnitsPerFuel)/sumArray(stats.CarsCheckedOut))
-	fmt.Printf("Average liters of Gas: %.2f l\n", stats.UnitsPerFuel[0]/float32(stats.CarsCheckedOut[0]))
-	fmt.Printf("Average liters of Diesel: %.2f l\n", stats.UnitsPerFuel[1]/float32(stats.CarsCheckedOut[1]))
-	fmt.Printf("Average kilograms of LPG: %.2f kg\n", stats.UnitsPerFuel[2]/float32(stats.CarsCheckedOut[2]))
-	fmt.Printf("Average kilowatt-hours recharged: %.2f kWh\n", stats.UnitsPerFuel[3]/float32(stats.CarsCheckedOut[3]))
-	fmt.Println("-------------------------------")
-	fmt.Printf("Average time spent refueling: %.2f s\n", sumArray(stats.TimeRefueling)/sumArray(stats.CarsRefueled))
-	fmt.Printf("Average time spent GAS: %.2f s\n", stats.TimeRefueling[0]/float32(stats.CarsRefueled[0]))
-	fmt.Printf("Average time spent Diesel: %.2f s\n", stats.TimeRefueling[1]/float32(stats.CarsRefueled[1]))
-	fmt.Printf("Average time spent LPG: %.2f s\n", stats.TimeRefueling[2]/float32(stats.CarsRefueled[2]))
-	fmt.Printf("Average time spent electric: %.2f s\n", stats.TimeRefueling[3]/float32(stats.CarsRefueled[3]))
-	fmt.Printf("Average time spent checking out: %.2f s\n", stats.CheckoutTimeTotal/sumArray(stats.CarsCheckedOut))
-	fmt.Printf("Average time spent in queue before leaving: %.2f s\n", stats.TimeBeforeLeaving/float32(stats.CarsNotServed))
-	fmt.Printf("Average time spent at gas station: %.2f s\n", (sumArray(stats.TimeRefueling)+stats.CheckoutTimeTotal+float32(stats.TimeInCheckoutQueue))/sumArray(stats.CarsCheckedOut))
-	fmt.Println("-----------------------------------------------------------------")
-}
+	var checkpoint *sim.Checkpoint
+	var config *sim.Config
+	path := findConfig(configPath)
+	if *resumePath != "" {
+		// the resumed run goes on as the run of the checkpoint
+		if len(overrides) > 0 {
+			return invalidf("--resume goes on with the config of the checkpoint, drop the config overrides")
+		}
+		checkpoint, err = readCheckpoint(*resumePath)
+		if err != nil {
+			return invalidf("reading checkpoint: %w", err)
+		}
+		config, path = &checkpoint.Config, *resumePath
+	} else {
+		config, err = readConfig(path, overrides)
+		if err != nil {
+			return err
+		}
+	}
 
-func checkoutCar(cashReg CashRegister) {
-	// take out the car
-	car := <-checkoutChannel
-	atomic.AddInt32(&stats.CarsInCheckoutQueue, -1)
-	atomicAddFloat32(&stats.TimeInCheckoutQueue, float32(time.Since(car.CheckoutQueueStart).Milliseconds())/1000.0)
+	if *pprofAddr != "" {
+		if err := servePprof(*pprofAddr); err != nil {
+			return invalidf("serving pprof: %w", err)
+		}
+	}
 
-	checkoutTime := config.CheckoutTime.Min + (rand.Float32() * (config.CheckoutTime.Max - config.CheckoutTime.Min))
-	atomicAddFloat32(&stats.CheckoutTimeTotal, checkoutTime)
-	atomicAddFloat32(&stats.CashPerFuel[car.Fuel], car.Receipt)
+	if *benchmark > 0 {
+		return runBenchmark(*config, *benchmark)
+	}
 
-	//fmt.Printf("Checking out car ID: %v, at cash register ID: %v, for %vs %v\n", car.ID, cashReg.ID, checkoutTime, time.Now())
-	time.Sleep(time.Duration(checkoutTime*1000) * time.Millisecond)
+	if quiet && (verbose || *tui || *step) {
+		return invalidf("--quiet prints the final report only, drop --verbose, --tui and --step")
+	}
+	if verbose && (*tui || *step || *replications > 1) {
+		return invalidf("--verbose needs a single run without --tui and --step, which show the events their own way")
+	}
+	if *liveJSONPath != "" && (!config.Realtime || *replications > 1) {
+		return invalidf("--live-json needs a single --realtime run, virtual runs have no live stats")
+	}
+	if *progress && (*tui || *step || verbose || *replications > 1) {
+		return invalidf("--progress needs a single run without --tui, --step and --verbose, which take over the terminal")
+	}
+	if *step && (config.Realtime || *replications > 1) {
+		return invalidf("--step needs a single virtual run, drop --realtime and --replications")
+	}
 
-	atomic.AddInt32(&stats.CarsCheckedOut[car.Fuel], 1)
-	cashRegisterChannel <- cashReg
-}
+	if *receiptsPath != "" && *replications > 1 {
+		return invalidf("--receipts needs a single run, drop --replications")
+	}
+	if *htmlPath != "" && *replications > 1 {
+		return invalidf("--html needs a single run, drop --replications")
+	}
+	if *influxTarget != "" && (config.SampleInterval <= 0 || *replications > 1) {
+		return invalidf("--influx needs sample_interval, e.g. --sample-interval 10s, and a single run")
+	}
+	if *mqttTarget != "" && (config.SampleInterval <= 0 || *replications > 1) {
+		return invalidf("--mqtt needs sample_interval, e.g. --sample-interval 10s, and a single run")
+	}
+	if *kafkaTarget != "" && *replications > 1 {
+		return invalidf("--kafka needs a single run, drop --replications")
+	}
+	if *pngDir != "" && *replications > 1 {
+		return invalidf("--png needs a single run, drop --replications")
+	}
+	if *timeSeriesPath != "" && (config.SampleInterval <= 0 || *replications > 1) {
+		return invalidf("--timeseries needs sample_interval, e.g. --sample-interval 1m, and a single run")
+	}
 
-func refuelCar(car Car) {
-	// car is waiting for a station to free up
-	atomic.AddInt32(&stats.CarsInRefuelQueue, 1)
-
-	// assign correct station
-	select {
-	case station := <-getStationCh(car.Fuel):
-		// car moves from queue to station
-		atomic.AddInt32(&stats.CarsInRefuelQueue, -1)
-		// refuel the car for random time within bounds
-		refuelTime := station.FuelingTime.Min + (rand.Float32() * (station.FuelingTime.Max - station.FuelingTime.Min))
-		//fmt.Printf("Refueling car ID: %v, fuel type: %v, for %vs\n", car.ID, getFuelTypeName(car.Fuel), refuelTime)
-		time.Sleep(time.Duration(refuelTime*1000) * time.Millisecond)
-
-		// calculate price of fuel
-		units := (float32(refuelTime) / float32(station.FuelingTime.Max)) * float32(car.FuelTankSize)
-		price := units * config.FuelPricing[car.Fuel]
-		car.Receipt = price
-
-		// stats
-		atomicAddFloat32(&stats.UnitsPerFuel[car.Fuel], units)
-		atomicAddFloat32(&stats.TimeRefueling[car.Fuel], refuelTime)
-		atomic.AddInt32(&stats.CarsRefueled[car.Fuel], 1)
-
-		// forward car to checkout queue
-		car.CheckoutQueueStart = time.Now()
-		checkoutChannel <- car
-		atomic.AddInt32(&stats.CarsInCheckoutQueue, 1)
-
-		// return station back to channel
-		getStationCh(station.Fuel) <- station
-	case <-time.After(time.Second * time.Duration(car.WaitTime)):
-		// car left without refueling
-		atomicAddFloat32(&stats.TimeBeforeLeaving, car.WaitTime)
-		atomic.AddInt32(&stats.CarsNotServed, 1)
-		atomic.AddInt32(&stats.CarsInRefuelQueue, -1)
+	if (*checkpointPath != "" || checkpoint != nil) && (config.Realtime || *replications > 1 || *replayPath != "" || config.CarSource != nil) {
+		return invalidf("--checkpoint and --resume need a single virtual run of random arrivals, drop --realtime, --replications, --replay and car_source")
+	}
+	if *checkpointPath != "" && *checkpointInterval <= 0 {
+		return invalidf("--checkpoint-interval must be positive, got %v", *checkpointInterval)
 	}
-}
 
-func manageGasStation() {
-	// spawn stations
-	id := 0
-	for i := 0; i < len(config.StationCounts); i++ {
-		for j := 0; j < config.StationCounts[i]; j++ {
-			getStationCh(fuelTypes[i]) <- *NewStation(id, fuelTypes[i], config.FuelingTime[i])
-			id++
+	if *storePath != "" {
+		if *replications > 1 {
+			return invalidf("--store needs a single run, drop --replications")
+		}
+		// stored runs can be repeated
+		if config.RandomSeed == 0 {
+			config.RandomSeed = time.Now().UnixNano()
 		}
 	}
 
-	id = 0
-	for i := 0; i < config.CashRegisterCount; i++ {
-		cashRegisterChannel <- *NewCashRegister(id)
-		id++
+	var runDir string
+	if *outDir != "" {
+		if *replications > 1 {
+			return invalidf("--out-dir needs a single run, drop --replications")
+		}
+		// the seed names the directory
+		if config.RandomSeed == 0 {
+			config.RandomSeed = time.Now().UnixNano()
+		}
+		runDir, err = makeRunDir(*outDir, config.RandomSeed)
+		if err != nil {
+			return fmt.Errorf("creating run directory: %w", err)
+		}
+		if err := writeEffectiveConfig(runDir, path, *config); err != nil {
+			return fmt.Errorf("writing effective config: %w", err)
+		}
+		// files given on their own still go where they were asked to
+		if *outputFile == "" {
+			*outputFile = filepath.Join(runDir, reportName(*output))
+		}
+		if *tracePath == "" {
+			*tracePath = filepath.Join(runDir, "trace.ndjson")
+		}
+		if *timeSeriesPath == "" && config.SampleInterval > 0 {
+			*timeSeriesPath = filepath.Join(runDir, "timeseries.csv")
+		}
 	}
 
-	for {
-		select {
-		case car := <-carChannel:
-			go refuelCar(car)
-		case cashReg := <-cashRegisterChannel:
-			go checkoutCar(cashReg)
+	if *replications > 1 {
+		results, err := sim.Replicate(context.Background(), *config, *replications)
+		if err != nil {
+			return fmt.Errorf("running replications: %w", err)
+		}
+		err = writeOutput(*outputFile, func(w io.Writer) error { return writeReplications(w, *output, results) })
+		if err != nil {
+			return fmt.Errorf("writing report: %w", err)
 		}
+		return nil
 	}
-}
 
-func spawnCars() {
-	for {
-		select {
-		case <-ticker.C:
-			if rand.Float32() < config.CarSpawnChance {
-				carChannel <- *NewCar(getFuelTypeByChance(), config.CarWaitTimeBias)
-				atomic.AddInt32(&stats.CarsSpawnedTotal, 1)
+	// errors of the outputs, the others are written regardless
+	var errs []error
+	defer func() { err = errors.Join(append(errs, err)...) }()
+
+	simulation := sim.New(*config)
+	if checkpoint != nil {
+		if simulation, err = sim.FromCheckpoint(checkpoint); err != nil {
+			return invalidf("resuming from checkpoint: %w", err)
+		}
+	}
+	if *checkpointPath != "" {
+		simulation.CheckpointInterval = *checkpointInterval
+		simulation.Checkpoints = func(c *sim.Checkpoint) error { return writeCheckpoint(*checkpointPath, c) }
+	}
+	simulation.LiveStats = os.Stdout
+	if (*output != "text" && *outputFile == "") || *liveJSONPath == "-" {
+		// keep stdout parseable
+		simulation.LiveStats = os.Stderr
+	}
+	switch *liveJSONPath {
+	case "":
+	case "-":
+		simulation.LiveJSON = os.Stdout
+	default:
+		liveJSON, err := os.Create(*liveJSONPath)
+		if err != nil {
+			return fmt.Errorf("creating live JSON output: %w", err)
+		}
+		defer liveJSON.Close()
+		simulation.LiveJSON = liveJSON
+	}
+	if *tui {
+		if !config.Realtime {
+			return invalidf("--tui needs --realtime, virtual runs finish without anything to watch")
+		}
+		simulation.Dashboard = simulation.LiveStats
+		simulation.LiveStats = nil
+	}
+	// virtual runs print nothing live unless --step or --verbose
+	if runDir != "" && simulation.LiveStats != nil && (config.Realtime || *step || verbose) {
+		liveLog, err := os.Create(filepath.Join(runDir, "live.log"))
+		if err != nil {
+			return fmt.Errorf("creating live stats log: %w", err)
+		}
+		defer liveLog.Close()
+		simulation.LiveStats = io.MultiWriter(simulation.LiveStats, liveLog)
+	}
+	if *step {
+		simulation.Step = stepper(simulation.LiveStats)
+	}
+	if verbose {
+		out := simulation.LiveStats
+		simulation.TraceEvents = func(e sim.TraceEvent) { fmt.Fprintln(out, describeEvent(e)) }
+	}
+	if quiet {
+		simulation.LiveStats = nil
+	}
+	var receipts []sim.Receipt
+	if *receiptsPath != "" {
+		simulation.Receipts = func(r sim.Receipt) { receipts = append(receipts, r) }
+	}
+	if *influxTarget != "" {
+		influx, err := newInfluxWriter(*influxTarget, simulation.Metadata())
+		if err != nil {
+			return fmt.Errorf("opening InfluxDB output: %w", err)
+		}
+		defer func() {
+			if err := influx.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("writing InfluxDB output: %w", err))
 			}
-		case <-doneCh:
-			return
+		}()
+		simulation.QueueSamples = influx.write
+	}
+	if *mqttTarget != "" {
+		mqtt, err := newMQTTWriter(*mqttTarget, *config, simulation.Metadata())
+		if err != nil {
+			return fmt.Errorf("connecting to MQTT broker: %w", err)
 		}
+		defer func() {
+			if err := mqtt.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("publishing to MQTT: %w", err))
+			}
+		}()
+		simulation.QueueSamples = chain(simulation.QueueSamples, mqtt.write)
 	}
-}
-
-func printCurrentStats() {
-	tick := 1
-	for {
-		select {
-		case <-ticker.C:
-			if tick%10 == 0 {
-				fmt.Println("Cars spawned: ", stats.CarsSpawnedTotal)
-				fmt.Println("Cars in queue to refuel: ", stats.CarsInRefuelQueue)
-				fmt.Println("Cars in queue to checkout: ", stats.CarsInCheckoutQueue)
-				fmt.Println("Cars checked out: ", sumArray(stats.CarsCheckedOut))
+	if *kafkaTarget != "" {
+		kafka, err := newKafkaWriter(*kafkaTarget, simulation.Metadata())
+		if err != nil {
+			return fmt.Errorf("opening Kafka output: %w", err)
+		}
+		defer func() {
+			if err := kafka.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("publishing to Kafka: %w", err))
 			}
-			tick++
-		case <-doneCh:
-			return
+		}()
+		simulation.TraceEvents = chain(simulation.TraceEvents, kafka.write)
+	}
+	var store *sql.DB
+	recorder := newRunRecorder()
+	if *storePath != "" {
+		store, err = openStore(*storePath)
+		if err != nil {
+			return fmt.Errorf("opening results database: %w", err)
 		}
+		defer store.Close()
+		simulation.TraceEvents = chain(simulation.TraceEvents, recorder.record)
 	}
-}
-
-func loadConfig() *Config {
-	jsonBytes, err := os.ReadFile("config.json")
-	if err != nil {
-		fmt.Println("Error reading config file:", err)
-		return nil
+	served := new(throughput)
+	if *pngDir != "" {
+		simulation.Observe(served)
 	}
 
-	var config Config
-	err = json.Unmarshal(jsonBytes, &config)
-	if err != nil {
-		fmt.Println("Error unmarshalling JSON:", err)
-		return nil
+	if *replayPath != "" {
+		arrivals, err := readArrivals(*replayPath)
+		if err == nil {
+			err = simulation.Replay(arrivals)
+		}
+		if err != nil {
+			return invalidf("reading replay trace: %w", err)
+		}
 	}
 
-	return &config
-}
-
-var carID = 0
-
-func NewCar(fuel FuelType, waitTimeBias float32) *Car {
-	c := new(Car)
-	c.Fuel = fuel
-	c.ID = carID
-	carID++
-
-	min := waitTimeBias / 1.5
-	max := waitTimeBias * 2
-	c.WaitTime = min + (rand.Float32() * (max - min))
+	var trace *bufio.Writer
+	if *tracePath != "" {
+		traceFile, err := os.Create(*tracePath)
+		if err != nil {
+			return fmt.Errorf("creating trace file: %w", err)
+		}
+		defer traceFile.Close()
 
-	if fuel == Gas {
-		c.FuelTankSize = (rand.Intn(17) + 8) * 5 // 40-120 l
-	} else if fuel == Diesel {
-		c.FuelTankSize = (rand.Intn(21) + 9) * 5 // 45-150 l
-	} else if fuel == LPG {
-		c.FuelTankSize = (rand.Intn(18) + 7) * 5 // 35-120 kg
-	} else if fuel == Electric {
-		c.FuelTankSize = (rand.Intn(19) + 6) * 5 // 30-120 kWh
+		trace = bufio.NewWriter(traceFile)
+		simulation.Trace = trace
 	}
 
-	return c
-}
-
-func NewStation(id int, fuel FuelType, time TimeRange) *Station {
-	s := new(Station)
-	s.ID = id
-	s.Fuel = fuel
-	s.FuelingTime = time
+	ctx, cancel := context.WithCancel(context.Background())
+	// a resumed run has no config file to reload
+	if checkpoint == nil {
+		go watchConfig(ctx, path, simulation)
+	}
+	if config.Realtime {
+		go watchKeys(ctx, simulation)
+	}
+	stopProgress := func() {}
+	if *progress {
+		stopProgress = showProgress(os.Stderr, simulation)
+	}
+	if err := simulation.Run(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("running the simulation: %w", err))
+	}
+	stopProgress()
+	cancel()
 
-	return s
-}
+	if trace != nil {
+		if err := trace.Flush(); err != nil {
+			errs = append(errs, fmt.Errorf("writing trace file: %w", err))
+		}
+	}
 
-func NewCashRegister(id int) *CashRegister {
-	c := new(CashRegister)
-	c.ID = id
+	if store != nil {
+		id, err := storeRun(store, path, simulation.Results(), recorder)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("storing the run: %w", err))
+		} else {
+			fmt.Fprintf(os.Stderr, "Stored the run as run %d\n", id)
+		}
+	}
+	if *receiptsPath != "" {
+		if err := writeReceipts(*receiptsPath, simulation.Metadata().ID, receipts); err != nil {
+			errs = append(errs, fmt.Errorf("writing receipts: %w", err))
+		}
+	}
+	if *timeSeriesPath != "" {
+		if err := writeTimeSeries(*timeSeriesPath, simulation.Metadata().ID, simulation.TimeSeries()); err != nil {
+			errs = append(errs, fmt.Errorf("writing time series: %w", err))
+		}
+	}
+	if *htmlPath != "" {
+		err := writeOutput(*htmlPath, func(w io.Writer) error { return simulation.Results().WriteHTML(w, simulation.TimeSeries()) })
+		if err != nil {
+			errs = append(errs, fmt.Errorf("writing HTML report: %w", err))
+		}
+	}
+	if *pngDir != "" {
+		if err := writePNGs(*pngDir, simulation.TimeSeries(), served.hourly); err != nil {
+			errs = append(errs, fmt.Errorf("writing PNG charts: %w", err))
+		}
+	}
 
-	return c
+	err = writeOutput(*outputFile, func(w io.Writer) error { return writeResults(w, *output, simulation.Results()) })
+	if err != nil {
+		errs = append(errs, fmt.Errorf("writing report: %w", err))
+	}
+	if runDir != "" {
+		fmt.Println("Wrote the run to", runDir)
+	}
+	return nil
 }
 
-func getStationCh(fuel FuelType) chan Station {
-	switch fuel {
-	case Gas:
-		return GasStationCh
-	case Diesel:
-		return DieselStationCh
-	case LPG:
-		return LPGStationCh
-	case Electric:
-		return ElectricStationCh
-	default:
-		return nil
+// chain returns a callback such as TraceEvents or QueueSamples handing every value to both
+func chain[T any](first, then func(T)) func(T) {
+	if first == nil {
+		return then
+	}
+	return func(v T) {
+		first(v)
+		then(v)
 	}
 }
 
-func getFuelTypeByChance() FuelType {
-	var ranges [4][2]float32
-	var total float32 = 0.0
-	for i := range config.FuelTypeChance {
-		ranges[i][0] = total
-		ranges[i][1] = total + config.FuelTypeChance[i]
-		total += config.FuelTypeChance[i]
+// readConfig loads the config file and applies the environment and then the command line overrides,
+// the error tells what is wrong when the result can't be used
+func readConfig(path string, overrides []override) (*sim.Config, error) {
+	config, err := loadConfig(path)
+	if err != nil {
+		return nil, err
 	}
 
-	probability := rand.Float32()
-
-	var selected int = 0
-	for i := range ranges {
-		if probability >= ranges[i][0] && probability <= ranges[i][1] {
-			selected = i
-		}
+	if err := applyEnv(config); err != nil {
+		return nil, invalidf("applying environment variables: %w", err)
+	}
+	if err := applyOverrides(config, overrides); err != nil {
+		return nil, invalidf("applying command line overrides: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, invalidf("invalid config:\n%w", err)
 	}
 
-	return fuelTypes[selected]
+	return config, nil
 }
 
-func getFuelTypeName(fuel FuelType) string {
-	switch fuel {
-	case Gas:
-		return "Gas"
-	case Diesel:
-		return "Diesel"
-	case LPG:
-		return "LPG"
-	case Electric:
-		return "Electric"
-	default:
-		return "None"
+// findConfig picks the config file given on the command line, then $CTC_CONFIG and
+// finally the first default config file that exists
+func findConfig(path string) string {
+	if path != "" {
+		return path
+	}
+	if path := os.Getenv("CTC_CONFIG"); path != "" {
+		return path
 	}
-}
 
-type Car struct {
-	ID                 int
-	Fuel               FuelType
-	WaitTime           float32 // max waiting time when all pumps busy
-	FuelTankSize       int     // liters/kg/kwh
-	Receipt            float32
-	CheckoutQueueStart time.Time
+	for _, path := range defaultConfigPaths {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return defaultConfigPaths[0]
 }
 
-type Station struct {
-	ID          int
-	Fuel        FuelType
-	FuelingTime TimeRange
-}
+// loadConfig parses the config file as YAML or JSON depending on its extension
+func loadConfig(path string) (*sim.Config, error) {
+	configBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, invalidf("reading config file: %w", err)
+	}
 
-type CashRegister struct {
-	ID int
-}
+	var config sim.Config
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(configBytes, &config)
+		if err != nil {
+			return nil, invalidf("unmarshalling YAML of %s: %w", path, err)
+		}
+	default:
+		err = json.Unmarshal(configBytes, &config)
+		if err != nil {
+			return nil, invalidf("unmarshalling JSON of %s: %w", path, err)
+		}
+	}
 
-type Stats struct {
-	// car counts
-	CarsSpawnedTotal    int32
-	CarsNotServed       int32
-	CarsRefueled        [4]int32
-	CarsCheckedOut      [4]int32
-	CarsInRefuelQueue   int32
-	CarsInCheckoutQueue int32
-
-	// money
-	CashPerFuel       [4]float32
-	CheckoutTimeTotal float32
-
-	// fuel
-	UnitsPerFuel  [4]float32
-	TimeRefueling [4]float32
-
-	// general time
-	TimeBeforeLeaving   float32
-	TimeInCheckoutQueue float32
+	return &config, nil
 }
 
-func sumArray(arr interface{}) float32 {
-	var sum float32
-	switch arr := arr.(type) {
-	case [4]int32:
-		for _, v := range arr {
-			sum += float32(v)
-		}
-	case [4]float32:
-		for _, v := range arr {
-			sum += v
-		}
+// readArrivals reads the arrivals of a trace file for replaying
+func readArrivals(path string) ([]sim.Arrival, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
 	}
-	return sum
+	defer file.Close()
+
+	return sim.ReadArrivals(file)
 }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"pump/sim"
+)
+
+// marketFile lists the competing sites of a market and the demand they share
+type marketFile struct {
+	sim.MarketConfig `yaml:",inline"`
+	Sites            []marketSite `json:"sites" yaml:"sites"`
+}
+
+// marketSite is a site of a market with its own config file
+type marketSite struct {
+	Name     string  `json:"name" yaml:"name"`
+	Config   string  `json:"config" yaml:"config"` // relative to the market file
+	Distance float32 `json:"distance" yaml:"distance"`
+}
+
+// runMarket implements the market subcommand
+func runMarket(args []string) error {
+	flags := flag.NewFlagSet("market", flag.ExitOnError)
+	seed := flags.Int64("seed", 0, "random seed of the market, overriding random_seed")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: market [--seed N] market.yaml")
+		fmt.Fprintln(flags.Output(), "Simulates the sites of the market file competing for the same cars and prints the report of every site and their market shares.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errUsage
+	}
+
+	market, err := loadMarket(flags.Arg(0))
+	if err != nil {
+		return invalidf("reading market file: %w", err)
+	}
+	if *seed != 0 {
+		market.RandomSeed = *seed
+	}
+	if err := market.Validate(); err != nil {
+		return invalidf("invalid market:\n%w", err)
+	}
+
+	var sites []sim.Site
+	for _, site := range market.Sites {
+		config, err := scenarioConfig(filepath.Dir(flags.Arg(0)), batchScenario{Name: site.Name, Config: site.Config})
+		if err != nil {
+			return invalidf("invalid site %s:\n%w", site.Name, err)
+		}
+		sites = append(sites, sim.Site{Name: site.Name, Config: *config, Distance: site.Distance})
+	}
+
+	m := sim.NewMarket(market.MarketConfig, sites)
+	m.Run(context.Background())
+	sim.PrintMarket(os.Stdout, m.Results())
+	return nil
+}
+
+func loadMarket(path string) (*marketFile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var market marketFile
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &market)
+	default:
+		err = json.Unmarshal(content, &market)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(market.Sites) == 0 {
+		return nil, fmt.Errorf("no sites in %s", path)
+	}
+
+	for i := range market.Sites {
+		if market.Sites[i].Name == "" {
+			market.Sites[i].Name = strings.TrimSuffix(filepath.Base(market.Sites[i].Config), filepath.Ext(market.Sites[i].Config))
+		}
+	}
+	return &market, nil
+}
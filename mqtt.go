@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"pump/sim"
+)
+
+// mqttWriter publishes the queue samples of a run to a tree of MQTT topics, one value per topic
+// like ctc/run1/queues/gas, retained so dashboards subscribing later get the latest values
+type mqttWriter struct {
+	client    mqtt.Client
+	prefix    string
+	stations  map[string]int // by fuel type, for their utilization
+	registers int
+
+	mu  sync.Mutex
+	err error // the first publish that failed
+}
+
+// newMQTTWriter connects to the broker of a target like tcp://localhost:1883/ctc/run1, the path
+// being the root of the topics, ctc without one, and publishes the metadata of the run as JSON
+func newMQTTWriter(target string, config sim.Config, run sim.Metadata) (*mqttWriter, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("expected a broker like tcp://localhost:1883/ctc/run1, got %q", target)
+	}
+	prefix := strings.Trim(u.Path, "/")
+	if prefix == "" {
+		prefix = "ctc"
+	}
+
+	w := &mqttWriter{prefix: prefix, stations: map[string]int{}, registers: config.CashRegisterCount}
+	for name, fc := range config.Fuels {
+		w.stations[name] = len(fc.StationConfigs())
+	}
+
+	options := mqtt.NewClientOptions().AddBroker(u.Scheme + "://" + u.Host).SetClientID("ctc-" + strconv.FormatInt(time.Now().UnixNano(), 36))
+	if u.User != nil {
+		password, _ := u.User.Password()
+		options.SetUsername(u.User.Username()).SetPassword(password)
+	}
+	w.client = mqtt.NewClient(options)
+	token := w.client.Connect()
+	if !token.WaitTimeout(10 * time.Second) {
+		return nil, fmt.Errorf("timed out connecting to %s", u.Host)
+	}
+	if err := token.Error(); err != nil {
+		return nil, err
+	}
+
+	metadata, _ := json.Marshal(run)
+	w.publish("run", string(metadata))
+	if w.err != nil {
+		w.client.Disconnect(0)
+		return nil, w.err
+	}
+	return w, nil
+}
+
+// write publishes a sample, the checkout queue and busy cash registers at the root and the queue,
+// busy stations, stations in repair and utilization of every fuel type under its name
+func (w *mqttWriter) write(s sim.QueueSample) {
+	w.publish("simulated_time", strconv.FormatFloat(s.Time, 'f', -1, 64))
+	w.publish("queues/checkout", strconv.Itoa(int(s.CarsInCheckoutQueue)))
+	w.publish("registers_busy", strconv.Itoa(int(s.RegistersBusy)))
+	w.publish("utilization/registers", percent(s.RegistersBusy, w.registers))
+	for _, f := range s.Fuels {
+		w.publish("queues/"+f.Name, strconv.Itoa(int(f.CarsInRefuelQueue)))
+		w.publish("stations_busy/"+f.Name, strconv.Itoa(int(f.StationsBusy)))
+		w.publish("stations_in_repair/"+f.Name, strconv.Itoa(int(f.StationsInRepair)))
+		w.publish("utilization/"+f.Name, percent(f.StationsBusy, w.stations[f.Name]))
+	}
+}
+
+// percent formats the share of busy in total as a percentage with two decimals
+func percent(busy int32, total int) string {
+	if total == 0 {
+		return "0"
+	}
+	return strconv.FormatFloat(float64(busy)/float64(total)*100, 'f', 2, 64)
+}
+
+// publish sends a value to the topic under the root, keeping the first error
+func (w *mqttWriter) publish(topic, value string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return
+	}
+	token := w.client.Publish(w.prefix+"/"+topic, 0, true, value)
+	if !token.WaitTimeout(10 * time.Second) {
+		w.err = fmt.Errorf("timed out publishing %s/%s", w.prefix, topic)
+	} else if err := token.Error(); err != nil {
+		w.err = err
+	}
+}
+
+// Close disconnects from the broker and returns the first error publishing
+func (w *mqttWriter) Close() error {
+	w.client.Disconnect(250)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"pump/sim"
+)
+
+// runOptimize implements the optimize subcommand
+func runOptimize(args []string) error {
+	var objectives []string
+	for name := range sim.Objectives {
+		objectives = append(objectives, name)
+	}
+	slices.Sort(objectives)
+
+	flags := flag.NewFlagSet("optimize", flag.ExitOnError)
+	configPath := flags.String("config", "", "config file to start from (default $CTC_CONFIG or "+strings.Join(defaultConfigPaths, ", ")+")")
+	objective := flags.String("objective", "not_served", "key metric to optimize, one of "+strings.Join(objectives, ", "))
+	pumps := flags.Int("pumps", 0, "stations of all fuel types together, at most (default the stations of the config)")
+	registers := flags.Int("registers", 0, "cash registers, at most (default the cash registers of the config)")
+	replications := flags.Int("replications", 3, "runs with consecutive seeds every layout is evaluated with")
+	iterations := flags.Int("iterations", 50, "layouts tried after the one of the config")
+	temperature := flags.Float64("temperature", 0.1, "start temperature of simulated annealing relative to the objective of the config, 0 only accepts improvements")
+	verbose := flags.Bool("v", false, "print every layout tried")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: optimize [--config path] [--objective name] [--pumps N] [--registers N] [flags]")
+		fmt.Fprintln(flags.Output(), "Searches the station counts and cash register count for the best objective within the budgets by simulated annealing.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	opt := sim.OptimizeConfig{PumpBudget: *pumps, MaxRegisters: *registers, Replications: *replications, Iterations: *iterations, Temperature: *temperature}
+	var ok bool
+	if opt.Objective, ok = sim.Objectives[*objective]; !ok {
+		return invalidf("unknown objective %q, expected one of %s", *objective, strings.Join(objectives, ", "))
+	}
+	if *replications < 1 || *iterations < 0 || *temperature < 0 {
+		return invalidf("--replications must be at least 1, --iterations and --temperature must not be negative")
+	}
+
+	config, err := loadConfig(findConfig(*configPath))
+	if err != nil {
+		return err
+	}
+	if err := config.Validate(); err != nil {
+		return invalidf("invalid config:\n%w", err)
+	}
+	if opt.PumpBudget == 0 {
+		for _, fc := range config.Fuels {
+			opt.PumpBudget += len(fc.StationConfigs())
+		}
+	}
+	if opt.MaxRegisters == 0 {
+		opt.MaxRegisters = config.CashRegisterCount
+	}
+
+	tried := 0
+	best, err := sim.Optimize(context.Background(), *config, opt, func(step sim.OptimizeStep) {
+		tried++
+		if *verbose || step.Best {
+			marker := " "
+			if step.Best {
+				marker = "*"
+			} else if step.Accepted {
+				marker = "+"
+			}
+			fmt.Printf("%s %-60s %10.2f ± %.2f\n", marker, strings.Join(step.Layout.Overrides(), " "), step.Estimate.Mean, step.Estimate.HalfWidth)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("optimizing: %w", err)
+	}
+	sim.PrintOptimized(os.Stdout, best, tried)
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"pump/sim"
+)
+
+// makeRunDir creates the directory of a run under parent, named after the time it started and
+// its seed like 20261014-153000-seed42, with a number appended for runs started in the same second
+func makeRunDir(parent string, seed int64) (string, error) {
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("%s-seed%d", time.Now().Format("20060102-150405"), seed)
+	dir := filepath.Join(parent, name)
+	for i := 2; ; i++ {
+		err := os.Mkdir(dir, 0755)
+		if !errors.Is(err, fs.ErrExist) {
+			return dir, err
+		}
+		dir = filepath.Join(parent, fmt.Sprintf("%s-%d", name, i))
+	}
+}
+
+// writeEffectiveConfig writes the config a run started with, its seed included, in the format of
+// configPath so the run can be repeated with --config
+func writeEffectiveConfig(dir, configPath string, config sim.Config) error {
+	var content []byte
+	var err error
+	ext := filepath.Ext(configPath)
+	switch ext {
+	case ".yaml", ".yml":
+		content, err = yaml.Marshal(config)
+	default:
+		ext = ".json"
+		content, err = json.MarshalIndent(config, "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "config"+ext), content, 0644)
+}
+
+// reportName is the file of the final report in a run directory
+func reportName(format string) string {
+	if format == "text" {
+		return "report.txt"
+	}
+	return "report." + format
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"pump/sim"
+)
+
+// report formats of --output
+var outputFormats = []string{"text", "json", "csv"}
+
+func validOutputFormat(format string) bool {
+	for _, f := range outputFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// writeOutput writes the report with write to the file at path, or to stdout when path is empty
+func writeOutput(path string, write func(w io.Writer) error) error {
+	if path == "" {
+		return write(os.Stdout)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// writeResults writes the final report of a single run in the format
+func writeResults(w io.Writer, format string, results sim.Results) error {
+	switch format {
+	case "json":
+		return writeJSON(w, results.Report())
+	case "csv":
+		return writeCSV(w, []sim.Results{results})
+	default:
+		results.Print(w)
+		return nil
+	}
+}
+
+// writeReplications writes the summary of replications in the format, JSON including every run
+func writeReplications(w io.Writer, format string, results []sim.Results) error {
+	switch format {
+	case "json":
+		reports := make([]sim.Report, len(results))
+		for i, r := range results {
+			reports[i] = r.Report()
+		}
+		return writeJSON(w, struct {
+			Estimates []sim.Estimate `json:"estimates"`
+			Runs      []sim.Report   `json:"runs"`
+		}{sim.Summarize(results), reports})
+	case "csv":
+		return writeCSV(w, results)
+	default:
+		sim.PrintSummary(w, results)
+		return nil
+	}
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("encoding JSON: %w", err)
+	}
+	return nil
+}
+
+// csvHeader are the columns of the CSV report, one row per fuel type and run followed by a total row
+var csvHeader = []string{
+	"run", "started", "version", "config_hash", "seed", "fuel", "unit", "cars_refueled", "cars_checked_out", "cars_not_served", "not_served_rate", "units", "revenue",
+	"avg_receipt", "avg_units", "avg_time_refueling", "avg_time_in_refuel_queue", "pump_failures", "downtime", "utilization",
+}
+
+func writeCSV(w io.Writer, results []sim.Results) error {
+	out := csv.NewWriter(w)
+	out.Write(csvHeader)
+
+	for _, r := range results {
+		run := []string{"", "", "", "", strconv.FormatInt(r.Config.RandomSeed, 10)}
+		if m := r.Metadata; m != nil {
+			run = []string{m.ID, m.Started.Format(time.RFC3339Nano), m.Version, m.ConfigHash, run[4]}
+		}
+		averages := r.Report().Averages
+		for i, f := range r.Stats.Fuels {
+			fa := averages.Fuels[i]
+			out.Write(csvRow(run, f, fa.Unit, fa.NotServedRate, fa.Receipt, fa.Units, fa.TimeRefueling, fa.TimeInRefuel, fa.Utilization))
+		}
+
+		total := r.Stats.Total()
+		avgUnits := sim.Number(float64(total.Units) / float64(total.CarsCheckedOut))
+		out.Write(csvRow(run, total, "", averages.NotServedRate, averages.Receipt, avgUnits, averages.TimeRefueling, averages.TimeInRefuel, averages.Utilization))
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+func csvRow(run []string, f sim.FuelStats, unit string, notServedRate, receipt, units, timeRefueling, timeInRefuelQueue, utilization sim.Number) []string {
+	return append(run[:len(run):len(run)],
+		f.Name, unit,
+		strconv.Itoa(int(f.CarsRefueled)), strconv.Itoa(int(f.CarsCheckedOut)),
+		strconv.Itoa(int(f.CarsNotServed)), csvNumber(notServedRate),
+		csvNumber(sim.Number(f.Units)), csvNumber(sim.Number(f.Cash)),
+		csvNumber(receipt), csvNumber(units), csvNumber(timeRefueling), csvNumber(timeInRefuelQueue),
+		strconv.Itoa(int(f.PumpFailures)), csvNumber(sim.Number(f.Downtime)), csvNumber(utilization),
+	)
+}
+
+// csvNumber formats the number as short as float32 precision allows, undefined ones as empty cells
+func csvNumber(n sim.Number) string {
+	if math.IsNaN(float64(n)) || math.IsInf(float64(n), 0) {
+		return ""
+	}
+	return strconv.FormatFloat(float64(n), 'f', -1, 32)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+
+	"pump/sim"
+)
+
+// size of the PNG charts, about a slide
+const pngWidth, pngHeight = 10 * vg.Inch, 5 * vg.Inch
+
+// throughput counts the cars that paid in every simulated hour since the start
+type throughput struct {
+	sim.BaseObserver
+	hourly []int
+}
+
+func (t *throughput) OnCheckout(e sim.TraceEvent) {
+	hour := int(e.Time / 3600)
+	for len(t.hourly) <= hour {
+		t.hourly = append(t.hourly, 0)
+	}
+	t.hourly[hour]++
+}
+
+// writePNGs renders the throughput by hour and, given the samples taken every sample_interval,
+// the queue lengths over time to PNG files in dir
+func writePNGs(dir string, samples []sim.QueueSample, hourly []int) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	if len(samples) > 0 {
+		p := plot.New()
+		p.Title.Text = "Queue lengths"
+		p.X.Label.Text = "minutes"
+		p.Y.Label.Text = "cars"
+		p.Add(plotter.NewGrid())
+		p.Legend.Top = true
+		for i, series := range []struct {
+			name  string
+			value func(s sim.QueueSample) int32
+		}{
+			{"refuel queue", func(s sim.QueueSample) int32 { return s.CarsInRefuelQueue }},
+			{"checkout queue", func(s sim.QueueSample) int32 { return s.CarsInCheckoutQueue }},
+			{"registers busy", func(s sim.QueueSample) int32 { return s.RegistersBusy }},
+		} {
+			points := make(plotter.XYs, len(samples))
+			for j, s := range samples {
+				points[j] = plotter.XY{X: s.Time / 60, Y: float64(series.value(s))}
+			}
+			line, err := plotter.NewLine(points)
+			if err != nil {
+				return err
+			}
+			line.Color = pngColors[i%len(pngColors)]
+			line.Width = vg.Points(1.5)
+			p.Add(line)
+			p.Legend.Add(series.name, line)
+		}
+		if err := p.Save(pngWidth, pngHeight, filepath.Join(dir, "queues.png")); err != nil {
+			return err
+		}
+	}
+
+	p := plot.New()
+	p.Title.Text = "Throughput"
+	p.X.Label.Text = "hour"
+	p.Y.Label.Text = "cars paid"
+	p.Add(plotter.NewGrid())
+	values := make(plotter.Values, len(hourly))
+	labels := make([]string, len(hourly))
+	for hour, cars := range hourly {
+		values[hour] = float64(cars)
+		labels[hour] = fmt.Sprint(hour)
+	}
+	if len(hourly) > 0 {
+		bars, err := plotter.NewBarChart(values, vg.Points(max(2, min(40, 500/float64(len(hourly))))))
+		if err != nil {
+			return err
+		}
+		bars.Color = pngColors[0]
+		bars.LineStyle.Width = 0
+		p.Add(bars)
+		// room for the half bars at both ends
+		p.X.Min, p.X.Max = -0.5, float64(len(hourly))-0.5
+		if len(hourly) <= 48 {
+			p.NominalX(labels...)
+		}
+	}
+	return p.Save(pngWidth, pngHeight, filepath.Join(dir, "throughput.png"))
+}
+
+// pngColors are used by the series in turn, the same as the HTML report
+var pngColors = []color.Color{
+	color.RGBA{0x1f, 0x77, 0xb4, 0xff},
+	color.RGBA{0xff, 0x7f, 0x0e, 0xff},
+	color.RGBA{0x2c, 0xa0, 0x2c, 0xff},
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"pump/sim"
+)
+
+// how often the progress bar is redrawn, and its width in characters
+const (
+	progressInterval = 200 * time.Millisecond
+	progressWidth    = 30
+)
+
+// showProgress redraws a bar of the simulated time passed and the estimated wall-clock time left
+// on w until the returned function is called, which draws the final state and ends the line
+func showProgress(w io.Writer, simulation *sim.Simulation) func() {
+	start := time.Now()
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				drawProgress(w, simulation.Progress(), time.Since(start))
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+		drawProgress(w, simulation.Progress(), time.Since(start))
+		fmt.Fprintln(w)
+	}
+}
+
+// drawProgress overwrites the line with a bar like "[#######-------]  50.0 %  ETA 12s"
+func drawProgress(w io.Writer, progress float64, wall time.Duration) {
+	filled := int(progress * progressWidth)
+	eta := "?"
+	if progress > 0 {
+		eta = time.Duration(float64(wall) * (1 - progress) / progress).Round(time.Second).String()
+	}
+	// the trailing spaces clear what is left of a longer ETA
+	fmt.Fprintf(w, "\r[%s%s] %5.1f %%  ETA %s   ", strings.Repeat("#", filled), strings.Repeat("-", progressWidth-filled), progress*100, eta)
+}
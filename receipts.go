@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"path/filepath"
+	"strconv"
+
+	"pump/sim"
+)
+
+// runReceipt is a receipt in the JSON of --receipts, with the ID of its run
+type runReceipt struct {
+	Run string `json:"run"`
+	sim.Receipt
+}
+
+// writeReceipts writes the receipts of the run to the file at path, as JSON for a .json file and CSV otherwise
+func writeReceipts(path, run string, receipts []sim.Receipt) error {
+	return writeOutput(path, func(w io.Writer) error {
+		if filepath.Ext(path) == ".json" {
+			rows := make([]runReceipt, len(receipts))
+			for i, r := range receipts {
+				rows[i] = runReceipt{run, r}
+			}
+			return writeJSON(w, rows)
+		}
+		return writeReceiptsCSV(w, run, receipts)
+	})
+}
+
+func writeReceiptsCSV(w io.Writer, run string, receipts []sim.Receipt) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"run", "car", "fuel", "units", "unit_price", "amount", "shop", "payment", "arrived", "fueling_started", "fueling_finished", "paid"})
+
+	seconds := func(t float64) string { return strconv.FormatFloat(t, 'f', 3, 64) }
+	for _, r := range receipts {
+		out.Write([]string{
+			run, strconv.Itoa(r.Car), r.Fuel,
+			csvNumber(sim.Number(r.Units)), csvNumber(sim.Number(r.UnitPrice)), csvNumber(sim.Number(r.Amount)), csvNumber(sim.Number(r.Shop)), r.Payment,
+			seconds(r.Arrived), seconds(r.FuelingStarted), seconds(r.FuelingFinished), seconds(r.Paid),
+		})
+	}
+
+	out.Flush()
+	return out.Error()
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"pump/sim"
+)
+
+// watchConfig reloads the tunable config values into the running simulation whenever
+// the config file changes or the process receives SIGHUP, announcing it on the live stats
+func watchConfig(ctx context.Context, path string, simulation *sim.Simulation) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	poll := time.NewTicker(time.Second)
+	defer poll.Stop()
+
+	modTime := fileModTime(path)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		case <-poll.C:
+			if fileModTime(path).Equal(modTime) {
+				continue
+			}
+		}
+		modTime = fileModTime(path)
+
+		// an invalid file keeps the previous values
+		config, err := readConfig(path, overrides)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error reloading config:", err)
+			continue
+		}
+		simulation.Reload(*config)
+		if simulation.LiveStats != nil {
+			fmt.Fprintln(simulation.LiveStats, "Reloaded car_spawn_chance, arrivals_per_hour, interarrival, fuel pricing and checkout_time from", path)
+		}
+	}
+}
+
+func fileModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
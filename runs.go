@@ -0,0 +1,166 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"pump/sim"
+)
+
+// runRuns implements the runs subcommand
+func runRuns(args []string) error {
+	flags := flag.NewFlagSet("runs", flag.ExitOnError)
+	storePath := flags.String("store", "results.db", "SQLite database or postgres:// URL the runs were stored in")
+	output := flags.String("output", "text", "format of the report of show, text or json")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: runs [--store results.db] [--output json] list | show ID | rm ID...")
+		fmt.Fprintln(flags.Output(), "Lists the runs appended to a results database with --store, prints the report of one or deletes runs.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	command, ids := flags.Arg(0), flags.Args()[min(1, flags.NArg()):]
+	switch {
+	case command == "list" && len(ids) == 0:
+	case command == "show" && len(ids) == 1:
+	case command == "rm" && len(ids) > 0:
+	default:
+		flags.Usage()
+		return errUsage
+	}
+	if *output != "text" && *output != "json" {
+		return invalidf("unknown output format %q, expected text or json", *output)
+	}
+
+	// listing a mistyped file shouldn't create an empty database
+	if !strings.Contains(*storePath, "://") {
+		if _, err := os.Stat(*storePath); err != nil {
+			return invalidf("opening results database: %w", err)
+		}
+	}
+	store, err := openStore(*storePath)
+	if err != nil {
+		return fmt.Errorf("opening results database: %w", err)
+	}
+	defer store.Close()
+
+	switch command {
+	case "list":
+		return listRuns(store)
+	case "show":
+		id, err := strconv.ParseInt(ids[0], 10, 64)
+		if err != nil {
+			return invalidf("invalid run ID %q", ids[0])
+		}
+		return showRun(store, id, *output)
+	default:
+		for _, arg := range ids {
+			id, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil {
+				return invalidf("invalid run ID %q", arg)
+			}
+			if err := removeRun(store, id); err != nil {
+				return err
+			}
+			fmt.Println("Removed run", id)
+		}
+		return nil
+	}
+}
+
+// listRuns prints a row with the key figures of every stored run, the oldest first
+func listRuns(store *sql.DB) error {
+	rows, err := store.Query(`SELECT id, run, started_at, seed, config_path, config_hash, cars_spawned, checked_out_rate, cash FROM runs ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "id\trun\tstarted\tseed\tconfig\tconfig hash\tspawned\tchecked out %\tcash\t")
+	for rows.Next() {
+		var id, seed int64
+		var started, configPath string
+		var run, configHash sql.NullString // of runs stored before they were recorded
+		var spawned int
+		var checkedOutRate sql.NullFloat64
+		var cash float64
+		if err := rows.Scan(&id, &run, &started, &seed, &configPath, &configHash, &spawned, &checkedOutRate, &cash); err != nil {
+			return err
+		}
+		rate := "-"
+		if checkedOutRate.Valid {
+			rate = fmt.Sprintf("%.2f", checkedOutRate.Float64)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\t%s\t%d\t%s\t%.2f\t\n", id, orDash(run), started, seed, configPath, orDash(configHash), spawned, rate, cash)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// orDash returns the string or - for NULL
+func orDash(s sql.NullString) string {
+	if !s.Valid {
+		return "-"
+	}
+	return s.String
+}
+
+// showRun prints what was stored of a run followed by its final report, or the JSON
+// report as it was stored
+func showRun(store *sql.DB, id int64, output string) error {
+	var configPath, report string
+	var cars, events int
+	err := store.QueryRow(`SELECT config_path, report,
+		(SELECT COUNT(*) FROM cars WHERE run_id = runs.id), (SELECT COUNT(*) FROM events WHERE run_id = runs.id)
+		FROM runs WHERE id = $1`, id).Scan(&configPath, &report, &cars, &events)
+	if errors.Is(err, sql.ErrNoRows) {
+		return invalidf("no run %d in the results database", id)
+	}
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		fmt.Println(report)
+		return nil
+	}
+	runs, err := sim.ReadRuns(strings.NewReader(report))
+	if err != nil {
+		return fmt.Errorf("reading the report of run %d: %w", id, err)
+	}
+	fmt.Printf("Stored run %d of %s with %d cars and %d events\n\n", id, configPath, cars, events)
+	runs[0].Print(os.Stdout)
+	return nil
+}
+
+// removeRun deletes a stored run with its cars and events
+func removeRun(store *sql.DB, id int64) error {
+	tx, err := store.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"events", "cars"} {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE run_id = $1`, id); err != nil {
+			return err
+		}
+	}
+	result, err := tx.Exec(`DELETE FROM runs WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return invalidf("no run %d in the results database", id)
+	}
+	return tx.Commit()
+}
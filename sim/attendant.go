@@ -0,0 +1,42 @@
+package sim
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// AttendantStats are the stats of a single forecourt attendant
+type AttendantStats struct {
+	ID         int     `json:"id"`
+	CarsServed int32   `json:"cars_served"`
+	BusyTime   float32 `json:"busy_time"` // seconds fueling cars within the observed part of the run
+}
+
+// newAttendants creates the IDs of the attendants and their stats
+func (s *Simulation) newAttendants() []int {
+	var attendants []int
+	for id := 0; id < s.config.AttendantCount; id++ {
+		attendants = append(attendants, id)
+		s.stats.Attendants = append(s.stats.Attendants, AttendantStats{ID: id})
+		s.attendantsBusySince = append(s.attendantsBusySince, -1)
+	}
+	return attendants
+}
+
+// attendantArrived records the attendant coming to the car at its attended station at now,
+// fueling starts then; the wait counts only for cars that found no attendant free
+func (s *Simulation) attendantArrived(car *Car, attendant int, now time.Time, waited bool) {
+	if waited {
+		wait := max(now.Sub(car.FuelingStart), 0)
+		atomic.AddInt32(&car.stats.CarsWaitedForAttendant, 1)
+		atomicAddFloat32(&car.stats.TimeWaitingForAttendant, float32(wait.Milliseconds())/1000.0)
+	}
+	car.FuelingStart = now
+	atomic.AddInt32(&car.stats.Attendants[attendant].CarsServed, 1)
+	s.startBusy(s.attendantsBusySince, attendant, now.Sub(s.start))
+}
+
+// attendantLeft frees the attendant once the car is refueled at now
+func (s *Simulation) attendantLeft(attendant int, now time.Time) {
+	s.endBusy(s.attendantsBusySince, attendant, &s.stats.Attendants[attendant].BusyTime, now.Sub(s.start))
+}
@@ -0,0 +1,95 @@
+package sim
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// benchSeed keeps the benchmarks doing the same work on every run
+const benchSeed = 42
+
+// benchConfig is a simulated day of the default config
+func benchConfig() Config {
+	config := DefaultConfig()
+	config.RandomSeed = benchSeed
+	config.SimulationLength = Duration(24 * time.Hour)
+	return config
+}
+
+// BenchmarkRunVirtual runs with and without reusing the cars that have left, for what the car pool saves
+func BenchmarkRunVirtual(b *testing.B) {
+	config := benchConfig()
+	for _, pooled := range []bool{true, false} {
+		name := "pooled"
+		if !pooled {
+			name = "unpooled"
+		}
+		b.Run(name, func(b *testing.B) {
+			benchmarkRuns(b, func() (*Simulation, error) {
+				simulation := New(config)
+				if !pooled {
+					simulation.carPool = nil
+				}
+				return simulation, simulation.Run(context.Background())
+			})
+		})
+	}
+}
+
+func BenchmarkRunRealtimeFakeClock(b *testing.B) {
+	config := benchConfig()
+	config.Realtime = true
+	config.TimeScale = 1
+	config.SimulationLength = Duration(10 * time.Minute)
+	benchmarkRuns(b, func() (*Simulation, error) {
+		simulation := New(config)
+		clock := NewFakeClock(time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC))
+		simulation.Clock = clock
+		return simulation, runAdvancing(simulation, clock, time.Second, nil)
+	})
+}
+
+// benchmarkRuns times run, reporting the allocations per run and the events, cars and allocations
+// per car of a run besides
+func benchmarkRuns(b *testing.B, run func() (*Simulation, error)) {
+	b.ReportAllocs()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	var events int
+	var cars int32
+	for i := 0; i < b.N; i++ {
+		simulation, err := run()
+		if err != nil {
+			b.Fatal(err)
+		}
+		events, cars = simulation.Events(), simulation.Results().Stats.CarsSpawnedTotal
+	}
+	runtime.ReadMemStats(&after)
+
+	if events > 0 {
+		b.ReportMetric(float64(events), "events/run")
+	}
+	b.ReportMetric(float64(cars), "cars/run")
+	b.ReportMetric(float64(after.Mallocs-before.Mallocs)/float64(b.N)/float64(max(cars, 1)), "allocs/car")
+}
+
+// runAdvancing runs the simulation, moving the clock on by step whenever its goroutines had the
+// chance to get to their next wait, calling each before every step when set
+func runAdvancing(simulation *Simulation, clock *FakeClock, step time.Duration, each func()) error {
+	done := make(chan error)
+	go func() { done <- simulation.Run(context.Background()) }()
+	for {
+		select {
+		case err := <-done:
+			return err
+		default:
+			runtime.Gosched()
+			if each != nil {
+				each()
+			}
+			clock.Advance(step)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package sim
+
+import "math"
+
+// defaultTaperFrom is the state of charge charging slows down at when taper_from is unset
+const defaultTaperFrom = 0.8
+
+// ChargingCurve makes a fuel charge a battery: the session runs between the fill levels as states of charge,
+// at full power up to taper_from and slowing down linearly above it to end_rate of full power at a full
+// battery. Fueling time is then the time a full charge would take without the taper.
+type ChargingCurve struct {
+	TaperFrom float32 `json:"taper_from" yaml:"taper_from"` // 0 means 0.8
+	EndRate   float32 `json:"end_rate" yaml:"end_rate"`     // share of full power left at a full battery
+}
+
+func validateCharging(curve *ChargingCurve, key string, invalid func(key, format string, args ...interface{})) {
+	if curve == nil {
+		return
+	}
+	if curve.TaperFrom < 0 || curve.TaperFrom > 1 {
+		invalid(key+".charging.taper_from", "must be between 0 and 1, got %v", curve.TaperFrom)
+	}
+	if curve.EndRate <= 0 || curve.EndRate > 1 {
+		invalid(key+".charging.end_rate", "must be greater than 0 and at most 1, got %v", curve.EndRate)
+	}
+}
+
+// sessionShare returns the time charging from the state of charge from to to takes, as a share of a full
+// charge at full power
+func (c *ChargingCurve) sessionShare(from, to float64) float64 {
+	taperFrom := float64(c.TaperFrom)
+	if taperFrom == 0 {
+		taperFrom = defaultTaperFrom
+	}
+
+	// full power below the taper
+	share := max(0, min(to, taperFrom)-from)
+	if to <= taperFrom || taperFrom >= 1 {
+		return share
+	}
+
+	// the rate 1 - k(s - taperFrom) integrates to -ln(1 - k(s - taperFrom)) / k
+	k := (1 - float64(c.EndRate)) / (1 - taperFrom)
+	rateAt := func(soc float64) float64 { return 1 - k*(soc-taperFrom) }
+	if k == 0 {
+		return share + to - max(from, taperFrom)
+	}
+	return share + (math.Log(rateAt(max(from, taperFrom)))-math.Log(rateAt(to)))/k
+}
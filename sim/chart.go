@@ -0,0 +1,188 @@
+package sim
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+)
+
+// chart is a line or bar chart of the report, rendered by the HTML report
+type chart struct {
+	Title  string
+	XLabel string
+	YLabel string
+	X      []float64 // shared by the series
+	Series []chartSeries
+	Bars   bool     // a bar per x of the first series instead of lines
+	Labels []string // of the bars, by x
+}
+
+type chartSeries struct {
+	Name string
+	Y    []float64
+}
+
+// chartColors are used by the series in turn
+var chartColors = []string{"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd", "#8c564b"}
+
+// charts returns the charts of a run, the queue lengths need the samples of sample_interval
+func (r Results) charts(samples []QueueSample) []chart {
+	var charts []chart
+	if len(samples) > 0 {
+		queues := chart{Title: "Queue lengths", XLabel: "minutes", YLabel: "cars",
+			Series: []chartSeries{{Name: "refuel queue"}, {Name: "checkout queue"}, {Name: "registers busy"}}}
+		for _, s := range samples {
+			queues.X = append(queues.X, s.Time/60)
+			queues.Series[0].Y = append(queues.Series[0].Y, float64(s.CarsInRefuelQueue))
+			queues.Series[1].Y = append(queues.Series[1].Y, float64(s.CarsInCheckoutQueue))
+			queues.Series[2].Y = append(queues.Series[2].Y, float64(s.RegistersBusy))
+		}
+		charts = append(charts, queues)
+	}
+
+	total := r.Stats.Total()
+	revenue := chart{Title: "Revenue by hour", XLabel: "hour", YLabel: "€", Bars: true, Series: []chartSeries{{Name: "revenue"}}}
+	for hour, cash := range total.HourlyRevenue {
+		revenue.X = append(revenue.X, float64(hour))
+		revenue.Labels = append(revenue.Labels, fmt.Sprint(hour))
+		revenue.Series[0].Y = append(revenue.Series[0].Y, float64(cash))
+	}
+	if len(revenue.X) > 0 {
+		charts = append(charts, revenue)
+	}
+
+	for _, waits := range []struct {
+		title   string
+		samples Samples
+	}{
+		{"Refuel queue wait", total.RefuelQueueWaits},
+		{"Checkout queue wait", total.CheckoutQueueWaits},
+	} {
+		if len(waits.samples) == 0 {
+			continue
+		}
+		c := chart{Title: waits.title, XLabel: "seconds, up to", YLabel: "cars", Bars: true, Series: []chartSeries{{Name: "cars"}}}
+		for i, bucket := range waits.samples.Histogram(r.waitBounds(waits.samples)) {
+			c.X = append(c.X, float64(i))
+			if math.IsInf(float64(bucket.UpTo), 1) {
+				c.Labels = append(c.Labels, "more")
+			} else {
+				c.Labels = append(c.Labels, fmt.Sprintf("%.3g", float64(bucket.UpTo)))
+			}
+			c.Series[0].Y = append(c.Series[0].Y, float64(bucket.Count))
+		}
+		charts = append(charts, c)
+	}
+	return charts
+}
+
+// waitBounds returns the histogram_buckets, or ten buckets of even width up to the longest wait
+func (r Results) waitBounds(samples Samples) []float32 {
+	if len(r.Config.HistogramBuckets) > 0 {
+		return r.Config.HistogramBuckets
+	}
+	longest := float32(0)
+	for _, v := range samples {
+		longest = max(longest, v)
+	}
+	width := float32(niceCeil(float64(longest) / 10))
+	bounds := make([]float32, 10)
+	for i := range bounds {
+		bounds[i] = width * float32(i+1)
+	}
+	return bounds
+}
+
+// niceCeil rounds v up to 1, 2 or 5 times a power of ten, 1 for v <= 0
+func niceCeil(v float64) float64 {
+	if v <= 0 || math.IsNaN(v) {
+		return 1
+	}
+	pow := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if m*pow >= v {
+			return m * pow
+		}
+	}
+	return 10 * pow
+}
+
+// svg dimensions of a chart and its plot area
+const (
+	chartWidth, chartHeight = 720, 300
+	plotLeft, plotRight     = 70, 700
+	plotTop, plotBottom     = 40, 250
+)
+
+// svg renders the chart as an inline SVG element
+func (c chart) svg() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`,
+		chartWidth, chartHeight, chartWidth, chartHeight)
+	fmt.Fprintf(&b, `<text x="%d" y="20" font-size="15" font-weight="bold">%s</text>`, plotLeft, html.EscapeString(c.Title))
+
+	top := 0.0
+	for _, s := range c.Series {
+		for _, y := range s.Y {
+			top = max(top, y)
+		}
+	}
+	top = niceCeil(top)
+	y := func(v float64) float64 { return plotBottom - v/top*(plotBottom-plotTop) }
+
+	// horizontal grid with the y axis labels
+	for i := 0; i <= 5; i++ {
+		v := top * float64(i) / 5
+		fmt.Fprintf(&b, `<line x1="%d" x2="%d" y1="%.1f" y2="%.1f" stroke="#ddd"/>`, plotLeft, plotRight, y(v), y(v))
+		fmt.Fprintf(&b, `<text x="%d" y="%.1f" text-anchor="end">%.7g</text>`, plotLeft-6, y(v)+4, v)
+	}
+	fmt.Fprintf(&b, `<text x="14" y="%d" transform="rotate(-90 14 %d)" text-anchor="middle">%s</text>`,
+		(plotTop+plotBottom)/2, (plotTop+plotBottom)/2, html.EscapeString(c.YLabel))
+	fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle">%s</text>`, (plotLeft+plotRight)/2, chartHeight-8, html.EscapeString(c.XLabel))
+
+	if c.Bars {
+		c.svgBars(&b, y)
+	} else {
+		c.svgLines(&b, y)
+	}
+	b.WriteString(`</svg>`)
+	return b.String()
+}
+
+func (c chart) svgBars(b *strings.Builder, y func(float64) float64) {
+	slot := float64(plotRight-plotLeft) / float64(len(c.X))
+	every := int(math.Ceil(float64(len(c.X)) / 24)) // labels that fit under the bars
+	for i, v := range c.Series[0].Y {
+		x := plotLeft + float64(i)*slot
+		fmt.Fprintf(b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s: %.2f</title></rect>`,
+			x+slot*0.1, y(v), slot*0.8, plotBottom-y(v), chartColors[0], html.EscapeString(c.Labels[i]), v)
+		if i%every == 0 {
+			fmt.Fprintf(b, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`, x+slot/2, plotBottom+16, html.EscapeString(c.Labels[i]))
+		}
+	}
+}
+
+func (c chart) svgLines(b *strings.Builder, y func(float64) float64) {
+	left, right := c.X[0], c.X[len(c.X)-1]
+	if right == left {
+		right = left + 1
+	}
+	x := func(v float64) float64 { return plotLeft + (v-left)/(right-left)*(plotRight-plotLeft) }
+	for i := 0; i <= 6; i++ {
+		v := left + (right-left)*float64(i)/6
+		fmt.Fprintf(b, `<text x="%.1f" y="%d" text-anchor="middle">%.5g</text>`, x(v), plotBottom+16, v)
+	}
+
+	for i, s := range c.Series {
+		color := chartColors[i%len(chartColors)]
+		points := make([]string, len(s.Y))
+		for j, v := range s.Y {
+			points[j] = fmt.Sprintf("%.1f,%.1f", x(c.X[j]), y(v))
+		}
+		fmt.Fprintf(b, `<polyline fill="none" stroke="%s" stroke-width="1.5" points="%s"/>`, color, strings.Join(points, " "))
+		// legend
+		fmt.Fprintf(b, `<rect x="%d" y="%d" width="10" height="10" fill="%s"/><text x="%d" y="%d">%s</text>`,
+			plotLeft+240+i*130, 10, color, plotLeft+254+i*130, 19, html.EscapeString(s.Name))
+	}
+}
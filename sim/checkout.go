@@ -0,0 +1,94 @@
+package sim
+
+import (
+	"fmt"
+	"io"
+	"slices"
+)
+
+// checkout policies, how the cars waiting to check out are spread over the cash registers
+const (
+	CheckoutShared   = "shared"   // one queue served by every register in turn
+	CheckoutShortest = "shortest" // a queue per register, cars join the shortest one
+)
+
+func validateCheckoutPolicy(c *Config, invalid func(key, format string, args ...interface{})) {
+	switch c.CheckoutPolicy {
+	case "", CheckoutShared:
+	case CheckoutShortest:
+		if len(c.Shifts) > 0 {
+			invalid("checkout_policy", "%s doesn't support shifts", CheckoutShortest)
+		}
+	default:
+		invalid("checkout_policy", "must be %s or %s, got %q", CheckoutShared, CheckoutShortest, c.CheckoutPolicy)
+	}
+}
+
+// registerQueues reports whether every cash register has a queue of its own
+func (c *Config) registerQueues() bool {
+	return c.CheckoutPolicy == CheckoutShortest
+}
+
+// joinCheckoutLine records the car joining the checkout queue, picking the register with the fewest cars
+// queued or checking out at it when every register has a queue of its own
+func (s *Simulation) joinCheckoutLine(car *Car) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checkoutTickets++
+	car.CheckoutTicket = s.checkoutTickets
+	s.checkoutLine = append(s.checkoutLine, car.CheckoutTicket)
+	if s.registerLoad == nil {
+		return
+	}
+	car.Register = 0
+	for id, load := range s.registerLoad {
+		if load < s.registerLoad[car.Register] {
+			car.Register = id
+		}
+	}
+	s.registerLoad[car.Register]++
+}
+
+// leaveCheckoutLine takes the car out of the checkout queue, counting a car that gets to a register
+// while one that joined before it still waits as out of turn. A car leaving without checking out
+// also frees its place at its register.
+func (s *Simulation) leaveCheckoutLine(car *Car, served bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.Index(s.checkoutLine, car.CheckoutTicket)
+	if i < 0 {
+		return
+	}
+	if served && i > 0 {
+		s.stats.CheckoutsOutOfTurn++
+	}
+	s.checkoutLine = slices.Delete(s.checkoutLine, i, i+1)
+	if !served && s.registerLoad != nil {
+		s.registerLoad[car.Register]--
+	}
+}
+
+// registerFreed counts the car checked out at the register as gone from it
+func (s *Simulation) registerFreed(register CashRegister) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.registerLoad != nil {
+		s.registerLoad[register.ID]--
+	}
+}
+
+// printCheckoutPolicy prints how fairly the cars got to the cash registers under the checkout policy
+func (r Results) printCheckoutPolicy(w io.Writer) {
+	if r.Config.CheckoutPolicy == "" {
+		return
+	}
+	checkouts := 0
+	for _, register := range r.Stats.Registers {
+		checkouts += int(register.CarsCheckedOut)
+	}
+	fmt.Fprintf(w, "Checkout policy %s: %d checkouts out of turn (%.2f %%), ahead of a car that joined the checkout queue earlier\n",
+		r.Config.CheckoutPolicy, r.Stats.CheckoutsOutOfTurn, float32(r.Stats.CheckoutsOutOfTurn)/float32(checkouts)*100)
+}
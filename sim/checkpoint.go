@@ -0,0 +1,603 @@
+package sim
+
+import (
+	"cmp"
+	"container/heap"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// Checkpoint is the state of a virtual run between two of its events: the pending events, the cars
+// waiting, refueling and checking out, the stations, cash registers and attendants, the position in the
+// random stream and the stats so far. Simulation.Checkpoints is handed one every CheckpointInterval, and
+// FromCheckpoint goes on from it to the same results the run has without the interruption. It is written
+// as JSON.
+type Checkpoint struct {
+	Run    Metadata      // of the run, which the resumed run keeps
+	Config Config        // effective, with the seed and the reloaded fields
+	Time   time.Duration // simulated time reached
+
+	state checkpointState
+}
+
+// checkpointState is what a checkpoint restores, the cars are referred to by their index in Cars,
+// the stations, cash registers and attendants by ID
+type checkpointState struct {
+	Start      time.Time `json:"start"`    // simulated wall-clock time of the start of the run
+	Events     int       `json:"events"`   // processed so far
+	Sequence   int       `json:"sequence"` // of the next event scheduled
+	Draws      uint64    `json:"draws"`    // from the random stream of the seed
+	CarID      int       `json:"car_id"`   // of the next car
+	CarsInside int32     `json:"cars_inside"`
+	Weather    int32     `json:"weather"`
+
+	Cars     []checkpointCar     `json:"cars"`
+	Pending  []checkpointEvent   `json:"pending"` // in the order they come up
+	Sessions []checkpointSession `json:"sessions,omitempty"`
+
+	FreeStations   []int                  `json:"free_stations"` // fuel type by fuel type
+	RefuelQueues   [][]int                `json:"refuel_queues"` // indexed by FuelType
+	FreeRegisters  []int                  `json:"free_registers"`
+	FreeAttendants []int                  `json:"free_attendants"`
+	AttendantQueue []checkpointBlocked    `json:"attendant_queue"`
+	CheckoutQueue  []int                  `json:"checkout_queue"`
+	RegisterQueues [][]int                `json:"register_queues,omitempty"` // indexed by register ID
+	Blocked        []checkpointBlocked    `json:"blocked"`
+	Failures       []int                  `json:"failures"`    // indexed by FuelType
+	PowerBanks     []*checkpointPowerBank `json:"power_banks"` // indexed by FuelType, null without shared power
+	Forecourts     []*checkpointForecourt `json:"forecourts"`  // indexed by FuelType, null without lanes
+	RegisterPool   checkpointStaff        `json:"register_pool"`
+	AttendantPool  checkpointStaff        `json:"attendant_pool"`
+
+	StationsBusySince   busyPeriods     `json:"stations_busy_since"`
+	RegistersBusySince  busyPeriods     `json:"registers_busy_since"`
+	AttendantsBusySince busyPeriods     `json:"attendants_busy_since"`
+	WeatherSince        busyPeriods     `json:"weather_since"`
+	RefuelWaiting       checkpointGauge `json:"refuel_waiting"`
+	CheckoutWaiting     checkpointGauge `json:"checkout_waiting"`
+	CheckoutTickets     int             `json:"checkout_tickets"`
+	CheckoutLine        []int           `json:"checkout_line"`
+	RegisterLoad        []int           `json:"register_load"`
+	Series              []QueueSample   `json:"series"`
+
+	Stats   Stats               `json:"stats"`   // names, IDs and the stats updated under mu
+	Shared  Stats               `json:"shared"`  // the counters, in the one shard of a virtual run
+	Samples []checkpointSamples `json:"samples"` // of the fuels, which Stats leaves out
+}
+
+type checkpointCar struct {
+	Car
+	HeldPump int  `json:"held_pump"` // station ID, -1 for none
+	Events   int  `json:"events"`
+	Gone     bool `json:"gone"`
+}
+
+type checkpointEvent struct {
+	Kind    string             `json:"kind"`
+	At      time.Duration      `json:"at"`
+	Seq     int                `json:"seq"`
+	Car     int                `json:"car"` // -1 for none
+	ID      int                `json:"id"`
+	Seconds float32            `json:"seconds,omitempty"`
+	Fueling *checkpointFueling `json:"fueling,omitempty"`
+	Session int                `json:"session"` // index in Sessions, -1 for none
+}
+
+type checkpointFueling struct {
+	Car        int           `json:"car"`
+	Station    int           `json:"station"`
+	Attendant  int           `json:"attendant"`
+	RefuelTime float32       `json:"refuel_time"`
+	UnitPrice  float32       `json:"unit_price"`
+	Start      time.Duration `json:"start"`
+}
+
+type checkpointSession struct {
+	Remaining float64           `json:"remaining"`
+	Round     int               `json:"round"`
+	Fueling   checkpointFueling `json:"fueling"`
+}
+
+type checkpointPowerBank struct {
+	Sessions []int         `json:"sessions"`
+	Since    time.Duration `json:"since"`
+}
+
+type checkpointBlocked struct {
+	Car     int `json:"car"`
+	Station int `json:"station"`
+}
+
+type checkpointForecourt struct {
+	Occupied []bool      `json:"occupied"`
+	Finished []bool      `json:"finished"`
+	Since    []time.Time `json:"since"`
+	Open     []bool      `json:"open"`
+}
+
+type checkpointStaff struct {
+	Wanted  int   `json:"wanted"`
+	OnDuty  int   `json:"on_duty"`
+	OffDuty []int `json:"off_duty"`
+}
+
+type checkpointGauge struct {
+	Length int           `json:"length"`
+	Since  time.Duration `json:"since"`
+}
+
+type checkpointSamples struct {
+	RefuelQueueWaits   Samples `json:"refuel_queue_waits"`
+	CheckoutQueueWaits Samples `json:"checkout_queue_waits"`
+	FuelingTimes       Samples `json:"fueling_times"`
+	TimesAtStation     Samples `json:"times_at_station"`
+}
+
+// the names of the event kinds in checkpoints
+var eventKinds = map[eventKind]string{
+	eventEndWarmup:  "end_warmup",
+	eventSample:     "sample",
+	eventArrival:    "arrival",
+	eventSpawnTick:  "spawn_tick",
+	eventRenege:     "renege",
+	eventDriveOff:   "drive_off",
+	eventFueled:     "fueled",
+	eventCharged:    "charged",
+	eventPaidAtPump: "paid_at_pump",
+	eventFailure:    "failure",
+	eventRepaired:   "repaired",
+	eventCheckedOut: "checked_out",
+	eventShift:      "shift",
+	eventWeather:    "weather",
+}
+
+type checkpointJSON struct {
+	Run    Metadata         `json:"run"`
+	Config Config           `json:"config"`
+	Time   time.Duration    `json:"time"`
+	State  *checkpointState `json:"state"`
+}
+
+func (c Checkpoint) MarshalJSON() ([]byte, error) {
+	return json.Marshal(checkpointJSON{Run: c.Run, Config: c.Config, Time: c.Time, State: &c.state})
+}
+
+func (c *Checkpoint) UnmarshalJSON(b []byte) error {
+	content := checkpointJSON{State: &c.state}
+	if err := json.Unmarshal(b, &content); err != nil {
+		return err
+	}
+	c.Run, c.Config, c.Time = content.Run, content.Config, content.Time
+	return nil
+}
+
+// runTo processes the events up to end, stopping to hand a checkpoint to Checkpoints every
+// CheckpointInterval of simulated time on the way
+func (g *virtualGasStation) runTo(ctx context.Context, end time.Duration) error {
+	if interval := g.CheckpointInterval; g.Checkpoints != nil && interval > 0 {
+		for next := (g.sched.now/interval + 1) * interval; next < end; next += interval {
+			if err := g.sched.run(ctx, next, nil); err != nil {
+				return err
+			}
+			if err := g.Checkpoints(g.checkpoint()); err != nil {
+				return fmt.Errorf("checkpoint at %v: %w", next, err)
+			}
+		}
+	}
+	return g.sched.run(ctx, end, nil)
+}
+
+// checkpointWriter collects the state of a run into a checkpoint, numbering the cars and charging
+// sessions in the order it comes across them
+type checkpointWriter struct {
+	state    *checkpointState
+	cars     map[*Car]int
+	sessions map[*chargingSession]int
+}
+
+func (w *checkpointWriter) car(car *Car) int {
+	if car == nil {
+		return -1
+	}
+	if i, ok := w.cars[car]; ok {
+		return i
+	}
+	held := -1
+	if car.heldPump != nil {
+		held = car.heldPump.ID
+	}
+	w.cars[car] = len(w.state.Cars)
+	w.state.Cars = append(w.state.Cars, checkpointCar{Car: *car, HeldPump: held, Events: car.events, Gone: car.gone})
+	return w.cars[car]
+}
+
+func (w *checkpointWriter) carList(cars []*Car) []int {
+	list := make([]int, len(cars))
+	for i, car := range cars {
+		list[i] = w.car(car)
+	}
+	return list
+}
+
+func (w *checkpointWriter) blockedList(cars []blockedCar) []checkpointBlocked {
+	list := make([]checkpointBlocked, len(cars))
+	for i, b := range cars {
+		list[i] = checkpointBlocked{Car: w.car(b.car), Station: b.station.ID}
+	}
+	return list
+}
+
+func (w *checkpointWriter) fueling(f *fueling) checkpointFueling {
+	return checkpointFueling{Car: w.car(f.car), Station: f.station.ID, Attendant: f.attendant,
+		RefuelTime: f.refuelTime, UnitPrice: f.unitPrice, Start: f.start}
+}
+
+func (w *checkpointWriter) session(session *chargingSession) int {
+	if session == nil {
+		return -1
+	}
+	if i, ok := w.sessions[session]; ok {
+		return i
+	}
+	i := len(w.state.Sessions)
+	w.sessions[session] = i
+	w.state.Sessions = append(w.state.Sessions, checkpointSession{Remaining: session.remaining, Round: session.round,
+		Fueling: w.fueling(session.fueling)})
+	return i
+}
+
+// checkpoint returns the state of the run between two events
+func (g *virtualGasStation) checkpoint() *Checkpoint {
+	g.configMu.RLock()
+	c := &Checkpoint{Run: g.metadata, Config: g.config, Time: g.sched.now}
+	g.configMu.RUnlock()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	st := &c.state
+	st.Start, st.Events, st.Sequence = g.sched.start, g.sched.ran, g.sched.seq
+	st.Draws = g.rngSource.drawn()
+	st.CarID, st.CarsInside, st.Weather = g.carID, atomic.LoadInt32(&g.carsInside), atomic.LoadInt32(&g.weather)
+	w := &checkpointWriter{state: st, cars: make(map[*Car]int), sessions: make(map[*chargingSession]int)}
+
+	pending := slices.Clone(g.sched.events)
+	slices.SortFunc(pending, func(a, b *event) int { return cmp.Or(cmp.Compare(a.at, b.at), cmp.Compare(a.seq, b.seq)) })
+	for _, e := range pending {
+		ce := checkpointEvent{Kind: eventKinds[e.kind], At: e.at, Seq: e.seq, Car: w.car(e.car), ID: e.id,
+			Seconds: e.seconds, Session: w.session(e.session)}
+		if e.fueling != nil {
+			f := w.fueling(e.fueling)
+			ce.Fueling = &f
+		}
+		st.Pending = append(st.Pending, ce)
+	}
+
+	for _, free := range g.freeStations {
+		for _, station := range free {
+			st.FreeStations = append(st.FreeStations, station.ID)
+		}
+	}
+	for _, queue := range g.refuelQueues {
+		st.RefuelQueues = append(st.RefuelQueues, w.carList(queue))
+	}
+	for _, cashReg := range g.freeRegisters {
+		st.FreeRegisters = append(st.FreeRegisters, cashReg.ID)
+	}
+	st.FreeAttendants = slices.Clone(g.freeAttendants)
+	st.AttendantQueue = w.blockedList(g.attendantQueue)
+	st.CheckoutQueue = w.carList(g.checkoutQueue)
+	for _, queue := range g.registerQueues {
+		st.RegisterQueues = append(st.RegisterQueues, w.carList(queue))
+	}
+	st.Blocked = w.blockedList(g.blocked)
+	st.Failures = slices.Clone(g.failures)
+	for _, bank := range g.powerBanks {
+		var b *checkpointPowerBank
+		if bank != nil {
+			b = &checkpointPowerBank{Since: bank.since}
+			for _, session := range bank.sessions {
+				b.Sessions = append(b.Sessions, w.session(session))
+			}
+		}
+		st.PowerBanks = append(st.PowerBanks, b)
+	}
+	for _, f := range g.forecourts {
+		var cf *checkpointForecourt
+		if f != nil {
+			f.mu.Lock()
+			cf = &checkpointForecourt{Occupied: slices.Clone(f.occupied), Finished: slices.Clone(f.finished),
+				Since: slices.Clone(f.since), Open: slices.Clone(f.open)}
+			f.mu.Unlock()
+		}
+		st.Forecourts = append(st.Forecourts, cf)
+	}
+	st.RegisterPool, st.AttendantPool = g.registerPool.checkpoint(), g.attendantPool.checkpoint()
+
+	st.StationsBusySince = slices.Clone(g.stationsBusySince)
+	st.RegistersBusySince = slices.Clone(g.registersBusySince)
+	st.AttendantsBusySince = slices.Clone(g.attendantsBusySince)
+	st.WeatherSince = slices.Clone(g.weatherSince)
+	st.RefuelWaiting = checkpointGauge{Length: g.refuelWaiting.length, Since: g.refuelWaiting.since}
+	st.CheckoutWaiting = checkpointGauge{Length: g.checkoutWaiting.length, Since: g.checkoutWaiting.since}
+	st.CheckoutTickets = g.checkoutTickets
+	st.CheckoutLine = slices.Clone(g.checkoutLine)
+	st.RegisterLoad = slices.Clone(g.registerLoad)
+	st.Series = g.TimeSeries()
+
+	st.Stats = g.stats.Snapshot()
+	st.Shared = g.shared().Snapshot()
+	for _, fuel := range g.stats.Fuels {
+		st.Samples = append(st.Samples, checkpointSamples{RefuelQueueWaits: fuel.RefuelQueueWaits,
+			CheckoutQueueWaits: fuel.CheckoutQueueWaits, FuelingTimes: fuel.FuelingTimes, TimesAtStation: fuel.TimesAtStation})
+	}
+	return c
+}
+
+func (p *staffPool) checkpoint() checkpointStaff {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return checkpointStaff{Wanted: p.wanted, OnDuty: p.onDuty, OffDuty: slices.Clone(p.offDuty)}
+}
+
+// FromCheckpoint returns a simulation that goes on with the run of the checkpoint when it is run, keeping
+// its metadata. Every callback and output set on it sees the part of the run after the checkpoint, the
+// stats, time series and results cover all of it. It fails for a checkpoint whose state doesn't fit its
+// config.
+func FromCheckpoint(c *Checkpoint) (*Simulation, error) {
+	if err := c.Config.Validate(); err != nil {
+		return nil, err
+	}
+	if c.Config.Realtime || c.Config.CarSource != nil {
+		return nil, errors.New("only virtual runs without a car source have checkpoints")
+	}
+	s := New(c.Config)
+	s.metadata = c.Run
+	s.stamp.Do(func() {})
+
+	st := &c.state
+	s.rngSource.skip(st.Draws)
+	sched := newScheduler(st.Start)
+	sched.now, sched.seq, sched.ran = c.Time, st.Sequence, st.Events
+	g := buildVirtualGasStation(s, sched)
+	r := &checkpointReader{g: g, state: st}
+	r.restore()
+	if r.err != nil {
+		return nil, fmt.Errorf("the checkpoint doesn't fit its config: %w", r.err)
+	}
+	s.resumed = g
+	return s, nil
+}
+
+// checkpointReader restores the state of a checkpoint into a site set up by its config, keeping the
+// first error of a reference that doesn't fit
+type checkpointReader struct {
+	g        *virtualGasStation
+	state    *checkpointState
+	cars     []*Car
+	sessions []*chargingSession
+	err      error
+}
+
+func (r *checkpointReader) fail(format string, args ...interface{}) {
+	if r.err == nil {
+		r.err = fmt.Errorf(format, args...)
+	}
+}
+
+// fits reports whether what has n entries as the config sets up want, failing otherwise
+func (r *checkpointReader) fits(what string, n, want int) bool {
+	if n != want {
+		r.fail("%d %s instead of %d", n, what, want)
+	}
+	return n == want
+}
+
+func (r *checkpointReader) car(i int) *Car {
+	if i == -1 {
+		return nil
+	}
+	if i < 0 || i >= len(r.cars) {
+		r.fail("no car %d", i)
+		return nil
+	}
+	return r.cars[i]
+}
+
+func (r *checkpointReader) carList(list []int) []*Car {
+	var cars []*Car
+	for _, i := range list {
+		if car := r.car(i); car != nil {
+			cars = append(cars, car)
+		}
+	}
+	return cars
+}
+
+func (r *checkpointReader) station(id int) Station {
+	if id < 0 || id >= len(r.g.stations) {
+		r.fail("no station %d", id)
+		return Station{}
+	}
+	return r.g.stations[id]
+}
+
+func (r *checkpointReader) blockedList(list []checkpointBlocked) []blockedCar {
+	var cars []blockedCar
+	for _, b := range list {
+		if car := r.car(b.Car); car != nil {
+			cars = append(cars, blockedCar{car, r.station(b.Station)})
+		}
+	}
+	return cars
+}
+
+func (r *checkpointReader) fueling(f checkpointFueling) *fueling {
+	return &fueling{car: r.car(f.Car), station: r.station(f.Station), attendant: f.Attendant,
+		refuelTime: f.RefuelTime, unitPrice: f.UnitPrice, start: f.Start}
+}
+
+func (r *checkpointReader) session(i int) *chargingSession {
+	if i == -1 {
+		return nil
+	}
+	if i < 0 || i >= len(r.sessions) {
+		r.fail("no charging session %d", i)
+		return nil
+	}
+	return r.sessions[i]
+}
+
+func (r *checkpointReader) restore() {
+	g, st := r.g, r.state
+	fuels := len(g.fuelNames)
+	if !r.fits("fuel types of stats", len(st.Stats.Fuels), fuels) || !r.fits("fuel types of samples", len(st.Samples), fuels) ||
+		!r.fits("stations", len(st.Stats.Stations), len(g.stats.Stations)) ||
+		!r.fits("cash registers", len(st.Stats.Registers), len(g.stats.Registers)) ||
+		!r.fits("attendants", len(st.Stats.Attendants), len(g.stats.Attendants)) ||
+		!r.fits("weather states", len(st.Stats.Weather), len(g.stats.Weather)) ||
+		!r.fits("payment methods", len(st.Stats.Payments), len(g.stats.Payments)) ||
+		!r.fits("vehicle classes", len(st.Stats.Classes), len(g.stats.Classes)) ||
+		!r.fits("demand periods", len(st.Stats.Periods), len(g.stats.Periods)) ||
+		!r.fits("stations busy", len(st.StationsBusySince), len(g.stationsBusySince)) ||
+		!r.fits("cash registers busy", len(st.RegistersBusySince), len(g.registersBusySince)) ||
+		!r.fits("attendants busy", len(st.AttendantsBusySince), len(g.attendantsBusySince)) ||
+		!r.fits("weather periods", len(st.WeatherSince), len(g.weatherSince)) ||
+		!r.fits("register loads", len(st.RegisterLoad), len(g.registerLoad)) ||
+		!r.fits("refuel queues", len(st.RefuelQueues), fuels) || !r.fits("failures", len(st.Failures), fuels) ||
+		!r.fits("power banks", len(st.PowerBanks), fuels) || !r.fits("forecourts", len(st.Forecourts), fuels) ||
+		!r.fits("register queues", len(st.RegisterQueues), len(g.registerQueues)) {
+		return
+	}
+	if st.Weather < 0 || int(st.Weather) >= max(len(g.weathers), 1) {
+		r.fail("no weather state %d", st.Weather)
+		return
+	}
+
+	g.carID, g.carsInside, g.weather = st.CarID, st.CarsInside, st.Weather
+	for _, cc := range st.Cars {
+		if cc.Fuel < 0 || int(cc.Fuel) >= fuels {
+			r.fail("car %d has no fuel type %d", cc.ID, cc.Fuel)
+			return
+		}
+		car := new(Car)
+		*car = cc.Car
+		car.rng, car.stats = g.rng, g.shared()
+		car.events, car.gone = cc.Events, cc.Gone
+		r.cars = append(r.cars, car)
+	}
+	for i, cc := range st.Cars {
+		if cc.HeldPump >= 0 {
+			station := r.station(cc.HeldPump)
+			r.cars[i].heldPump = &station
+		}
+	}
+	for _, cs := range st.Sessions {
+		r.sessions = append(r.sessions, &chargingSession{remaining: cs.Remaining, round: cs.Round, fueling: r.fueling(cs.Fueling)})
+	}
+
+	kinds := make(map[string]eventKind, len(eventKinds))
+	for kind, name := range eventKinds {
+		kinds[name] = kind
+	}
+	g.sched.events = nil
+	for _, ce := range st.Pending {
+		kind, ok := kinds[ce.Kind]
+		if !ok {
+			r.fail("unknown event %q", ce.Kind)
+			continue
+		}
+		e := &event{at: ce.At, seq: ce.Seq, kind: kind, g: g, car: r.car(ce.Car), id: ce.ID, seconds: ce.Seconds,
+			session: r.session(ce.Session)}
+		if ce.Fueling != nil {
+			e.fueling = r.fueling(*ce.Fueling)
+		}
+		g.sched.events = append(g.sched.events, e)
+	}
+	heap.Init(&g.sched.events)
+
+	for i := range g.freeStations {
+		g.freeStations[i] = nil
+	}
+	for _, id := range st.FreeStations {
+		station := r.station(id)
+		g.freeStations[station.Fuel] = append(g.freeStations[station.Fuel], station)
+	}
+	for i, queue := range st.RefuelQueues {
+		g.refuelQueues[i] = r.carList(queue)
+	}
+	g.freeRegisters = nil
+	for _, id := range st.FreeRegisters {
+		if id < 0 || id >= len(g.stats.Registers) {
+			r.fail("no cash register %d", id)
+			continue
+		}
+		g.freeRegisters = append(g.freeRegisters, CashRegister{ID: id})
+	}
+	g.freeAttendants = st.FreeAttendants
+	g.attendantQueue = r.blockedList(st.AttendantQueue)
+	g.checkoutQueue = r.carList(st.CheckoutQueue)
+	for i, queue := range st.RegisterQueues {
+		g.registerQueues[i] = r.carList(queue)
+	}
+	g.blocked = r.blockedList(st.Blocked)
+	g.failures = st.Failures
+	for i, b := range st.PowerBanks {
+		bank := g.powerBanks[i]
+		if (b == nil) != (bank == nil) {
+			r.fail("shared power of fuel type %d doesn't match", i)
+			continue
+		}
+		if bank == nil {
+			continue
+		}
+		bank.since = b.Since
+		for _, j := range b.Sessions {
+			if session := r.session(j); session != nil {
+				bank.sessions = append(bank.sessions, session)
+			}
+		}
+	}
+	for i, cf := range st.Forecourts {
+		f := g.forecourts[i]
+		if (cf == nil) != (f == nil) {
+			r.fail("lanes of fuel type %d don't match", i)
+			continue
+		}
+		if f == nil {
+			continue
+		}
+		if !r.fits("lane stations", len(cf.Occupied), len(f.occupied)) || !r.fits("lane stations", len(cf.Finished), len(f.finished)) ||
+			!r.fits("lane stations", len(cf.Since), len(f.since)) || !r.fits("lanes", len(cf.Open), len(f.open)) {
+			continue
+		}
+		f.occupied, f.finished, f.since, f.open = cf.Occupied, cf.Finished, cf.Since, cf.Open
+	}
+	g.registerPool.restore(st.RegisterPool)
+	g.attendantPool.restore(st.AttendantPool)
+
+	g.stationsBusySince, g.registersBusySince = st.StationsBusySince, st.RegistersBusySince
+	g.attendantsBusySince, g.weatherSince = st.AttendantsBusySince, st.WeatherSince
+	g.refuelWaiting = queueGauge{length: st.RefuelWaiting.Length, since: st.RefuelWaiting.Since}
+	g.checkoutWaiting = queueGauge{length: st.CheckoutWaiting.Length, since: st.CheckoutWaiting.Since}
+	g.checkoutTickets, g.checkoutLine, g.registerLoad = st.CheckoutTickets, st.CheckoutLine, st.RegisterLoad
+	g.series = st.Series
+
+	g.stats = st.Stats
+	for i, samples := range st.Samples {
+		fuel := &g.stats.Fuels[i]
+		fuel.RefuelQueueWaits, fuel.CheckoutQueueWaits = samples.RefuelQueueWaits, samples.CheckoutQueueWaits
+		fuel.FuelingTimes, fuel.TimesAtStation = samples.FuelingTimes, samples.TimesAtStation
+	}
+	shared := g.shared()
+	shared.add(&st.Shared)
+}
+
+func (p *staffPool) restore(c checkpointStaff) {
+	p.wanted, p.onDuty, p.offDuty = c.Wanted, c.OnDuty, c.OffDuty
+}
@@ -0,0 +1,165 @@
+package sim
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestCheckpointRoundTrip goes on with runs of every part of the model from a checkpoint written as JSON
+// and read back, which has to end with the stats, samples, time series, events and receipts of the run
+// without the interruption and write the same checkpoint after it
+func TestCheckpointRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		change func(c *Config)
+	}{
+		{"spawn chance", func(c *Config) {}},
+		{"arrivals per hour", func(c *Config) { c.ArrivalsPerHour = 1500 }},
+		{"prepay", func(c *Config) { c.Prepay = true }},
+		{"pay at pump", func(c *Config) { c.PayAtPump = &PayAtPump{Share: 0.3, Time: TimeRange{Min: 20, Max: 40}} }},
+		{"shared power", func(c *Config) {
+			changeFuel(c, "electric", func(fc *FuelConfig) { fc.SharedPower = &SharedPower{ChargerPower: 50, SitePower: 60} })
+		}},
+		{"attendants and shifts", func(c *Config) {
+			changeFuel(c, "gas", func(fc *FuelConfig) { fc.Attended = true })
+			c.AttendantCount = 2
+			c.Shifts = []Shift{{From: 0, CashRegisters: 1, Attendants: 1}, {From: Duration(90 * time.Minute), CashRegisters: 4, Attendants: 2}}
+		}},
+		{"weather", func(c *Config) {
+			c.Weather = &Weather{MeanDuration: Duration(30 * time.Minute), States: map[string]WeatherState{
+				"sun":  {Share: 0.7, Demand: 1},
+				"rain": {Share: 0.3, Demand: 1.5, FuelMix: map[string]float32{"electric": 2}},
+			}}
+		}},
+		{"pump failures", func(c *Config) {
+			c.PumpFailures = PumpFailures{MTBF: Duration(time.Hour), MTTR: Duration(20 * time.Minute)}
+		}},
+		{"lanes", func(c *Config) { changeFuel(c, "gas", func(fc *FuelConfig) { fc.Lanes = []int{2, 2} }) }},
+		{"register queues", func(c *Config) { c.CheckoutPolicy = CheckoutShortest }},
+		{"hold pump", func(c *Config) {
+			c.HoldPump = true
+			c.CheckoutQueueCapacity = 3
+		}},
+		{"warmup", func(c *Config) { c.Warmup = Duration(90 * time.Minute) }},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			config := checkpointConfig()
+			test.change(&config)
+			if err := config.Validate(); err != nil {
+				t.Fatal(err)
+			}
+
+			plain := New(config)
+			if err := plain.Run(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			full := runCheckpointed(t, New(config))
+			assertSameRun(t, "the run taking checkpoints", full.simulation, plain, nil, nil)
+			if len(full.checkpoints) != 2 {
+				t.Fatalf("took %d checkpoints, expected 2", len(full.checkpoints))
+			}
+
+			c := new(Checkpoint)
+			if err := json.Unmarshal(full.checkpoints[0], c); err != nil {
+				t.Fatal(err)
+			}
+			if len(c.state.Cars) == 0 || len(c.state.Pending) == 0 {
+				t.Fatalf("the checkpoint has %d cars and %d events, expected a busy station", len(c.state.Cars), len(c.state.Pending))
+			}
+			resumedSim, err := FromCheckpoint(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resumed := runCheckpointed(t, resumedSim)
+			assertSameRun(t, "the resumed run", resumed.simulation, full.simulation, resumed.receipts, full.receipts[full.receiptsAt[0]:])
+			if resumed.simulation.Metadata() != full.simulation.Metadata() {
+				t.Errorf("the resumed run is stamped %+v, expected %+v", resumed.simulation.Metadata(), full.simulation.Metadata())
+			}
+			if len(resumed.checkpoints) != 1 || !bytes.Equal(resumed.checkpoints[0], full.checkpoints[1]) {
+				t.Errorf("the resumed run took another checkpoint than the run without the interruption")
+			}
+		})
+	}
+}
+
+// checkpointConfig is a busy station for three hours, with both kinds of impatient drivers
+func checkpointConfig() Config {
+	config := DefaultConfig()
+	config.RandomSeed = 7
+	config.SimulationLength = Duration(3 * time.Hour)
+	config.CarWaitTimeBias = 120
+	config.CheckoutWaitTimeBias = 90
+	config.SampleInterval = Duration(10 * time.Minute)
+	return config
+}
+
+func changeFuel(c *Config, name string, change func(fc *FuelConfig)) {
+	fc := c.Fuels[name]
+	change(&fc)
+	c.Fuels[name] = fc
+}
+
+type checkpointedRun struct {
+	simulation  *Simulation
+	checkpoints [][]byte // as JSON
+	receipts    []Receipt
+	receiptsAt  []int // paid by every checkpoint
+}
+
+// runCheckpointed runs the simulation to the end taking a checkpoint every hour
+func runCheckpointed(t *testing.T, simulation *Simulation) *checkpointedRun {
+	run := &checkpointedRun{simulation: simulation}
+	simulation.Receipts = func(r Receipt) { run.receipts = append(run.receipts, r) }
+	simulation.CheckpointInterval = time.Hour
+	simulation.Checkpoints = func(c *Checkpoint) error {
+		content, err := json.Marshal(c)
+		run.checkpoints = append(run.checkpoints, content)
+		run.receiptsAt = append(run.receiptsAt, len(run.receipts))
+		return err
+	}
+	if err := simulation.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return run
+}
+
+// assertSameRun compares the outcome of the run with the one it has to match, and the receipts when given
+func assertSameRun(t *testing.T, name string, got, want *Simulation, gotReceipts, wantReceipts []Receipt) {
+	t.Helper()
+	golden, err := want.Results().GoldenStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := got.Results().CompareGolden(golden); err != nil {
+		t.Errorf("%s has other stats: %v", name, err)
+	}
+	for i, fuel := range got.Results().Stats.Fuels {
+		wantFuel := want.Results().Stats.Fuels[i]
+		if !reflect.DeepEqual(fuel.RefuelQueueWaits, wantFuel.RefuelQueueWaits) || !reflect.DeepEqual(fuel.CheckoutQueueWaits, wantFuel.CheckoutQueueWaits) ||
+			!reflect.DeepEqual(fuel.FuelingTimes, wantFuel.FuelingTimes) || !reflect.DeepEqual(fuel.TimesAtStation, wantFuel.TimesAtStation) {
+			t.Errorf("%s has other samples of %s", name, fuel.Name)
+		}
+	}
+	if !reflect.DeepEqual(got.TimeSeries(), want.TimeSeries()) {
+		t.Errorf("%s has another time series", name)
+	}
+	if got.Events() != want.Events() {
+		t.Errorf("%s processed %d events, expected %d", name, got.Events(), want.Events())
+	}
+
+	gotJSON, err := json.Marshal(gotReceipts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantJSON, err := json.Marshal(wantReceipts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(gotJSON, wantJSON) {
+		t.Errorf("%s wrote %d other receipts than the %d expected", name, len(gotReceipts), len(wantReceipts))
+	}
+}
@@ -0,0 +1,148 @@
+package sim
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+)
+
+// VehicleClass describes a kind of vehicle such as a motorcycle, car, van or truck
+type VehicleClass struct {
+	Share float32 `json:"share" yaml:"share"` // chance of a new vehicle being of the class, all shares sum to 1
+	// multiply the tank size and fueling time of the fuel, 0 means 1
+	TankSizeMultiplier    float32 `json:"tank_size_multiplier" yaml:"tank_size_multiplier"`
+	FuelingTimeMultiplier float32 `json:"fueling_time_multiplier" yaml:"fueling_time_multiplier"`
+	// fuel types the class uses, all of them open to it when empty
+	Fuels []string `json:"fuels,omitempty" yaml:"fuels,omitempty"`
+}
+
+// VehicleClassNames returns the names of the vehicle classes in alphabetical order, the order of their stats
+func (c *Config) VehicleClassNames() []string {
+	names := make([]string, 0, len(c.VehicleClasses))
+	for name := range c.VehicleClasses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// classUses reports whether vehicles of the class may use the fuel, both by the fuels of the class
+// and by the classes the fuel is dedicated to
+func (c *Config) classUses(class, fuel string) bool {
+	fuels := c.VehicleClasses[class].Fuels
+	classes := c.Fuels[fuel].Classes
+	return (len(fuels) == 0 || slices.Contains(fuels, fuel)) && (len(classes) == 0 || slices.Contains(classes, class))
+}
+
+func validateVehicleClasses(c *Config, invalid func(key, format string, args ...interface{})) {
+	for _, fuel := range c.FuelNames() {
+		for _, class := range c.Fuels[fuel].Classes {
+			if _, ok := c.VehicleClasses[class]; !ok {
+				invalid("fuels."+fuel+".classes", "vehicle class %q is not configured", class)
+			}
+		}
+	}
+	if len(c.VehicleClasses) == 0 {
+		return
+	}
+
+	var shareTotal float32
+	for _, name := range c.VehicleClassNames() {
+		class := c.VehicleClasses[name]
+		key := "vehicle_classes." + name
+		if class.Share < 0 {
+			invalid(key+".share", "must not be negative, got %v", class.Share)
+		}
+		shareTotal += class.Share
+		if class.TankSizeMultiplier < 0 {
+			invalid(key+".tank_size_multiplier", "must not be negative, got %v", class.TankSizeMultiplier)
+		}
+		if class.FuelingTimeMultiplier < 0 {
+			invalid(key+".fueling_time_multiplier", "must not be negative, got %v", class.FuelingTimeMultiplier)
+		}
+		for _, fuel := range class.Fuels {
+			if _, ok := c.Fuels[fuel]; !ok {
+				invalid(key+".fuels", "fuel type %q is not configured", fuel)
+			}
+		}
+		var chanceTotal float32
+		for fuel, fc := range c.Fuels {
+			if c.classUses(name, fuel) {
+				chanceTotal += fc.Chance
+			}
+		}
+		if chanceTotal <= 0 {
+			invalid(key, "no fuel type with a chance is open to the class")
+		}
+	}
+	if math.Abs(float64(shareTotal)-1) > 0.01 {
+		invalid("vehicle_classes", "shares must sum to 1, got %v", shareTotal)
+	}
+}
+
+// drawClass draws the vehicle class of a new car by the shares, 0 without classes
+func (s *Simulation) drawClass() int {
+	if len(s.classes) == 0 {
+		return 0
+	}
+
+	chance := s.rng.Float32()
+	class := 0
+	for i, name := range s.classes {
+		class = i
+		if chance -= s.config.VehicleClasses[name].Share; chance < 0 {
+			break
+		}
+	}
+	return class
+}
+
+// classTankSize scales the tank sizes of the fuel to the car's vehicle class
+func (s *Simulation) classTankSize(class int, tankSize Range) Range {
+	if len(s.classes) == 0 {
+		return tankSize
+	}
+	if m := s.config.VehicleClasses[s.classes[class]].TankSizeMultiplier; m > 0 {
+		tankSize.Min *= m
+		tankSize.Max *= m
+	}
+	return tankSize
+}
+
+// fuelingTimeMultiplier returns how much longer the car's vehicle class takes to fuel
+func (s *Simulation) fuelingTimeMultiplier(car *Car) float32 {
+	if len(s.classes) == 0 {
+		return 1
+	}
+	if m := s.config.VehicleClasses[s.classes[car.Class]].FuelingTimeMultiplier; m > 0 {
+		return m
+	}
+	return 1
+}
+
+// drawRefuelTime draws the seconds the car takes to fuel at the station, only the part of the tank
+// between the fill levels when they are set. A full tank takes its size at the flow rate of the station,
+// or the drawn fueling time without one.
+func (s *Simulation) drawRefuelTime(car *Car, station Station) float32 {
+	var refuelTime float32
+	if station.DispenseRate > 0 {
+		refuelTime = car.FuelTankSize / station.DispenseRate
+	} else {
+		refuelTime = s.randomInRange(car.rng, station.FuelingTime)
+	}
+	refuelTime *= s.fuelingTimeMultiplier(car)
+	if fc := s.fuelConfig(car.Fuel); fc.Fill != nil {
+		refuelTime *= s.drawFill(car, fc)
+	}
+	return refuelTime
+}
+
+// printClasses writes a row with the car counts of every vehicle class
+func printClasses(w io.Writer, config Config, stats *Stats) {
+	printGroups(w, "Vehicle classes:", "share", stats.Classes, func(i int) string {
+		return fmt.Sprintf("%.2f %%", config.VehicleClasses[stats.Classes[i].Name].Share*100)
+	})
+}
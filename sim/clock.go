@@ -0,0 +1,219 @@
+package sim
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Clock is the wall clock a simulation tells the time and waits by, the system clock unless
+// Simulation.Clock is set, e.g. to a FakeClock in tests
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers the ticks of Clock.NewTicker, dropping ticks for slow receivers like time.Ticker
+type Ticker interface {
+	Chan() <-chan time.Time
+	Stop()
+}
+
+// systemClock is the Clock of the time package
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (systemClock) NewTicker(d time.Duration) Ticker       { return systemTicker{time.NewTicker(d)} }
+
+type systemTicker struct{ *time.Ticker }
+
+func (t systemTicker) Chan() <-chan time.Time { return t.C }
+
+// wallClock returns the clock the run is timed by
+func (s *Simulation) wallClock() Clock {
+	if s.Clock != nil {
+		return s.Clock
+	}
+	return systemClock{}
+}
+
+// realtimeClock is the simulated clock of realtime runs, running at the time scale of its wall clock
+// and standing still while paused
+type realtimeClock struct {
+	wall    Clock
+	mu      sync.Mutex
+	scale   float64       // wall-clock seconds per simulated second
+	offset  time.Duration // simulated time when the clock last started running
+	started time.Time     // wall-clock time it last started running
+	paused  bool
+	resumed chan struct{} // closed when a pause ends
+	timers  timerQueue    // channels of at waiting for their time, earliest first
+	wake    chan struct{} // tells runTimers of an earlier timer or a resume, holds one wake up
+}
+
+// timer is a channel of realtimeClock.at closed once the clock reaches its simulated time
+type timer struct {
+	at time.Duration
+	ch chan struct{}
+}
+
+type timerQueue []timer
+
+func (q timerQueue) Len() int            { return len(q) }
+func (q timerQueue) Less(i, j int) bool  { return q[i].at < q[j].at }
+func (q timerQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *timerQueue) Push(x interface{}) { *q = append(*q, x.(timer)) }
+
+func (q *timerQueue) Pop() interface{} {
+	old := *q
+	t := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return t
+}
+
+func newRealtimeClock(scale float32) *realtimeClock {
+	c := new(realtimeClock)
+	c.wall = systemClock{}
+	c.scale = float64(scale)
+	c.started = c.wall.Now()
+	c.wake = make(chan struct{}, 1)
+
+	return c
+}
+
+// start sets the clock back to 0 on the wall clock, a clock paused before the start stays paused
+func (c *realtimeClock) start(wall Clock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.wall = wall
+	c.offset = 0
+	c.started = wall.Now()
+}
+
+// elapsed returns the simulated time since the start
+func (c *realtimeClock) elapsed() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.elapsedLocked()
+}
+
+func (c *realtimeClock) elapsedLocked() time.Duration {
+	if c.paused {
+		return c.offset
+	}
+	return c.offset + time.Duration(float64(c.wall.Now().Sub(c.started))/c.scale)
+}
+
+func (c *realtimeClock) pause() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.paused {
+		return errors.New("already paused")
+	}
+	c.offset = c.elapsedLocked()
+	c.paused = true
+	c.resumed = make(chan struct{})
+	return nil
+}
+
+func (c *realtimeClock) resume() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.paused {
+		return errors.New("not paused")
+	}
+	c.started = c.wall.Now()
+	c.paused = false
+	close(c.resumed)
+	c.poke()
+	return nil
+}
+
+// poke wakes runTimers up to look at the timers again
+func (c *realtimeClock) poke() {
+	select {
+	case c.wake <- struct{}{}:
+	default: // a wake up is pending already
+	}
+}
+
+func (c *realtimeClock) isPaused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.paused
+}
+
+// runTimers closes the channels of at as the clock reaches their time until ctx is cancelled, one
+// goroutine for every timer of the run however many cars wait
+func (c *realtimeClock) runTimers(ctx context.Context) {
+	var sleep <-chan time.Time
+	var armed time.Duration // the simulated time sleep was set to wake up at
+	for {
+		c.mu.Lock()
+		if c.paused {
+			sleep = nil
+		} else {
+			now := c.elapsedLocked()
+			for len(c.timers) > 0 && c.timers[0].at <= now {
+				close(heap.Pop(&c.timers).(timer).ch)
+			}
+			// a pause while sleeping only delays the wake up, checked on the next round
+			if len(c.timers) > 0 && (sleep == nil || c.timers[0].at < armed) {
+				armed = c.timers[0].at
+				sleep = c.wall.After(time.Duration(float64(armed-now) * c.scale))
+			}
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-sleep:
+			sleep = nil
+		case <-c.wake:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// wait blocks for the simulated duration and reports whether it passed before ctx was cancelled
+func (c *realtimeClock) wait(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-c.after(d):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// after returns a channel closed once the simulated duration has passed
+func (c *realtimeClock) after(d time.Duration) <-chan struct{} {
+	return c.at(c.elapsed() + d)
+}
+
+// at returns a channel closed once the clock reaches the simulated time, while runTimers runs
+func (c *realtimeClock) at(target time.Duration) <-chan struct{} {
+	ch := make(chan struct{})
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.paused && target <= c.elapsedLocked() {
+		close(ch)
+		return ch
+	}
+	heap.Push(&c.timers, timer{target, ch})
+	if c.timers[0].ch == ch {
+		c.poke()
+	}
+	return ch
+}
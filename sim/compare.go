@@ -0,0 +1,145 @@
+package sim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"text/tabwriter"
+)
+
+// ReadRuns reads the results of the runs in a report written with --output json, of a single run
+// or of replications
+func ReadRuns(r io.Reader) ([]Results, error) {
+	var report struct {
+		Run    *Metadata `json:"run"`
+		Config *Config   `json:"config"`
+		Stats  Stats     `json:"stats"`
+		Runs   []struct {
+			Run    *Metadata `json:"run"`
+			Config Config    `json:"config"`
+			Stats  Stats     `json:"stats"`
+		} `json:"runs"`
+	}
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return nil, err
+	}
+
+	if report.Config != nil {
+		return []Results{{Metadata: report.Run, Config: *report.Config, Stats: report.Stats}}, nil
+	}
+	if len(report.Runs) == 0 {
+		return nil, errors.New("neither a report of a run nor of replications")
+	}
+	results := make([]Results, len(report.Runs))
+	for i, run := range report.Runs {
+		results[i] = Results{Metadata: run.Run, Config: run.Config, Stats: run.Stats}
+	}
+	return results, nil
+}
+
+// Comparison is a key metric of scenarios set against the first one
+type Comparison struct {
+	Name        string
+	Estimates   []Estimate // by scenario
+	Deltas      []Number   // percent change of the mean from the first scenario, by scenario
+	Significant []bool     // the difference from the first scenario is significant at 95%, by scenario
+}
+
+// Compare compares the key metrics of the runs of every scenario with the first scenario. Differences
+// are tested with Welch's t-test, which needs at least two runs of both scenarios.
+func Compare(scenarios [][]Results) []Comparison {
+	comparisons := make([]Comparison, len(KeyMetrics))
+	for i, metric := range KeyMetrics {
+		c := Comparison{Name: metric.Name}
+		values := make([][]float64, len(scenarios))
+		for j, results := range scenarios {
+			for _, r := range results {
+				values[j] = append(values[j], metric.Value(r))
+			}
+			mean, halfWidth := confidenceInterval(values[j])
+			c.Estimates = append(c.Estimates, Estimate{metric.Name, Number(mean), Number(halfWidth)})
+			c.Deltas = append(c.Deltas, (c.Estimates[j].Mean-c.Estimates[0].Mean)/c.Estimates[0].Mean*100)
+			c.Significant = append(c.Significant, j > 0 && significant(values[0], values[j]))
+		}
+		comparisons[i] = c
+	}
+	return comparisons
+}
+
+// significant reports whether the means of a and b differ at the 95% level by Welch's t-test,
+// NaN values of runs without cars are skipped
+func significant(a, b []float64) bool {
+	meanA, varA, nA := meanVariance(a)
+	meanB, varB, nB := meanVariance(b)
+	if nA < 2 || nB < 2 {
+		return false
+	}
+
+	sA, sB := varA/float64(nA), varB/float64(nB)
+	if sA+sB == 0 {
+		return meanA != meanB
+	}
+	t := math.Abs(meanA-meanB) / math.Sqrt(sA+sB)
+	df := (sA + sB) * (sA + sB) / (sA*sA/float64(nA-1) + sB*sB/float64(nB-1))
+	return t > tCritical(max(int(df), 1))
+}
+
+// meanVariance returns the mean, sample variance and count of the values that aren't NaN
+func meanVariance(values []float64) (mean, variance float64, n int) {
+	var sum float64
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			sum += v
+			n++
+		}
+	}
+	if n < 2 {
+		return sum, 0, n
+	}
+	mean = sum / float64(n)
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			variance += (v - mean) * (v - mean)
+		}
+	}
+	return mean, variance / float64(n-1), n
+}
+
+// PrintComparison writes the key metrics of the scenarios side by side with their change from the first
+// scenario, significant differences are marked with *
+func PrintComparison(w io.Writer, names []string, scenarios [][]Results) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	header := []string{"", names[0] + fmt.Sprintf(" (%d)", len(scenarios[0]))}
+	for i, name := range names[1:] {
+		header = append(header, name+fmt.Sprintf(" (%d)", len(scenarios[i+1])), "Δ")
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t")+"\t")
+
+	for _, c := range Compare(scenarios) {
+		row := []string{c.Name}
+		for i, e := range c.Estimates {
+			if len(scenarios[i]) > 1 {
+				row = append(row, fmt.Sprintf("%.2f ± %.2f", e.Mean, e.HalfWidth))
+			} else {
+				row = append(row, fmt.Sprintf("%.2f", e.Mean))
+			}
+			if i == 0 {
+				continue
+			}
+			delta := fmt.Sprintf("%+.2f %%", c.Deltas[i])
+			if c.Significant[i] {
+				delta += " *"
+			} else {
+				delta += "  "
+			}
+			row = append(row, delta)
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t")+"\t")
+	}
+	tw.Flush()
+	fmt.Fprintln(w, "Runs in brackets, means ± their 95 % confidence interval")
+	fmt.Fprintln(w, "* significant difference at 95 % by Welch's t-test, which needs two runs of both")
+}
@@ -0,0 +1,534 @@
+package sim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FuelType numbers the configured fuels in the order of Config.FuelNames
+type FuelType int
+
+const (
+	spawnInterval                = 100 * time.Millisecond // how often a car may spawn
+	defaultCheckoutQueueCapacity = 10                     // of configs without checkout_queue_capacity
+	defaultMaxCarWorkers         = 1024                   // of realtime runs without max_car_workers
+)
+
+// Range is an interval values are drawn from, uniformly unless a distribution is given.
+// Samples of the distribution are clamped to min and to max when it's greater than 0.
+type Range struct {
+	Min          float32             `json:"min" yaml:"min"`
+	Max          float32             `json:"max" yaml:"max"`
+	Distribution *DistributionConfig `json:"distribution,omitempty" yaml:"distribution,omitempty"`
+
+	scale float32 // multiplies samples of the distribution, 0 means 1
+}
+
+// TimeRange is a Range of seconds
+type TimeRange = Range
+
+// FuelConfig describes the stations and demand of a single fuel type
+type FuelConfig struct {
+	Unit        string    `json:"unit" yaml:"unit"`       // e.g. l, kg or kWh, used in the report
+	Pricing     float32   `json:"pricing" yaml:"pricing"` // per unit, the base price of dynamic pricing
+	Chance      float32   `json:"chance" yaml:"chance"`   // probability of a new car using this fuel
+	TankSize    Range     `json:"tank_size" yaml:"tank_size"`
+	FuelingTime TimeRange `json:"fueling_time" yaml:"fueling_time"` // filling up the whole tank takes max
+	// units per minute a pump dispenses, kW for fuels in kWh, replacing fueling_time when set
+	FlowRate     float32 `json:"flow_rate,omitempty" yaml:"flow_rate,omitempty"`
+	StationCount int     `json:"station_count" yaml:"station_count"`
+	// cars that fit into the refuel queue, arriving cars balk when it is full, 0 means no limit
+	QueueCapacity int `json:"queue_capacity,omitempty" yaml:"queue_capacity,omitempty"`
+	// every station of the fuel needs an attendant to fuel a car
+	Attended bool           `json:"attended,omitempty" yaml:"attended,omitempty"`
+	Fill     *FillLevels    `json:"fill,omitempty" yaml:"fill,omitempty"`         // levels cars arrive with and fill up to, unset fills by the drawn fueling time
+	Charging *ChargingCurve `json:"charging,omitempty" yaml:"charging,omitempty"` // charging slowing down as batteries fill up
+	// power budget the stations share, slowing every car down while they together would draw more
+	SharedPower *SharedPower `json:"shared_power,omitempty" yaml:"shared_power,omitempty"`
+	// vehicle classes the stations are dedicated to, such as high-flow truck diesel, open to all when empty
+	Classes []string `json:"classes,omitempty" yaml:"classes,omitempty"`
+
+	PriceSchedule []PricePeriod `json:"price_schedule,omitempty" yaml:"price_schedule,omitempty"` // daily multipliers of pricing
+	Surge         *SurgePricing `json:"surge,omitempty" yaml:"surge,omitempty"`
+
+	// Stations lists the stations one by one instead of StationCount when they differ
+	Stations []StationConfig `json:"stations,omitempty" yaml:"stations,omitempty"`
+	// lengths of the lanes the stations stand in one behind the other, in station order, front first;
+	// unset lets every car reach every free station
+	Lanes []int `json:"lanes,omitempty" yaml:"lanes,omitempty"`
+}
+
+// StationConfig describes a single station of a fuel type
+type StationConfig struct {
+	FuelingTimeMultiplier float32 `json:"fueling_time_multiplier" yaml:"fueling_time_multiplier"` // e.g. 1.5 for an old slow pump, 0 means 1
+	Attended              bool    `json:"attended,omitempty" yaml:"attended,omitempty"`           // needs an attendant to fuel a car
+	FlowRate              float32 `json:"flow_rate,omitempty" yaml:"flow_rate,omitempty"`         // replaces the flow rate of the fuel, e.g. a high-flow pump
+}
+
+type Config struct {
+	Fuels             map[string]FuelConfig `json:"fuels" yaml:"fuels"` // keyed by fuel name
+	CashRegisterCount int                   `json:"cash_register_count" yaml:"cash_register_count"`
+	// cars waiting to check out before refueled cars block their stations, 0 means 10
+	CheckoutQueueCapacity int `json:"checkout_queue_capacity" yaml:"checkout_queue_capacity"`
+	// shared or shortest, a queue per cash register with cars joining the shortest one; unset is shared
+	CheckoutPolicy string `json:"checkout_policy,omitempty" yaml:"checkout_policy,omitempty"`
+	AttendantCount int    `json:"attendant_count" yaml:"attendant_count"` // forecourt attendants serving the attended stations
+	// cars that fit on the site queueing, fueling and paying together, arrivals are turned away beyond it; 0 means no limit
+	SiteCapacity int `json:"site_capacity" yaml:"site_capacity"`
+	// daily schedule of the cash registers and attendants on duty, all of them are when empty
+	Shifts []Shift `json:"shifts,omitempty" yaml:"shifts,omitempty"`
+
+	CheckoutTime TimeRange  `json:"checkout_time" yaml:"checkout_time"`
+	Prepay       bool       `json:"prepay" yaml:"prepay"`                 // customers pay at a cash register before fueling instead of after
+	HoldPump     bool       `json:"hold_pump" yaml:"hold_pump"`           // refueled cars occupy their pump until they paid at a cash register
+	Shop         *Shop      `json:"shop,omitempty" yaml:"shop,omitempty"` // purchases in the convenience store while paying
+	PayAtPump    *PayAtPump `json:"pay_at_pump,omitempty" yaml:"pay_at_pump,omitempty"`
+	Loyalty      *Loyalty   `json:"loyalty,omitempty" yaml:"loyalty,omitempty"`
+	Costs        *Costs     `json:"costs,omitempty" yaml:"costs,omitempty"` // of the pumps and staff, for the profit
+	// payment methods by name with their share of the customers, replacing checkout_time when set
+	PaymentMethods map[string]PaymentMethod `json:"payment_methods,omitempty" yaml:"payment_methods,omitempty"`
+
+	PumpFailures PumpFailures `json:"pump_failures" yaml:"pump_failures"`
+
+	CarSpawnChance  SpawnChance `json:"car_spawn_chance" yaml:"car_spawn_chance"`   // checks 10 times a second
+	ArrivalsPerHour float32     `json:"arrivals_per_hour" yaml:"arrivals_per_hour"` // Poisson arrivals replacing car_spawn_chance when set
+	// seconds between two cars drawn from a distribution, replacing car_spawn_chance when set
+	Interarrival *DistributionConfig `json:"interarrival,omitempty" yaml:"interarrival,omitempty"`
+	// registered source of the arrivals replacing the spawning above when set, such as bursty or fleet
+	CarSource       *CarSourceConfig `json:"car_source,omitempty" yaml:"car_source,omitempty"`
+	CarWaitTimeBias float32          `json:"car_wait_time_bias" yaml:"car_wait_time_bias"`
+	// named periods of the day multiplying the arrival rate, such as rush hours
+	DemandPeriods []DemandPeriod `json:"demand_periods,omitempty" yaml:"demand_periods,omitempty"`
+	Weather       *Weather       `json:"weather,omitempty" yaml:"weather,omitempty"` // random weather changing the demand, unset leaves it alone
+	// kinds of vehicles by name with their shares, tank sizes and fueling times, unset makes every car alike
+	VehicleClasses map[string]VehicleClass `json:"vehicle_classes,omitempty" yaml:"vehicle_classes,omitempty"`
+	// typical seconds a refueled car waits to check out before driving off without paying, 0 waits forever
+	CheckoutWaitTimeBias float32 `json:"checkout_wait_time_bias" yaml:"checkout_wait_time_bias"`
+
+	SimulationLength Duration      `json:"simulation_length" yaml:"simulation_length"`             // in seconds, a duration string or days such as 7d
+	Warmup           Duration      `json:"warmup" yaml:"warmup"`                                   // stats are reset after this part of the simulation
+	DrainTimeout     Duration      `json:"drain_timeout" yaml:"drain_timeout"`                     // cars inside at the end may finish for this long, 0 cuts them off
+	OpeningHours     *OpeningHours `json:"opening_hours,omitempty" yaml:"opening_hours,omitempty"` // no cars arrive while closed, unset never closes
+
+	HistogramBuckets []float32 `json:"histogram_buckets" yaml:"histogram_buckets"` // upper bounds in seconds, none disables histograms
+	SampleInterval   Duration  `json:"sample_interval" yaml:"sample_interval"`     // queue lengths are sampled this often, 0 disables it
+
+	RandomSeed int64 `json:"random_seed" yaml:"random_seed"` // 0 picks a seed from the current time
+
+	Realtime  bool    `json:"realtime" yaml:"realtime"`     // run in wall-clock time instead of virtual time
+	TimeScale float32 `json:"time_scale" yaml:"time_scale"` // wall-clock seconds per simulated second in realtime mode
+	// car workers of a realtime run, the cars it handles at once; arrivals wait at the entrance while all are
+	// busy, 0 starts 1024
+	MaxCarWorkers int `json:"max_car_workers,omitempty" yaml:"max_car_workers,omitempty"`
+	// how often the live stats of realtime runs are printed and the metrics they show, unset prints the defaults every second
+	LiveStats *LiveStatsConfig `json:"live_stats,omitempty" yaml:"live_stats,omitempty"`
+}
+
+// Shop makes customers buy items in the convenience store at the cash register
+type Shop struct {
+	Chance       float32   `json:"chance" yaml:"chance"`               // probability of a customer buying something
+	Amount       Range     `json:"amount" yaml:"amount"`               // spent in the shop
+	CheckoutTime TimeRange `json:"checkout_time" yaml:"checkout_time"` // seconds added to the checkout
+}
+
+// PaymentMethod is a way of paying at the cash register, e.g. cash, card or mobile
+type PaymentMethod struct {
+	Share        float32   `json:"share" yaml:"share"`                 // of the customers paying at a cash register
+	CheckoutTime TimeRange `json:"checkout_time" yaml:"checkout_time"` // seconds paying this way
+}
+
+// Loyalty gives the members of a loyalty program a discount on every unit of fuel
+type Loyalty struct {
+	Share    float32 `json:"share" yaml:"share"`       // of the customers
+	Discount float32 `json:"discount" yaml:"discount"` // per unit, the price never drops below 0
+}
+
+// PayAtPump lets a share of the customers pay at the pump, skipping the cash registers
+type PayAtPump struct {
+	Share float32   `json:"share" yaml:"share"` // of the customers
+	Time  TimeRange `json:"time" yaml:"time"`   // seconds paying at the pump, which stays occupied
+}
+
+// PumpFailures makes stations break down at random, both times are exponentially distributed
+type PumpFailures struct {
+	MTBF Duration `json:"mtbf" yaml:"mtbf"` // mean time between failures of a single station, 0 disables failures
+	MTTR Duration `json:"mttr" yaml:"mttr"` // mean time to repair
+}
+
+// DefaultConfig returns a small station with sane values for every field
+func DefaultConfig() Config {
+	return Config{
+		Fuels: map[string]FuelConfig{
+			"gas":      {Unit: "l", Pricing: 2.5, Chance: 0.2, TankSize: Range{Min: 40, Max: 120}, FuelingTime: TimeRange{Min: 2, Max: 5}, StationCount: 4},
+			"diesel":   {Unit: "l", Pricing: 2.7, Chance: 0.3, TankSize: Range{Min: 45, Max: 150}, FuelingTime: TimeRange{Min: 3, Max: 6}, StationCount: 4},
+			"lpg":      {Unit: "kg", Pricing: 1.8, Chance: 0.4, TankSize: Range{Min: 35, Max: 120}, FuelingTime: TimeRange{Min: 4, Max: 7}, StationCount: 2},
+			"electric": {Unit: "kWh", Pricing: 0.1, Chance: 0.1, TankSize: Range{Min: 30, Max: 120}, FuelingTime: TimeRange{Min: 5, Max: 7}, StationCount: 2},
+		},
+		CashRegisterCount:     4,
+		CheckoutQueueCapacity: defaultCheckoutQueueCapacity,
+		CheckoutTime:          TimeRange{Min: 1, Max: 3},
+		CarSpawnChance:        SpawnChance{Chance: 0.4},
+		CarWaitTimeBias:       1,
+		SimulationLength:      Duration(300 * time.Second),
+		HistogramBuckets:      []float32{1, 2, 5, 10, 20, 30, 60},
+		TimeScale:             1,
+	}
+}
+
+// Validate reports every field that would make the simulation crash or produce nonsense
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(key, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+	checkDistribution := func(key string, d *DistributionConfig) {
+		if d == nil {
+			return
+		}
+		if _, err := d.New(); err != nil {
+			invalid(key, "%v", err)
+		}
+	}
+	checkRange := func(key string, r TimeRange) {
+		if r.Min < 0 {
+			invalid(key, "min must not be negative, got %v", r.Min)
+		}
+		if r.Min > r.Max && (r.Distribution == nil || r.Max > 0) {
+			invalid(key, "min %v is greater than max %v", r.Min, r.Max)
+		}
+		checkDistribution(key+".distribution", r.Distribution)
+	}
+
+	if len(c.Fuels) == 0 {
+		invalid("fuels", "at least one fuel type is needed")
+	}
+
+	var chanceTotal float32
+	for _, name := range c.FuelNames() {
+		fc := c.Fuels[name]
+		key := "fuels." + name
+		if name == "" {
+			invalid(key, "fuel name must not be empty")
+		}
+		if fc.Pricing < 0 {
+			invalid(key+".pricing", "must not be negative, got %v", fc.Pricing)
+		}
+		if fc.Chance < 0 {
+			invalid(key+".chance", "must not be negative, got %v", fc.Chance)
+		}
+		chanceTotal += fc.Chance
+
+		validatePricing(fc, key, invalid)
+		validateFill(fc, key, invalid)
+		validateCharging(fc.Charging, key, invalid)
+		validateSharedPower(fc.SharedPower, key, invalid)
+		validateLanes(c, fc, key, invalid)
+
+		checkRange(key+".tank_size", fc.TankSize)
+		if fc.TankSize.Max <= 0 {
+			invalid(key+".tank_size", "max must be greater than 0")
+		}
+		checkRange(key+".fueling_time", fc.FuelingTime)
+		// dispensed units are computed relative to the longest fueling time
+		if fc.FuelingTime.Max <= 0 && fc.FlowRate <= 0 {
+			invalid(key+".fueling_time", "max must be greater than 0 unless flow_rate is set")
+		}
+		if fc.FlowRate < 0 {
+			invalid(key+".flow_rate", "must not be negative, got %v", fc.FlowRate)
+		}
+		if fc.StationCount < 0 {
+			invalid(key+".station_count", "must not be negative, got %v", fc.StationCount)
+		}
+		if fc.QueueCapacity < 0 {
+			invalid(key+".queue_capacity", "must not be negative, got %v", fc.QueueCapacity)
+		}
+		if fc.StationCount > 0 && len(fc.Stations) > 0 {
+			invalid(key, "set either station_count or stations, not both")
+		}
+		for i, station := range fc.Stations {
+			if station.FuelingTimeMultiplier < 0 {
+				invalid(fmt.Sprintf("%s.stations[%d].fueling_time_multiplier", key, i), "must not be negative, got %v", station.FuelingTimeMultiplier)
+			}
+			if station.FlowRate < 0 {
+				invalid(fmt.Sprintf("%s.stations[%d].flow_rate", key, i), "must not be negative, got %v", station.FlowRate)
+			}
+		}
+	}
+	if math.Abs(float64(chanceTotal)-1) > 0.01 {
+		invalid("fuels", "chances must sum to 1, got %v", chanceTotal)
+	}
+
+	if c.CashRegisterCount <= 0 {
+		invalid("cash_register_count", "at least one cash register is needed, got %v", c.CashRegisterCount)
+	}
+	if c.AttendantCount < 0 {
+		invalid("attendant_count", "must not be negative, got %v", c.AttendantCount)
+	}
+	if c.AttendantCount == 0 && c.attendedStations() {
+		invalid("attendant_count", "attended stations need at least one attendant")
+	}
+	validateShifts(c, invalid)
+	validateOpeningHours(c.OpeningHours, invalid)
+	validateDemandPeriods(c.DemandPeriods, invalid)
+	validateWeather(c, invalid)
+	validateVehicleClasses(c, invalid)
+	if c.CheckoutQueueCapacity < 0 {
+		invalid("checkout_queue_capacity", "must not be negative, got %v", c.CheckoutQueueCapacity)
+	}
+	validateCheckoutPolicy(c, invalid)
+	if c.SiteCapacity < 0 {
+		invalid("site_capacity", "must not be negative, got %v", c.SiteCapacity)
+	}
+	checkRange("checkout_time", c.CheckoutTime)
+	if c.Shop != nil {
+		if c.Shop.Chance < 0 || c.Shop.Chance > 1 {
+			invalid("shop.chance", "must be between 0 and 1, got %v", c.Shop.Chance)
+		}
+		checkRange("shop.amount", c.Shop.Amount)
+		checkRange("shop.checkout_time", c.Shop.CheckoutTime)
+	}
+	validateCosts(c.Costs, invalid)
+
+	if len(c.PaymentMethods) > 0 {
+		var shareTotal float32
+		for _, name := range c.PaymentMethodNames() {
+			method := c.PaymentMethods[name]
+			key := "payment_methods." + name
+			if method.Share < 0 {
+				invalid(key+".share", "must not be negative, got %v", method.Share)
+			}
+			shareTotal += method.Share
+			checkRange(key+".checkout_time", method.CheckoutTime)
+		}
+		if math.Abs(float64(shareTotal)-1) > 0.01 {
+			invalid("payment_methods", "shares must sum to 1, got %v", shareTotal)
+		}
+	}
+	if c.Loyalty != nil {
+		if c.Loyalty.Share < 0 || c.Loyalty.Share > 1 {
+			invalid("loyalty.share", "must be between 0 and 1, got %v", c.Loyalty.Share)
+		}
+		if c.Loyalty.Discount < 0 {
+			invalid("loyalty.discount", "must not be negative, got %v", c.Loyalty.Discount)
+		}
+	}
+	if c.PayAtPump != nil {
+		if c.PayAtPump.Share < 0 || c.PayAtPump.Share > 1 {
+			invalid("pay_at_pump.share", "must be between 0 and 1, got %v", c.PayAtPump.Share)
+		}
+		checkRange("pay_at_pump.time", c.PayAtPump.Time)
+	}
+
+	if c.PumpFailures.MTBF < 0 {
+		invalid("pump_failures.mtbf", "must not be negative, got %v", time.Duration(c.PumpFailures.MTBF))
+	}
+	if c.PumpFailures.MTBF > 0 && c.PumpFailures.MTTR <= 0 {
+		invalid("pump_failures.mttr", "must be greater than 0 when failures are enabled, got %v", time.Duration(c.PumpFailures.MTTR))
+	}
+
+	if len(c.CarSpawnChance.Schedule) == 0 && (c.CarSpawnChance.Chance < 0 || c.CarSpawnChance.Chance > 1) {
+		invalid("car_spawn_chance", "must be between 0 and 1, got %v", c.CarSpawnChance.Chance)
+	}
+	validateSchedule(c.CarSpawnChance.Schedule, "car_spawn_chance", invalid)
+	for i, period := range c.CarSpawnChance.Schedule {
+		if period.Chance < 0 || period.Chance > 1 {
+			invalid(fmt.Sprintf("car_spawn_chance[%d].chance", i), "must be between 0 and 1, got %v", period.Chance)
+		}
+	}
+	if c.ArrivalsPerHour < 0 {
+		invalid("arrivals_per_hour", "must not be negative, got %v", c.ArrivalsPerHour)
+	}
+	checkDistribution("interarrival", c.Interarrival)
+	if c.CarSource != nil {
+		if _, err := c.CarSource.New(); err != nil {
+			invalid("car_source", "%v", err)
+		}
+	}
+	if c.Interarrival != nil && c.ArrivalsPerHour > 0 {
+		invalid("interarrival", "set either interarrival or arrivals_per_hour, not both")
+	}
+	if c.CarWaitTimeBias < 0 {
+		invalid("car_wait_time_bias", "must not be negative, got %v", c.CarWaitTimeBias)
+	}
+	if c.CheckoutWaitTimeBias < 0 {
+		invalid("checkout_wait_time_bias", "must not be negative, got %v", c.CheckoutWaitTimeBias)
+	}
+	if c.SimulationLength <= 0 {
+		invalid("simulation_length", "must be greater than 0, got %v", time.Duration(c.SimulationLength))
+	}
+	if c.Warmup < 0 || (c.Warmup > 0 && c.Warmup >= c.SimulationLength) {
+		invalid("warmup", "must be between 0 and simulation_length, got %v", time.Duration(c.Warmup))
+	}
+	if c.DrainTimeout < 0 {
+		invalid("drain_timeout", "must not be negative, got %v", time.Duration(c.DrainTimeout))
+	}
+	if c.SampleInterval < 0 {
+		invalid("sample_interval", "must not be negative, got %v", time.Duration(c.SampleInterval))
+	}
+	for i, bound := range c.HistogramBuckets {
+		if bound <= 0 || (i > 0 && bound <= c.HistogramBuckets[i-1]) {
+			invalid(fmt.Sprintf("histogram_buckets[%d]", i), "must be greater than 0 and the previous bound, got %v", bound)
+		}
+	}
+	if c.TimeScale < 0 {
+		invalid("time_scale", "must not be negative, got %v", c.TimeScale)
+	}
+	if c.MaxCarWorkers < 0 {
+		invalid("max_car_workers", "must not be negative, got %v", c.MaxCarWorkers)
+	}
+	if c.LiveStats != nil {
+		c.LiveStats.validate(invalid)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Duration is a length of simulated time, given in config files either as a number of
+// seconds or as a duration string such as "90s" or "2h"
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	return d.set(v)
+}
+
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	var v interface{}
+	if err := value.Decode(&v); err != nil {
+		return err
+	}
+	return d.set(v)
+}
+
+func (d *Duration) set(v interface{}) error {
+	switch v := v.(type) {
+	case int:
+		*d = Duration(time.Duration(v) * time.Second)
+	case float64:
+		*d = Duration(v * float64(time.Second))
+	case string:
+		parsed, err := ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*d = parsed
+	default:
+		return fmt.Errorf("invalid duration %v, expected seconds or a duration string", v)
+	}
+	return nil
+}
+
+// ParseDuration parses a number of seconds, a duration string such as "2h" or a number of days such as "7d"
+func ParseDuration(s string) (Duration, error) {
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+		return Duration(seconds * float64(time.Second)), nil
+	}
+	if days, err := strconv.ParseFloat(strings.TrimSuffix(s, "d"), 64); err == nil && strings.HasSuffix(s, "d") {
+		return Duration(days * float64(day)), nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q, expected seconds, a duration string or days", s)
+	}
+	return Duration(d), nil
+}
+
+// MarshalJSON writes the duration as seconds, the same way config files usually give it
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).Seconds())
+}
+
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).Seconds(), nil
+}
+
+// PaymentMethodNames returns the names of the payment methods in alphabetical order, the order of their stats
+func (c *Config) PaymentMethodNames() []string {
+	names := make([]string, 0, len(c.PaymentMethods))
+	for name := range c.PaymentMethods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// FuelNames returns the names of the configured fuels sorted, FuelType values index this list
+func (c *Config) FuelNames() []string {
+	names := make([]string, 0, len(c.Fuels))
+	for name := range c.Fuels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// StationConfigs returns a config for every station of the fuel, StationCount identical ones
+// unless Stations lists them
+func (fc FuelConfig) StationConfigs() []StationConfig {
+	if len(fc.Stations) > 0 {
+		return fc.Stations
+	}
+	return make([]StationConfig, fc.StationCount)
+}
+
+// attendedStations reports whether any station needs an attendant
+func (c *Config) attendedStations() bool {
+	for _, fc := range c.Fuels {
+		for _, sc := range fc.StationConfigs() {
+			if fc.Attended || sc.Attended {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FuelingTime returns the fueling time of the station scaled by its multiplier
+func (sc StationConfig) FuelingTime(base TimeRange) TimeRange {
+	if sc.FuelingTimeMultiplier == 0 {
+		return base
+	}
+	scaled := base
+	scaled.Min *= sc.FuelingTimeMultiplier
+	scaled.Max *= sc.FuelingTimeMultiplier
+	scaled.scale = sc.FuelingTimeMultiplier
+	return scaled
+}
+
+// DispenseRate returns the units per second the station dispenses by its flow rate, slower by its
+// fueling time multiplier, and 0 for stations fueling by fueling_time
+func (sc StationConfig) DispenseRate(fc FuelConfig) float32 {
+	rate := fc.FlowRate
+	if sc.FlowRate > 0 {
+		rate = sc.FlowRate
+	}
+	if sc.FuelingTimeMultiplier > 0 {
+		rate /= sc.FuelingTimeMultiplier
+	}
+	if fc.Unit == "kWh" {
+		return rate / 3600 // kW
+	}
+	return rate / 60
+}
+
+// FuelUnit returns the unit label of the fuel for reports
+func (c *Config) FuelUnit(name string) string {
+	if unit := c.Fuels[name].Unit; unit != "" {
+		return unit
+	}
+	return "units"
+}
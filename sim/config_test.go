@@ -0,0 +1,88 @@
+package sim
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		want   []string // keys of the expected errors, none for a valid config
+	}{
+		{"default", func(c *Config) {}, nil},
+		{"no fuels", func(c *Config) { c.Fuels = nil }, []string{"fuels: at least one", "fuels: chances must sum to 1"}},
+		{"negative price", func(c *Config) { changeFuel(c, "gas", func(fc *FuelConfig) { fc.Pricing = -1 }) }, []string{"fuels.gas.pricing:"}},
+		{"chances", func(c *Config) { changeFuel(c, "gas", func(fc *FuelConfig) { fc.Chance = 0.5 }) }, []string{"fuels: chances must sum to 1"}},
+		{"tank size", func(c *Config) {
+			changeFuel(c, "lpg", func(fc *FuelConfig) { fc.TankSize = Range{Min: 50, Max: 40} })
+		}, []string{"fuels.lpg.tank_size: min 50 is greater than max 40"}},
+		{"fueling time", func(c *Config) {
+			changeFuel(c, "diesel", func(fc *FuelConfig) { fc.FuelingTime = TimeRange{} })
+		}, []string{"fuels.diesel.fueling_time: max must be greater than 0 unless flow_rate is set"}},
+		{"flow rate instead of fueling time", func(c *Config) {
+			changeFuel(c, "diesel", func(fc *FuelConfig) {
+				fc.FuelingTime = TimeRange{}
+				fc.FlowRate = 0.5
+			})
+		}, nil},
+		{"station count and stations", func(c *Config) {
+			changeFuel(c, "gas", func(fc *FuelConfig) { fc.Stations = []StationConfig{{}} })
+		}, []string{"fuels.gas: set either station_count or stations"}},
+		{"no cash registers", func(c *Config) { c.CashRegisterCount = 0 }, []string{"cash_register_count:"}},
+		{"attended without attendants", func(c *Config) {
+			changeFuel(c, "gas", func(fc *FuelConfig) { fc.Attended = true })
+		}, []string{"attendant_count: attended stations need at least one attendant"}},
+		{"shop chance", func(c *Config) { c.Shop = &Shop{Chance: 2} }, []string{"shop.chance: must be between 0 and 1"}},
+		{"payment shares", func(c *Config) {
+			c.PaymentMethods = map[string]PaymentMethod{"card": {Share: 0.5}, "cash": {Share: 0.2}}
+		}, []string{"payment_methods: shares must sum to 1"}},
+		{"repairs", func(c *Config) { c.PumpFailures.MTBF = Duration(time.Hour) }, []string{"pump_failures.mttr:"}},
+		{"spawn chance", func(c *Config) { c.CarSpawnChance.Chance = 1.5 }, []string{"car_spawn_chance: must be between 0 and 1"}},
+		{"interarrival and arrivals per hour", func(c *Config) {
+			c.ArrivalsPerHour = 60
+			c.Interarrival = &DistributionConfig{Name: "exponential", Params: map[string]float64{"mean": 60}}
+		}, []string{"interarrival: set either"}},
+		{"simulation length", func(c *Config) { c.SimulationLength = 0 }, []string{"simulation_length:"}},
+		{"warmup", func(c *Config) { c.Warmup = c.SimulationLength }, []string{"warmup: must be between 0 and simulation_length"}},
+		{"unknown distribution", func(c *Config) { c.Interarrival = &DistributionConfig{Name: "zipf"} }, []string{"interarrival:"}},
+		{"histogram buckets", func(c *Config) { c.HistogramBuckets = []float32{1, 5, 5} }, []string{"histogram_buckets[2]:"}},
+		{"several problems", func(c *Config) {
+			c.CashRegisterCount = -1
+			c.CarWaitTimeBias = -1
+			c.SampleInterval = -1
+		}, []string{"cash_register_count:", "car_wait_time_bias:", "sample_interval:"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.SimulationLength = Duration(time.Hour)
+			tt.change(&config)
+			err := config.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("expected a valid config, got %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected errors %q, got none", tt.want)
+			}
+			lines := strings.Split(err.Error(), "\n")
+			if len(lines) != len(tt.want) {
+				t.Errorf("expected %d errors, got %q", len(tt.want), lines)
+			}
+			for _, want := range tt.want {
+				found := false
+				for _, line := range lines {
+					found = found || strings.HasPrefix(line, want)
+				}
+				if !found {
+					t.Errorf("expected an error starting with %q, got %q", want, lines)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package sim
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Costs are what running the gas station costs per hour of the observed time
+type Costs struct {
+	PumpCapital       float32 `json:"pump_capital" yaml:"pump_capital"`             // per pump, its purchase and installation spread over its life
+	PumpOperating     float32 `json:"pump_operating" yaml:"pump_operating"`         // per pump, maintenance and power
+	RegisterStaffing  float32 `json:"register_staffing" yaml:"register_staffing"`   // per cash register on duty
+	AttendantStaffing float32 `json:"attendant_staffing" yaml:"attendant_staffing"` // per attendant on duty
+}
+
+func validateCosts(costs *Costs, invalid func(key, format string, args ...interface{})) {
+	if costs == nil {
+		return
+	}
+	check := func(key string, cost float32) {
+		if cost < 0 {
+			invalid("costs."+key, "must not be negative, got %v", cost)
+		}
+	}
+	check("pump_capital", costs.PumpCapital)
+	check("pump_operating", costs.PumpOperating)
+	check("register_staffing", costs.RegisterStaffing)
+	check("attendant_staffing", costs.AttendantStaffing)
+}
+
+// CostReport is the cost of the observed time of a run and the profit left of the revenue
+type CostReport struct {
+	Pumps      Number `json:"pumps"`
+	Registers  Number `json:"registers"`
+	Attendants Number `json:"attendants"`
+	Total      Number `json:"total"`
+	Profit     Number `json:"profit"` // fuel and shop revenue minus the total
+}
+
+// costs returns the costs of the run, nil without costs
+func (r Results) costs() *CostReport {
+	costs := r.Config.Costs
+	if costs == nil {
+		return nil
+	}
+
+	c := new(CostReport)
+	hours := r.Config.observedTime() / time.Hour.Seconds()
+	c.Pumps = Number(float64(costs.PumpCapital+costs.PumpOperating) * float64(len(r.Stats.Stations)) * hours)
+	c.Registers = Number(float64(costs.RegisterStaffing) * r.Config.staffHours(len(r.Stats.Registers), func(s Shift) int { return s.CashRegisters }))
+	c.Attendants = Number(float64(costs.AttendantStaffing) * r.Config.staffHours(len(r.Stats.Attendants), func(s Shift) int { return s.Attendants }))
+	c.Total = c.Pumps + c.Registers + c.Attendants
+	c.Profit = Number(r.revenue()) - c.Total
+	return c
+}
+
+// revenue returns the fuel and shop revenue of the run
+func (r Results) revenue() float64 {
+	return float64(r.Stats.Total().Cash + r.Stats.ShopRevenue)
+}
+
+// profit returns the revenue minus the costs, the revenue without costs
+func (r Results) profit() float64 {
+	if c := r.costs(); c != nil {
+		return float64(c.Profit)
+	}
+	return r.revenue()
+}
+
+// staffHours returns the hours of the observed time all of the staff or, by the shifts, the ones of
+// onDuty were at work together
+func (c Config) staffHours(all int, onDuty func(Shift) int) float64 {
+	start, end := time.Duration(c.Warmup), time.Duration(c.SimulationLength)
+	if len(c.Shifts) == 0 {
+		return float64(all) * (end - start).Hours()
+	}
+
+	var hours float64
+	for t := start; t < end; {
+		next := min(t+untilNextPeriod(c.Shifts, t), end)
+		hours += float64(onDuty(periodAt(c.Shifts, t))) * (next - t).Hours()
+		t = next
+	}
+	return hours
+}
+
+// printCosts writes the costs of the run and the profit
+func printCosts(w io.Writer, c *CostReport) {
+	if c == nil {
+		return
+	}
+	fmt.Fprintf(w, "Costs pumps / registers / attendants: %.2f € / %.2f € / %.2f €\n", c.Pumps, c.Registers, c.Attendants)
+	fmt.Fprintf(w, "Profit: %.2f € after %.2f € of costs\n", c.Profit, c.Total)
+}
@@ -0,0 +1,104 @@
+package sim
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	dashboardRefresh   = 100 * time.Millisecond // wall-clock time between redraws
+	throughputWindow   = time.Minute            // simulated time the throughput is averaged over
+	dashboardBarLength = 20
+)
+
+// throughputSample is the number of checked out cars at a point in simulated time
+type throughputSample struct {
+	at         time.Duration
+	checkedOut int32
+}
+
+// runDashboard redraws a full-screen view of the station on Dashboard until stop is closed,
+// then restores the terminal and closes finished
+func (s *Simulation) runDashboard(stationCounts []int, stop <-chan struct{}, finished chan<- struct{}) {
+	defer close(finished)
+
+	// alternate screen buffer without cursor, like full-screen terminal programs
+	fmt.Fprint(s.Dashboard, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(s.Dashboard, "\x1b[?25h\x1b[?1049l")
+
+	ticker := s.wallClock().NewTicker(dashboardRefresh)
+	defer ticker.Stop()
+
+	var samples []throughputSample
+	for {
+		select {
+		case <-ticker.Chan():
+		case <-stop:
+			return
+		}
+
+		now := s.realtimeElapsed()
+		if length := time.Duration(s.config.SimulationLength); now > length {
+			now = length
+		}
+		results := s.Results()
+		samples = append(samples, throughputSample{now, results.Stats.Total().CarsCheckedOut})
+		for len(samples) > 1 && now-samples[0].at > throughputWindow {
+			samples = samples[1:]
+		}
+
+		s.drawDashboard(now, results, stationCounts, samples)
+	}
+}
+
+func (s *Simulation) drawDashboard(now time.Duration, results Results, stationCounts []int, samples []throughputSample) {
+	stats := results.Stats
+	total := stats.Total()
+	length := time.Duration(s.config.SimulationLength)
+
+	// draw into a buffer first so the screen doesn't flicker
+	var b bytes.Buffer
+	b.WriteString("\x1b[H\x1b[2J")
+	fmt.Fprintf(&b, "Gas station simulation   %s / %s simulated   %s",
+		now.Truncate(time.Second), length, bar(float64(now)/float64(length), dashboardBarLength))
+	if s.Paused() {
+		b.WriteString("   PAUSED")
+	}
+	b.WriteString("\n\n")
+
+	fmt.Fprintf(&b, "Cars spawned %d   checked out %d   not served %d\n", stats.CarsSpawnedTotal, total.CarsCheckedOut, stats.CarsNotServed)
+	first, last := samples[0], samples[len(samples)-1]
+	if window := last.at - first.at; window > 0 {
+		fmt.Fprintf(&b, "Throughput   %.1f cars/min over the last %s\n", float64(last.checkedOut-first.checkedOut)/window.Minutes(), window.Truncate(time.Second))
+	} else {
+		b.WriteString("Throughput   -\n")
+	}
+
+	fmt.Fprintf(&b, "\n%-12s %-*s %9s %7s %7s\n", "Fuel", dashboardBarLength, "Pumps", "busy", "repair", "queue")
+	for i, f := range stats.Fuels {
+		count := stationCounts[i]
+		fmt.Fprintf(&b, "%-12s %s %4d / %-2d %7d %7d\n", f.Name, bar(float64(f.StationsBusy)/float64(count), dashboardBarLength),
+			f.StationsBusy, count, f.StationsInRepair, f.CarsInRefuelQueue)
+	}
+
+	registers := s.config.CashRegisterCount
+	fmt.Fprintf(&b, "\n%-12s %s %4d / %-2d\n", "Registers", bar(float64(stats.RegistersBusy)/float64(registers), dashboardBarLength), stats.RegistersBusy, registers)
+	capacity := s.config.CheckoutQueueCapacity
+	fmt.Fprintf(&b, "%-12s %s %4d / %-2d\n", "Checkout", bar(float64(stats.CarsInCheckoutQueue)/float64(capacity), dashboardBarLength), stats.CarsInCheckoutQueue, capacity)
+
+	s.Dashboard.Write(b.Bytes())
+}
+
+// bar draws a horizontal bar filled to the fraction
+func bar(fraction float64, length int) string {
+	if fraction != fraction || fraction < 0 { // NaN when there is nothing to fill
+		fraction = 0
+	}
+	filled := int(fraction*float64(length) + 0.5)
+	if filled > length {
+		filled = length
+	}
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(".", length-filled) + "]"
+}
@@ -0,0 +1,79 @@
+package sim
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// OpeningHours is the time of day the station opens and closes, it closes past midnight when close is before open
+type OpeningHours struct {
+	Open  Duration `json:"open" yaml:"open"`
+	Close Duration `json:"close" yaml:"close"`
+}
+
+// isOpen reports whether the station is open elapsed into the run, it always is without opening hours
+func (h *OpeningHours) isOpen(elapsed time.Duration) bool {
+	if h == nil {
+		return true
+	}
+
+	return withinDay(h.Open, h.Close, elapsed)
+}
+
+func validateOpeningHours(h *OpeningHours, invalid func(key, format string, args ...interface{})) {
+	if h == nil {
+		return
+	}
+	if h.Open < 0 || h.Open >= Duration(day) {
+		invalid("opening_hours.open", "must be a time of day between 0h and 24h, got %v", time.Duration(h.Open))
+	}
+	if h.Close < 0 || h.Close >= Duration(day) {
+		invalid("opening_hours.close", "must be a time of day between 0h and 24h, got %v", time.Duration(h.Close))
+	}
+	if h.Open == h.Close {
+		invalid("opening_hours", "open and close must differ, leave opening_hours unset for a station that never closes")
+	}
+}
+
+// DayStats sum up a single simulated day, runs start at midnight
+type DayStats struct {
+	CarsArrived    int32   `json:"cars_arrived"` // including the ones that balked
+	CarsCheckedOut int32   `json:"cars_checked_out"`
+	CarsNotServed  int32   `json:"cars_not_served"`
+	Revenue        float32 `json:"revenue"` // fuel cash
+}
+
+// isOpen reports whether the station lets cars in elapsed into the run
+func (s *Simulation) isOpen(elapsed time.Duration) bool {
+	return s.config.OpeningHours.isOpen(elapsed)
+}
+
+// day returns the stats of the day elapsed falls into, guarded by mu
+func (s *Simulation) day(elapsed time.Duration) *DayStats {
+	i := int(elapsed / day)
+	for len(s.stats.Days) <= i {
+		s.stats.Days = append(s.stats.Days, DayStats{})
+	}
+	return &s.stats.Days[i]
+}
+
+// countDay counts an event elapsed into the run in the stats of its day
+func (s *Simulation) countDay(elapsed time.Duration, count func(d *DayStats)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count(s.day(elapsed))
+}
+
+// printDays writes a row with the summary of every simulated day
+func printDays(w io.Writer, stats *Stats) {
+	fmt.Fprintln(w, "Days:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "\tarrived\tchecked out\tnot served\trevenue\t")
+	for i, d := range stats.Days {
+		fmt.Fprintf(tw, "day %d\t%d\t%d\t%d\t%.2f €\t\n", i+1, d.CarsArrived, d.CarsCheckedOut, d.CarsNotServed, d.Revenue)
+	}
+	tw.Flush()
+}
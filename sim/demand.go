@@ -0,0 +1,125 @@
+package sim
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// offPeak names the stats of the cars arriving outside of every demand period
+const offPeak = "off-peak"
+
+// DemandPeriod multiplies the arrival rate between two times of day, such as a rush hour
+type DemandPeriod struct {
+	Name       string   `json:"name" yaml:"name"`
+	From       Duration `json:"from" yaml:"from"`
+	To         Duration `json:"to" yaml:"to"` // before from for periods past midnight
+	Multiplier float32  `json:"multiplier" yaml:"multiplier"`
+}
+
+// GroupStats are the stats of a group of cars, those that arrived during a demand period or of a vehicle class
+type GroupStats struct {
+	Name           string `json:"name"`
+	CarsArrived    int32  `json:"cars_arrived"` // including the ones that balked
+	CarsBalked     int32  `json:"cars_balked"`
+	CarsNotServed  int32  `json:"cars_not_served"`
+	CarsDroveOff   int32  `json:"cars_drove_off"`
+	CarsCheckedOut int32  `json:"cars_checked_out"`
+}
+
+// withinDay reports whether elapsed into the run falls between the times of day from and to,
+// past midnight when to is before from
+func withinDay(from, to Duration, elapsed time.Duration) bool {
+	timeOfDay := Duration(elapsed % day)
+	if from < to {
+		return timeOfDay >= from && timeOfDay < to
+	}
+	return timeOfDay >= from || timeOfDay < to
+}
+
+func validateDemandPeriods(periods []DemandPeriod, invalid func(key, format string, args ...interface{})) {
+	names := make(map[string]bool)
+	for i, p := range periods {
+		key := fmt.Sprintf("demand_periods[%d]", i)
+		if p.Name == "" || p.Name == offPeak || names[p.Name] {
+			invalid(key+".name", "must be unique and not empty or %q, got %q", offPeak, p.Name)
+		}
+		names[p.Name] = true
+		if p.From < 0 || p.From >= Duration(day) {
+			invalid(key+".from", "must be a time of day between 0h and 24h, got %v", time.Duration(p.From))
+		}
+		if p.To < 0 || p.To >= Duration(day) {
+			invalid(key+".to", "must be a time of day between 0h and 24h, got %v", time.Duration(p.To))
+		}
+		if p.From == p.To {
+			invalid(key, "from and to must differ")
+		}
+		if p.Multiplier <= 0 {
+			invalid(key+".multiplier", "must be greater than 0, got %v", p.Multiplier)
+		}
+	}
+}
+
+// demandPeriod returns the index of the demand period elapsed into the run falls into, the first one
+// listed when they overlap, and the index of the off-peak stats outside of all of them
+func (s *Simulation) demandPeriod(elapsed time.Duration) int {
+	for i, p := range s.config.DemandPeriods {
+		if withinDay(p.From, p.To, elapsed) {
+			return i
+		}
+	}
+	return len(s.config.DemandPeriods)
+}
+
+// demandMultiplier returns how much the demand period and the weather raise the arrival rate elapsed into the run
+func (s *Simulation) demandMultiplier(elapsed time.Duration) float32 {
+	multiplier := s.weatherDemand()
+	if i := s.demandPeriod(elapsed); i < len(s.config.DemandPeriods) {
+		multiplier *= s.config.DemandPeriods[i].Multiplier
+	}
+	return multiplier
+}
+
+// countGroups counts an event of the car in the stats of the demand period it arrived in and of its vehicle class
+func (s *Simulation) countGroups(car *Car, count func(g *GroupStats)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.stats.Periods) > 0 {
+		count(&s.stats.Periods[car.Period])
+	}
+	if len(s.stats.Classes) > 0 {
+		count(&s.stats.Classes[car.Class])
+	}
+}
+
+// printGroups writes a row with the car counts of every group, after the columns of header
+// that describe returns the cells of
+func printGroups(w io.Writer, title, header string, groups []GroupStats, describe func(i int) string) {
+	fmt.Fprintln(w, title)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "\t%s\tarrived\tbalked\tnot served\tdrove off\tchecked out\tnot served rate\t\n", header)
+	for i, g := range groups {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%.2f %%\t\n", g.Name, describe(i),
+			g.CarsArrived, g.CarsBalked, g.CarsNotServed, g.CarsDroveOff, g.CarsCheckedOut, float32(g.CarsNotServed)/float32(g.CarsArrived)*100)
+	}
+	tw.Flush()
+}
+
+// printPeriods writes a row with the car counts of every demand period
+func printPeriods(w io.Writer, config Config, stats *Stats) {
+	printGroups(w, "Demand periods:", "hours\tdemand", stats.Periods, func(i int) string {
+		if i == len(config.DemandPeriods) {
+			return "\tx1.00"
+		}
+		period := config.DemandPeriods[i]
+		return fmt.Sprintf("%s-%s\tx%.2f", timeOfDay(period.From), timeOfDay(period.To), period.Multiplier)
+	})
+}
+
+// timeOfDay formats a time of day as hh:mm
+func timeOfDay(d Duration) string {
+	minutes := int(time.Duration(d) / time.Minute)
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
@@ -0,0 +1,158 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// Distribution draws random values, times are drawn in seconds
+type Distribution interface {
+	Sample(r *rand.Rand) float64
+}
+
+// DistributionFactory creates a distribution from the params given in the config
+type DistributionFactory func(params map[string]float64) (Distribution, error)
+
+// DistributionConfig selects a registered distribution and its params, e.g.
+// {name: exponential, params: {mean: 2}}
+type DistributionConfig struct {
+	Name   string             `json:"name" yaml:"name"`
+	Params map[string]float64 `json:"params,omitempty" yaml:"params,omitempty"`
+}
+
+var (
+	distributionsMu sync.RWMutex
+	distributions   = map[string]DistributionFactory{}
+)
+
+// RegisterDistribution makes a distribution available to configs under name,
+// it panics when the name is taken
+func RegisterDistribution(name string, factory DistributionFactory) {
+	distributionsMu.Lock()
+	defer distributionsMu.Unlock()
+
+	if _, ok := distributions[name]; ok {
+		panic("sim: distribution " + name + " registered twice")
+	}
+	distributions[name] = factory
+}
+
+// Distributions lists the names of the registered distributions sorted
+func Distributions() []string {
+	distributionsMu.RLock()
+	defer distributionsMu.RUnlock()
+
+	names := make([]string, 0, len(distributions))
+	for name := range distributions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// New creates the configured distribution
+func (c DistributionConfig) New() (Distribution, error) {
+	distributionsMu.RLock()
+	factory, ok := distributions[c.Name]
+	distributionsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown distribution %q, known are %v", c.Name, Distributions())
+	}
+
+	return factory(c.Params)
+}
+
+// DistributionFunc adapts a function to the Distribution interface
+type DistributionFunc func(r *rand.Rand) float64
+
+func (f DistributionFunc) Sample(r *rand.Rand) float64 { return f(r) }
+
+func init() {
+	RegisterDistribution("constant", func(params map[string]float64) (Distribution, error) {
+		p, err := distributionParams(params, "value")
+		if err != nil {
+			return nil, err
+		}
+		return DistributionFunc(func(r *rand.Rand) float64 { return p[0] }), nil
+	})
+	RegisterDistribution("uniform", func(params map[string]float64) (Distribution, error) {
+		p, err := distributionParams(params, "min", "max")
+		if err != nil {
+			return nil, err
+		}
+		if p[0] > p[1] {
+			return nil, fmt.Errorf("min %v is greater than max %v", p[0], p[1])
+		}
+		return DistributionFunc(func(r *rand.Rand) float64 { return p[0] + r.Float64()*(p[1]-p[0]) }), nil
+	})
+	RegisterDistribution("exponential", func(params map[string]float64) (Distribution, error) {
+		p, err := distributionParams(params, "mean")
+		if err != nil {
+			return nil, err
+		}
+		if p[0] <= 0 {
+			return nil, fmt.Errorf("mean must be greater than 0, got %v", p[0])
+		}
+		return DistributionFunc(func(r *rand.Rand) float64 { return r.ExpFloat64() * p[0] }), nil
+	})
+	RegisterDistribution("normal", func(params map[string]float64) (Distribution, error) {
+		p, err := distributionParams(params, "mean", "stddev")
+		if err != nil {
+			return nil, err
+		}
+		if p[1] < 0 {
+			return nil, fmt.Errorf("stddev must not be negative, got %v", p[1])
+		}
+		return DistributionFunc(func(r *rand.Rand) float64 { return p[0] + r.NormFloat64()*p[1] }), nil
+	})
+	RegisterDistribution("triangular", func(params map[string]float64) (Distribution, error) {
+		p, err := distributionParams(params, "min", "mode", "max")
+		if err != nil {
+			return nil, err
+		}
+		min, mode, max := p[0], p[1], p[2]
+		if min > mode || mode > max || min == max {
+			return nil, fmt.Errorf("expected min <= mode <= max and min < max, got %v, %v, %v", min, mode, max)
+		}
+		return DistributionFunc(func(r *rand.Rand) float64 {
+			// inverse of the cumulative distribution function
+			u := r.Float64()
+			if u < (mode-min)/(max-min) {
+				return min + math.Sqrt(u*(max-min)*(mode-min))
+			}
+			return max - math.Sqrt((1-u)*(max-min)*(max-mode))
+		}), nil
+	})
+}
+
+// distributionParams returns the values of the named params, all of which are required
+func distributionParams(params map[string]float64, names ...string) ([]float64, error) {
+	for name := range params {
+		if !contains(names, name) {
+			return nil, fmt.Errorf("unknown param %q, expected %v", name, names)
+		}
+	}
+
+	values := make([]float64, len(names))
+	for i, name := range names {
+		v, ok := params[name]
+		if !ok {
+			return nil, fmt.Errorf("missing param %q", name)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package sim
+
+import (
+	"sync"
+	"time"
+)
+
+// FakeClock is a Clock standing still until Advance moves it on, for tests of realtime runs that
+// shouldn't wait for the wall clock
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+// fakeWaiter is a pending After or a ticker of a FakeClock
+type fakeWaiter struct {
+	at     time.Time
+	period time.Duration // of tickers, 0 for After
+	ch     chan time.Time
+}
+
+// NewFakeClock returns a FakeClock showing now
+func NewFakeClock(now time.Time) *FakeClock {
+	c := new(FakeClock)
+	c.now = now
+
+	return c
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// Sleep blocks until Advance has moved the clock on by d
+func (c *FakeClock) Sleep(d time.Duration) {
+	<-c.After(d)
+}
+
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	return c.wait(d, 0).ch
+}
+
+func (c *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	return fakeTicker{c, c.wait(d, d)}
+}
+
+// wait registers a waiter due in d, repeating every period when it isn't 0
+func (c *FakeClock) wait(d, period time.Duration) *fakeWaiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	w := &fakeWaiter{at: c.now.Add(d), period: period, ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		w.ch <- c.now
+		return w
+	}
+	c.waiters = append(c.waiters, w)
+	return w
+}
+
+// Advance moves the clock on by d, delivering what falls due in between in order, at the time it is due
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	end := c.now.Add(d)
+	for {
+		next := -1
+		for i, w := range c.waiters {
+			if !w.at.After(end) && (next < 0 || w.at.Before(c.waiters[next].at)) {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+
+		w := c.waiters[next]
+		c.now = w.at
+		select {
+		case w.ch <- w.at:
+		default: // the last tick wasn't received yet
+		}
+		if w.period > 0 {
+			w.at = w.at.Add(w.period)
+		} else {
+			c.waiters = append(c.waiters[:next], c.waiters[next+1:]...)
+		}
+	}
+	c.now = end
+}
+
+// Waiters returns the number of sleeps, Afters and tickers pending, for tests to advance the clock
+// once the run waits for it
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.waiters)
+}
+
+// remove stops delivering to the waiter
+func (c *FakeClock) remove(w *fakeWaiter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, other := range c.waiters {
+		if other == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+type fakeTicker struct {
+	c *FakeClock
+	w *fakeWaiter
+}
+
+func (t fakeTicker) Chan() <-chan time.Time { return t.w.ch }
+func (t fakeTicker) Stop()                  { t.c.remove(t.w) }
@@ -0,0 +1,122 @@
+package sim
+
+import (
+	"testing"
+	"time"
+)
+
+var fakeStart = time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+
+func TestFakeClockAfter(t *testing.T) {
+	clock := NewFakeClock(fakeStart)
+	ch := clock.After(time.Minute)
+	if clock.Waiters() != 1 {
+		t.Fatalf("expected a waiter, got %d", clock.Waiters())
+	}
+
+	clock.Advance(59 * time.Second)
+	select {
+	case <-ch:
+		t.Fatal("fired before it was due")
+	default:
+	}
+	clock.Advance(time.Second)
+	if at := <-ch; !at.Equal(fakeStart.Add(time.Minute)) {
+		t.Errorf("fired at %v, expected %v", at, fakeStart.Add(time.Minute))
+	}
+	if clock.Waiters() != 0 {
+		t.Errorf("expected no waiters after firing, got %d", clock.Waiters())
+	}
+	if now := clock.Now(); !now.Equal(fakeStart.Add(time.Minute)) {
+		t.Errorf("now is %v, expected %v", now, fakeStart.Add(time.Minute))
+	}
+
+	select {
+	case <-clock.After(0):
+	default:
+		t.Error("After(0) didn't fire right away")
+	}
+}
+
+func TestFakeClockTicker(t *testing.T) {
+	clock := NewFakeClock(fakeStart)
+	ticker := clock.NewTicker(10 * time.Second)
+
+	clock.Advance(10 * time.Second)
+	if at := <-ticker.Chan(); !at.Equal(fakeStart.Add(10 * time.Second)) {
+		t.Errorf("ticked at %v, expected %v", at, fakeStart.Add(10*time.Second))
+	}
+	// ticks nobody received are dropped like those of time.Ticker
+	clock.Advance(30 * time.Second)
+	if at := <-ticker.Chan(); !at.Equal(fakeStart.Add(20 * time.Second)) {
+		t.Errorf("ticked at %v, expected the first undelivered tick at %v", at, fakeStart.Add(20*time.Second))
+	}
+
+	ticker.Stop()
+	clock.Advance(time.Minute)
+	select {
+	case <-ticker.Chan():
+		t.Error("ticked after Stop")
+	default:
+	}
+}
+
+func TestFakeClockSleep(t *testing.T) {
+	clock := NewFakeClock(fakeStart)
+	woke := make(chan struct{})
+	go func() {
+		clock.Sleep(time.Hour)
+		close(woke)
+	}()
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Hour)
+	select {
+	case <-woke:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Sleep didn't return after Advance")
+	}
+}
+
+// TestRealtimeRunOnFakeClock runs an hour of realtime simulation by advancing a fake clock,
+// finishing without waiting for the wall clock
+func TestRealtimeRunOnFakeClock(t *testing.T) {
+	config := DefaultConfig()
+	config.Realtime = true
+	config.TimeScale = 1
+	config.RandomSeed = 1
+	config.SimulationLength = Duration(time.Hour)
+
+	simulation := New(config)
+	clock := NewFakeClock(fakeStart)
+	simulation.Clock = clock
+	if err := runAdvancing(simulation, clock, time.Second, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if clock.Now().Before(fakeStart.Add(time.Hour)) {
+		t.Errorf("the run ended at %v of the fake clock, before the simulated hour was up", clock.Now())
+	}
+	if p := simulation.Progress(); p != 1 {
+		t.Errorf("progress is %v, expected 1", p)
+	}
+	results := simulation.Results()
+	if results.Stats.CarsSpawnedTotal == 0 || results.Stats.Total().CarsCheckedOut == 0 {
+		t.Errorf("expected cars to arrive and check out, got %d and %d", results.Stats.CarsSpawnedTotal, results.Stats.Total().CarsCheckedOut)
+	}
+	if !results.Metadata.Started.Equal(fakeStart) {
+		t.Errorf("the run was stamped %v, expected the start of the fake clock %v", results.Metadata.Started, fakeStart)
+	}
+}
+
+func TestVirtualRunStampedByClock(t *testing.T) {
+	config := DefaultConfig()
+	config.RandomSeed = 1
+	simulation := New(config)
+	simulation.Clock = NewFakeClock(fakeStart)
+
+	if started := simulation.Metadata().Started; !started.Equal(fakeStart) {
+		t.Errorf("the run was stamped %v, expected %v", started, fakeStart)
+	}
+}
@@ -0,0 +1,49 @@
+package sim
+
+import "fmt"
+
+// FillLevels make cars arrive with part of their tank left and fill it up to a target, both shares of the
+// tank between 0 and 1. The units follow from the levels and the fueling time from the units, a full tank
+// taking fueling_time.
+type FillLevels struct {
+	Arrival Range `json:"arrival" yaml:"arrival"` // level left on arrival
+	Target  Range `json:"target" yaml:"target"`   // level the driver fills up to
+}
+
+func validateFill(fc FuelConfig, key string, invalid func(key, format string, args ...interface{})) {
+	if fc.Fill == nil {
+		if fc.Charging != nil {
+			invalid(key+".fill", "charging needs the states of charge the cars arrive and leave with")
+		}
+		return
+	}
+
+	checkLevel := func(name string, r Range) {
+		if r.Min < 0 || r.Max > 1 || r.Min > r.Max {
+			invalid(key+".fill."+name, "must be a range within 0 and 1, got %v-%v", r.Min, r.Max)
+		}
+	}
+	checkLevel("arrival", fc.Fill.Arrival)
+	checkLevel("target", fc.Fill.Target)
+}
+
+// drawFill draws the levels of the car's tank at the start and end of fueling and returns the share of
+// the time fueling a full tank takes, longer for charging sessions slowing down as the battery fills up
+func (s *Simulation) drawFill(car *Car, fc FuelConfig) float32 {
+	car.ArrivalLevel = min(1, s.randomInRange(car.rng, fc.Fill.Arrival))
+	car.TargetLevel = max(car.ArrivalLevel, min(1, s.randomInRange(car.rng, fc.Fill.Target)))
+
+	if fc.Charging != nil {
+		return float32(fc.Charging.sessionShare(float64(car.ArrivalLevel), float64(car.TargetLevel)))
+	}
+	return car.TargetLevel - car.ArrivalLevel
+}
+
+// fillSummary describes the average levels the cars of a fuel came and left with for the report
+func fillSummary(f FuelStats, charging bool) string {
+	level := "full"
+	if charging {
+		level = "state of charge"
+	}
+	return fmt.Sprintf("from %.1f %% to %.1f %% %s", f.ArrivalLevel/float32(f.CarsRefueled)*100, f.TargetLevel/float32(f.CarsRefueled)*100, level)
+}
@@ -0,0 +1,95 @@
+package sim
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Expectation is a range a key metric of a run has to fall into, for Results.Check
+type Expectation struct {
+	Metric   string // Name or Column of one of KeyMetrics
+	Min, Max float64
+}
+
+// RunDeterministic runs the config on virtual time with the seed and returns its results, the same
+// stats every time for the same config, seed and build, as regression tests of models and configs need
+func RunDeterministic(ctx context.Context, config Config, seed int64) (Results, error) {
+	if seed == 0 {
+		return Results{}, errors.New("a deterministic run needs a seed other than 0")
+	}
+	config.Realtime = false
+	config.RandomSeed = seed
+	if err := config.Validate(); err != nil {
+		return Results{}, err
+	}
+
+	simulation := New(config)
+	err := simulation.Run(ctx)
+	return simulation.Results(), err
+}
+
+// Check returns an error listing the expectations the key metrics of the run miss
+func (r Results) Check(expectations ...Expectation) error {
+	var errs []error
+	for _, e := range expectations {
+		i := findMetric(e.Metric)
+		if i < 0 {
+			errs = append(errs, fmt.Errorf("unknown metric %q", e.Metric))
+			continue
+		}
+		if v := KeyMetrics[i].Value(r); !(v >= e.Min && v <= e.Max) {
+			errs = append(errs, fmt.Errorf("%s is %.4g, expected %.4g to %.4g", KeyMetrics[i].Name, v, e.Min, e.Max))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// findMetric returns the index of the key metric by name or column, -1 if there is none
+func findMetric(name string) int {
+	for i, m := range KeyMetrics {
+		if m.Name == name || m.Column == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// GoldenStats returns the stats of the run as they are kept in a golden file, indented JSON without
+// the wall-clock parts of the results such as the run metadata and resources
+func (r Results) GoldenStats() ([]byte, error) {
+	content, err := json.MarshalIndent(r.Stats, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(content, '\n'), nil
+}
+
+// CompareGolden returns an error pointing at the first line the stats of the run differ from the
+// golden file content in
+func (r Results) CompareGolden(golden []byte) error {
+	got, err := r.GoldenStats()
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(got, golden) {
+		return nil
+	}
+
+	gotLines, wantLines := bytes.Split(got, []byte("\n")), bytes.Split(golden, []byte("\n"))
+	for i := 0; ; i++ {
+		var gotLine, wantLine []byte
+		if i < len(gotLines) {
+			gotLine = gotLines[i]
+		}
+		if i < len(wantLines) {
+			wantLine = wantLines[i]
+		}
+		if !bytes.Equal(gotLine, wantLine) {
+			trim := func(line []byte) []byte { return bytes.TrimSuffix(bytes.TrimSpace(line), []byte(",")) }
+			return fmt.Errorf("line %d is %s, expected %s", i+1, trim(gotLine), trim(wantLine))
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package sim
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// harnessConfig is a short run of the default config
+func harnessConfig() Config {
+	config := DefaultConfig()
+	config.SimulationLength = Duration(time.Hour)
+	return config
+}
+
+func TestRunDeterministic(t *testing.T) {
+	first, err := RunDeterministic(context.Background(), harnessConfig(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden, err := first.GoldenStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := RunDeterministic(context.Background(), harnessConfig(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := again.CompareGolden(golden); err != nil {
+		t.Errorf("a second run with the same seed differs: %v", err)
+	}
+
+	other, err := RunDeterministic(context.Background(), harnessConfig(), 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.CompareGolden(golden); err == nil {
+		t.Error("a run with another seed has the same stats")
+	} else if !strings.HasPrefix(err.Error(), "line ") {
+		t.Errorf("expected the first differing line, got %v", err)
+	}
+}
+
+func TestRunDeterministicNeedsSeed(t *testing.T) {
+	if _, err := RunDeterministic(context.Background(), harnessConfig(), 0); err == nil {
+		t.Error("expected an error for seed 0")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	results, err := RunDeterministic(context.Background(), harnessConfig(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rate := KeyMetrics[findMetric("checked out %")].Value(results)
+
+	tests := []struct {
+		name        string
+		expectation Expectation
+		wantErr     string
+	}{
+		{"within by column", Expectation{Metric: "checked out %", Min: rate - 1, Max: rate + 1}, ""},
+		{"within by name", Expectation{Metric: "Cars checked out rate (%)", Min: rate, Max: rate}, ""},
+		{"below", Expectation{Metric: "checked out %", Min: rate + 1, Max: 100}, "Cars checked out rate (%) is"},
+		{"above", Expectation{Metric: "checked out %", Min: 0, Max: rate - 1}, "Cars checked out rate (%) is"},
+		{"unknown metric", Expectation{Metric: "happiness", Min: 0, Max: 1}, `unknown metric "happiness"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := results.Check(tt.expectation)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("expected an error with %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRunReportsUnknownCarSource(t *testing.T) {
+	config := harnessConfig()
+	config.CarSource = &CarSourceConfig{Name: "nowhere"}
+
+	err := New(config).Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "car_source") {
+		t.Errorf("expected an error naming the car_source, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package sim
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Histograms show the distribution of the per-car times of a run, in seconds
+type Histograms struct {
+	RefuelQueueWait   Histogram `json:"refuel_queue_wait"` // of the cars that got a station
+	CheckoutQueueWait Histogram `json:"checkout_queue_wait"`
+	FuelingTime       Histogram `json:"fueling_time"`
+	TimeAtStation     Histogram `json:"time_at_station"` // from arriving to paying
+}
+
+// Histogram counts samples into buckets of increasing upper bounds
+type Histogram []HistogramBucket
+
+// HistogramBucket counts the samples above the previous bound up to and including UpTo,
+// the last bucket has no bound (null in JSON) and counts the rest
+type HistogramBucket struct {
+	UpTo  Number `json:"up_to"`
+	Count int    `json:"count"`
+}
+
+// Histogram counts the samples into buckets with the upper bounds, followed by one for the rest
+func (s Samples) Histogram(bounds []float32) Histogram {
+	h := make(Histogram, len(bounds)+1)
+	for i, bound := range bounds {
+		h[i].UpTo = Number(bound)
+	}
+	h[len(bounds)].UpTo = Number(math.Inf(1))
+
+	for _, v := range s {
+		i := 0
+		for i < len(bounds) && v > bounds[i] {
+			i++
+		}
+		h[i].Count++
+	}
+	return h
+}
+
+// histograms returns the histograms of the stats with the configured buckets, nil without buckets
+func (st *Stats) histograms(bounds []float32) *Histograms {
+	if len(bounds) == 0 {
+		return nil
+	}
+
+	total := st.Total()
+	return &Histograms{
+		RefuelQueueWait:   total.RefuelQueueWaits.Histogram(bounds),
+		CheckoutQueueWait: total.CheckoutQueueWaits.Histogram(bounds),
+		FuelingTime:       total.FuelingTimes.Histogram(bounds),
+		TimeAtStation:     total.TimesAtStation.Histogram(bounds),
+	}
+}
+
+// print draws the histogram as a bar per bucket
+func (h Histogram) print(w io.Writer, name string) {
+	fmt.Fprintf(w, "%s:\n", name)
+
+	most := 0
+	for _, b := range h {
+		most = max(most, b.Count)
+	}
+	for i, b := range h {
+		label := fmt.Sprintf("<= %g s", b.UpTo)
+		if i == len(h)-1 {
+			label = fmt.Sprintf(" > %g s", h[i-1].UpTo)
+		}
+		length := 0
+		if most > 0 {
+			length = int(math.Round(float64(b.Count) / float64(most) * histogramBarLength))
+		}
+		fmt.Fprintln(w, strings.TrimRight(fmt.Sprintf("  %-10s %6d %s", label, b.Count, strings.Repeat("#", length)), " "))
+	}
+}
+
+const histogramBarLength = 40
@@ -0,0 +1,55 @@
+package sim
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHistogram(t *testing.T) {
+	bounds := []float32{1, 5, 10}
+	tests := []struct {
+		name    string
+		samples Samples
+		counts  []int
+	}{
+		{"no samples", nil, []int{0, 0, 0, 0}},
+		{"bounds are inclusive", Samples{1, 5, 10}, []int{1, 1, 1, 0}},
+		{"above the bounds", Samples{0.5, 1.5, 7, 10.01, 300}, []int{1, 1, 1, 2}},
+		{"zero waits", Samples{0, 0, 0, 2}, []int{3, 1, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := tt.samples.Histogram(bounds)
+			counts := make([]int, len(h))
+			for i, b := range h {
+				counts[i] = b.Count
+			}
+			if !reflect.DeepEqual(counts, tt.counts) {
+				t.Errorf("counts %v, want %v", counts, tt.counts)
+			}
+			for i, bound := range bounds {
+				if h[i].UpTo != Number(bound) {
+					t.Errorf("bucket %d goes up to %v, want %v", i, h[i].UpTo, bound)
+				}
+			}
+			if !math.IsInf(float64(h[len(h)-1].UpTo), 1) {
+				t.Errorf("the last bucket goes up to %v, want no bound", h[len(h)-1].UpTo)
+			}
+		})
+	}
+}
+
+func TestHistogramPrint(t *testing.T) {
+	var b strings.Builder
+	Samples{0.5, 3, 4, 20}.Histogram([]float32{1, 5}).print(&b, "Checkout queue wait")
+
+	want := "Checkout queue wait:\n" +
+		"  <= 1 s          1 ####################\n" +
+		"  <= 5 s          2 ########################################\n" +
+		"   > 5 s          1 ####################\n"
+	if b.String() != want {
+		t.Errorf("printed\n%s\nwant\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,95 @@
+package sim
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Gas station simulation report</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 760px; color: #222; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { padding: 0.3em 0.8em; border-bottom: 1px solid #ddd; }
+td { text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+pre { font-size: 12px; background: #f6f6f6; padding: 1em; overflow-x: auto; }
+.note { color: #777; }
+</style>
+</head>
+<body>
+<h1>Gas station simulation report</h1>
+<p>Seed {{.Seed}}, {{.Length}} simulated{{if .Warmup}}, the first {{.Warmup}} left out as warm-up{{end}}.</p>
+{{with .Run}}<p class="note">Run {{.ID}} started {{.Started.Format "2006-01-02 15:04:05 MST"}}, version {{.Version}}, config {{.ConfigHash}}.</p>
+{{end}}
+
+<h2>Key metrics</h2>
+<table>
+{{range .Metrics}}<tr><td>{{.Name}}</td><td>{{.Value}}</td></tr>
+{{end}}</table>
+
+<h2>Fuel types</h2>
+<table>
+<tr><th></th><th>cars</th><th>refueled</th><th>not served</th><th>checked out</th><th>revenue (€)</th><th>receipt (€)</th><th>utilization</th></tr>
+{{range .Fuels}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+
+<h2>Charts</h2>
+{{range .Charts}}<p>{{.}}</p>
+{{end}}{{if not .Sampled}}<p class="note">Set sample_interval to chart the queue lengths over time.</p>
+{{end}}
+<details>
+<summary>Full report</summary>
+<pre>{{.Text}}</pre>
+</details>
+</body>
+</html>
+`))
+
+// WriteHTML writes a self-contained HTML report of the run for sharing, with the key metrics, a table of
+// the fuel types and SVG charts of the revenue by hour, the queue waits and, given the samples taken
+// every sample_interval, the queue lengths over time
+func (r Results) WriteHTML(w io.Writer, samples []QueueSample) error {
+	type metric struct{ Name, Value string }
+	data := struct {
+		Seed           int64
+		Run            *Metadata
+		Length, Warmup time.Duration
+		Metrics        []metric
+		Fuels          [][]string
+		Charts         []template.HTML
+		Sampled        bool
+		Text           string
+	}{Seed: r.Config.RandomSeed, Run: r.Metadata, Length: time.Duration(r.Config.SimulationLength), Warmup: time.Duration(r.Config.Warmup), Sampled: len(samples) > 0}
+
+	for _, m := range KeyMetrics {
+		data.Metrics = append(data.Metrics, metric{m.Name, fmt.Sprintf("%.2f", m.Value(r))})
+	}
+	if costs := r.costs(); costs != nil {
+		data.Metrics = append(data.Metrics, metric{"Profit (€)", fmt.Sprintf("%.2f", costs.Profit)})
+	}
+
+	averages := r.Report().Averages
+	for i, f := range r.Stats.Fuels {
+		data.Fuels = append(data.Fuels, []string{f.Name, fmt.Sprint(f.CarsSpawned), fmt.Sprint(f.CarsRefueled), fmt.Sprint(f.CarsNotServed),
+			fmt.Sprint(f.CarsCheckedOut), fmt.Sprintf("%.2f", f.Cash), fmt.Sprintf("%.2f", averages.Fuels[i].Receipt),
+			fmt.Sprintf("%.2f %%", averages.Fuels[i].Utilization)})
+	}
+
+	for _, c := range r.charts(samples) {
+		// built from numbers and escaped labels only
+		data.Charts = append(data.Charts, template.HTML(c.svg()))
+	}
+
+	var text strings.Builder
+	r.Print(&text)
+	data.Text = text.String()
+
+	return htmlReport.Execute(w, data)
+}
@@ -0,0 +1,169 @@
+package sim
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+func validateLanes(c *Config, fc FuelConfig, key string, invalid func(key, format string, args ...interface{})) {
+	if len(fc.Lanes) == 0 {
+		return
+	}
+	pumps := 0
+	for i, length := range fc.Lanes {
+		if length < 1 {
+			invalid(fmt.Sprintf("%s.lanes[%d]", key, i), "must be at least 1, got %v", length)
+		}
+		pumps += length
+	}
+	if stations := len(fc.StationConfigs()); pumps != stations {
+		invalid(key+".lanes", "must add up to the %d stations of the fuel, got %d", stations, pumps)
+	}
+	if c.PumpFailures.MTBF > 0 {
+		invalid(key+".lanes", "not supported with pump_failures")
+	}
+}
+
+// lanes reports whether the stations of any fuel type stand in lanes
+func (c *Config) lanes() bool {
+	for _, fc := range c.Fuels {
+		if len(fc.Lanes) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// forecourt tracks the cars in the lanes of a fuel type, cars enter a lane from the rear, can't pass
+// a car at a pump and leave at the front. Every lane open for another car has one free station of it
+// handed out as its token, the station the car gets is chosen once it enters the lane.
+type forecourt struct {
+	mu       sync.Mutex
+	lanes    [][]Station // front first
+	lane     []int       // lane of every station, by its index in stations of the fuel
+	position []int       // of every station in its lane
+	first    int         // station ID of the first station of the fuel
+	occupied []bool
+	finished []bool      // refueled cars waiting for the cars in front of them to leave
+	since    []time.Time // finished at
+	open     []bool      // the lane has its token handed out
+}
+
+// newForecourts arranges the stations of every fuel type with lanes into them, in station order,
+// and returns the tokens of the lanes; stations of fuel types without lanes are returned as they are
+func newForecourts(s *Simulation, stations []Station) ([]*forecourt, []Station) {
+	forecourts := make([]*forecourt, len(s.fuelNames))
+	var free []Station
+	for _, station := range stations {
+		lanes := s.fuelConfig(station.Fuel).Lanes
+		if len(lanes) == 0 {
+			free = append(free, station)
+			continue
+		}
+
+		f := forecourts[station.Fuel]
+		if f == nil {
+			f = &forecourt{first: station.ID}
+			forecourts[station.Fuel] = f
+		}
+		lane := len(f.lanes) - 1
+		if lane < 0 || len(f.lanes[lane]) == lanes[lane] {
+			f.lanes = append(f.lanes, nil)
+			f.open = append(f.open, true)
+			lane++
+			free = append(free, station) // the front station is the token
+		}
+		f.lanes[lane] = append(f.lanes[lane], station)
+		f.lane = append(f.lane, lane)
+		f.position = append(f.position, len(f.lanes[lane])-1)
+		f.occupied = append(f.occupied, false)
+		f.finished = append(f.finished, false)
+		f.since = append(f.since, time.Time{})
+	}
+	return forecourts, free
+}
+
+func (f *forecourt) index(station Station) int { return station.ID - f.first }
+
+// enter takes the car with the token of a lane to the free station furthest to the front it can reach,
+// the token of the lane is returned again while its rear station is free, nil otherwise
+func (f *forecourt) enter(token Station) (Station, *Station) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	lane := f.lanes[f.lane[f.index(token)]]
+	pos := len(lane)
+	for pos > 0 && !f.occupied[f.index(lane[pos-1])] {
+		pos--
+	}
+	station := lane[pos]
+	f.occupied[f.index(station)] = true
+	if pos == len(lane)-1 {
+		f.open[f.lane[f.index(token)]] = false
+		return station, nil
+	}
+	next := lane[pos+1]
+	return station, &next
+}
+
+// finish records the car at the station refueled and done at now, it reports whether the car can leave
+// or has to wait for a car in front of it
+func (f *forecourt) finish(station Station, now time.Time) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	i := f.index(station)
+	lane := f.lanes[f.lane[i]]
+	for _, ahead := range lane[:f.position[i]] {
+		if f.occupied[f.index(ahead)] {
+			if !f.finished[i] {
+				f.finished[i], f.since[i] = true, now
+			}
+			return false
+		}
+	}
+	return true
+}
+
+// leave frees the station of the car driving away at now and returns how long it waited for the cars
+// in front of it, the cars behind it free to leave now and the token of the lane when it opened up
+// again
+func (f *forecourt) leave(station Station, now time.Time) (blocked time.Duration, unblocked []Station, token *Station) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	i := f.index(station)
+	if f.finished[i] {
+		blocked = now.Sub(f.since[i])
+	}
+	f.occupied[i], f.finished[i] = false, false
+
+	lane := f.lanes[f.lane[i]]
+	for _, behind := range lane[f.position[i]+1:] {
+		if j := f.index(behind); f.occupied[j] {
+			if f.finished[j] {
+				unblocked = append(unblocked, behind)
+			}
+			break
+		}
+	}
+	if rear := lane[len(lane)-1]; !f.open[f.lane[i]] && !f.occupied[f.index(rear)] {
+		f.open[f.lane[i]] = true
+		token = &lane[f.position[i]]
+	}
+
+	return blocked, unblocked, token
+}
+
+// leftLane records a car that waited for blocked to leave its lane behind a car in front of it
+func (s *Simulation) leftLane(blocked time.Duration) {
+	if blocked <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.CarsBlockedInLane++
+	s.stats.TimeBlockedInLane += float32(blocked.Seconds())
+}
@@ -0,0 +1,129 @@
+package sim
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestForecourt drives cars through a lane of two stations, 0 at the front and 1 at the rear, and a
+// lane of station 2 alone
+func TestForecourt(t *testing.T) {
+	const none = -1
+	type step struct {
+		op        string // enter with the token, finish or leave the station
+		station   int
+		at        int // seconds
+		got       int // station entered
+		token     int // returned by enter and leave
+		canLeave  bool
+		blocked   time.Duration
+		unblocked []int // IDs
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"car behind waits for the front car", []step{
+			{op: "enter", station: 0, got: 0, token: 1},
+			{op: "enter", station: 1, got: 1, token: none},
+			{op: "finish", station: 1, at: 10},
+			{op: "finish", station: 0, at: 12, canLeave: true},
+			{op: "leave", station: 0, at: 12, token: none, unblocked: []int{1}},
+			{op: "leave", station: 1, at: 20, token: 1, blocked: 10 * time.Second},
+		}},
+		{"front car leaves first", []step{
+			{op: "enter", station: 0, got: 0, token: 1},
+			{op: "enter", station: 1, got: 1, token: none},
+			{op: "finish", station: 0, at: 5, canLeave: true},
+			{op: "leave", station: 0, at: 5, token: none},
+			{op: "finish", station: 1, at: 9, canLeave: true},
+			{op: "leave", station: 1, at: 9, token: 1},
+		}},
+		{"next car drives up to the free front", []step{
+			{op: "enter", station: 0, got: 0, token: 1},
+			{op: "finish", station: 0, at: 5, canLeave: true},
+			{op: "leave", station: 0, at: 5, token: none},
+			{op: "enter", station: 1, got: 0, token: 1},
+		}},
+		{"lane of a single station", []step{
+			{op: "enter", station: 2, got: 2, token: none},
+			{op: "finish", station: 2, at: 3, canLeave: true},
+			{op: "leave", station: 2, at: 3, token: 2},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			changeFuel(&config, "gas", func(fc *FuelConfig) { fc.Lanes = []int{2, 1} })
+			s := New(config)
+			gas := fuelIndex(t, s, "gas")
+			stations := []Station{{ID: 0, Fuel: gas}, {ID: 1, Fuel: gas}, {ID: 2, Fuel: gas}}
+			forecourts, tokens := newForecourts(s, stations)
+			if !reflect.DeepEqual(tokens, []Station{stations[0], stations[2]}) {
+				t.Fatalf("tokens %v, expected the front stations of the lanes", tokens)
+			}
+			f := forecourts[gas]
+			station := func(id int) Station { return stations[id] }
+			tokenID := func(token *Station) int {
+				if token == nil {
+					return none
+				}
+				return token.ID
+			}
+			for i, step := range tt.steps {
+				at := fakeStart.Add(time.Duration(step.at) * time.Second)
+				switch step.op {
+				case "enter":
+					got, token := f.enter(station(step.station))
+					if got.ID != step.got || tokenID(token) != step.token {
+						t.Errorf("step %d: entered %d with token %d, want %d with %d", i, got.ID, tokenID(token), step.got, step.token)
+					}
+				case "finish":
+					if canLeave := f.finish(station(step.station), at); canLeave != step.canLeave {
+						t.Errorf("step %d: finish reports %v, want %v", i, canLeave, step.canLeave)
+					}
+				case "leave":
+					blocked, unblocked, token := f.leave(station(step.station), at)
+					var ids []int
+					for _, behind := range unblocked {
+						ids = append(ids, behind.ID)
+					}
+					if blocked != step.blocked || !reflect.DeepEqual(ids, step.unblocked) || tokenID(token) != step.token {
+						t.Errorf("step %d: blocked %v, unblocked %v, token %d, want %v, %v and %d",
+							i, blocked, ids, tokenID(token), step.blocked, step.unblocked, step.token)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestLanesInVirtualRun checks that only cars in lanes are ever blocked by the car in front of them
+func TestLanesInVirtualRun(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		lanes   []int
+		blocked bool
+	}{
+		{"separate pumps", nil, false},
+		{"two lanes of two", []int{2, 2}, true},
+		{"four lanes of one", []int{1, 1, 1, 1}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config := harnessConfig()
+			changeFuel(&config, "gas", func(fc *FuelConfig) { fc.Lanes = tt.lanes })
+			if err := config.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			results, err := RunDeterministic(context.Background(), config, 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if blocked := results.Stats.CarsBlockedInLane > 0; blocked != tt.blocked {
+				t.Errorf("%d cars blocked in a lane, expected any: %v", results.Stats.CarsBlockedInLane, tt.blocked)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package sim
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// littlesLawTolerance is the percent L and λW may differ by before the check is flagged
+const littlesLawTolerance = 10
+
+// queueGauge tracks the length of a queue to integrate it over time, guarded by mu
+type queueGauge struct {
+	length int
+	since  time.Duration // elapsed into the run when the length last changed
+}
+
+// changeQueue adds the current length of the queue since its last change to area, only the part
+// between the warm-up and the end of the run counts, and changes the length by delta
+func (s *Simulation) changeQueue(gauge *queueGauge, area *float32, delta int, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elapsed := now.Sub(s.start)
+	from := max(gauge.since, time.Duration(s.config.Warmup))
+	to := min(elapsed, time.Duration(s.config.SimulationLength))
+	if to > from {
+		*area += float32(gauge.length) * float32(to.Seconds()-from.Seconds())
+	}
+	gauge.length += delta
+	gauge.since = elapsed
+}
+
+// LittlesLaw checks the queue measurements against Little's law L = λW
+type LittlesLaw struct {
+	L         Number `json:"l"`      // time-average number of cars in the queue
+	Lambda    Number `json:"lambda"` // cars leaving the queue per second
+	W         Number `json:"w"`      // average seconds a car spent in the queue
+	LambdaW   Number `json:"lambda_w"`
+	Deviation Number `json:"deviation"` // percent λW is off from L
+}
+
+// littlesLaw derives the check of a queue that area car-seconds were spent in by cars that left it
+// after waiting wait seconds together
+func (r Results) littlesLaw(area float32, cars int, wait float64) LittlesLaw {
+	observed := r.Config.observedTime()
+	l := LittlesLaw{
+		L:      Number(float64(area) / observed),
+		Lambda: Number(float64(cars) / observed),
+		W:      Number(wait / float64(cars)),
+	}
+	l.LambdaW = l.Lambda * l.W
+	l.Deviation = Number(math.Abs(float64(l.LambdaW-l.L)) / float64(l.L) * 100)
+
+	return l
+}
+
+// Flagged reports whether L and λW differ by more than the boundary effects of a run explain,
+// pointing to a measurement error. Queues nobody waited in are never flagged.
+func (l LittlesLaw) Flagged() bool {
+	return l.L > 0 && l.Deviation > littlesLawTolerance
+}
+
+func (l LittlesLaw) print(w io.Writer, name string) {
+	fmt.Fprintf(w, "Little's law %s: L %.3f cars, λ %.4f cars/s, W %.2f s, λW %.3f cars (%.1f %% off)", name, l.L, l.Lambda, l.W, l.LambdaW, l.Deviation)
+	if l.Flagged() {
+		fmt.Fprint(w, " <- large deviation")
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,85 @@
+package sim
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLittlesLaw(t *testing.T) {
+	tests := []struct {
+		name    string
+		warmup  time.Duration
+		area    float32 // car-seconds
+		cars    int
+		wait    float64 // seconds of all cars
+		want    LittlesLaw
+		flagged bool
+	}{
+		// an hour observed: 720 cars of 10 s each keep 2 cars in the queue on average
+		{name: "consistent", area: 7200, cars: 720, wait: 7200,
+			want: LittlesLaw{L: 2, Lambda: 0.2, W: 10, LambdaW: 2, Deviation: 0}},
+		{name: "within the tolerance", area: 7200, cars: 720, wait: 7800,
+			want: LittlesLaw{L: 2, Lambda: 0.2, W: 10.833333, LambdaW: 2.1666667, Deviation: 8.333333}},
+		{name: "measurement error", area: 3600, cars: 720, wait: 7200,
+			want: LittlesLaw{L: 1, Lambda: 0.2, W: 10, LambdaW: 2, Deviation: 100}, flagged: true},
+		{name: "after the warm-up", warmup: 30 * time.Minute, area: 3600, cars: 360, wait: 3600,
+			want: LittlesLaw{L: 2, Lambda: 0.2, W: 10, LambdaW: 2, Deviation: 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.SimulationLength = Duration(time.Hour)
+			config.Warmup = Duration(tt.warmup)
+			got := Results{Config: config}.littlesLaw(tt.area, tt.cars, tt.wait)
+
+			for _, field := range []struct {
+				name      string
+				got, want Number
+			}{
+				{"L", got.L, tt.want.L},
+				{"λ", got.Lambda, tt.want.Lambda},
+				{"W", got.W, tt.want.W},
+				{"λW", got.LambdaW, tt.want.LambdaW},
+				{"deviation", got.Deviation, tt.want.Deviation},
+			} {
+				if math.Abs(float64(field.got-field.want)) > 1e-4 {
+					t.Errorf("%s = %v, want %v", field.name, field.got, field.want)
+				}
+			}
+			if got.Flagged() != tt.flagged {
+				t.Errorf("Flagged() = %v, want %v", got.Flagged(), tt.flagged)
+			}
+		})
+	}
+}
+
+func TestLittlesLawOfAnEmptyQueue(t *testing.T) {
+	config := DefaultConfig()
+	config.SimulationLength = Duration(time.Hour)
+	if l := (Results{Config: config}).littlesLaw(0, 0, 0); l.Flagged() {
+		t.Errorf("a queue nobody waited in is flagged: %+v", l)
+	}
+}
+
+func TestChangeQueue(t *testing.T) {
+	config := DefaultConfig()
+	config.SimulationLength = Duration(time.Hour)
+	config.Warmup = Duration(10 * time.Minute)
+	s := New(config)
+	at := func(d time.Duration) time.Time { return s.start.Add(d) }
+
+	var gauge queueGauge
+	var area float32
+	s.changeQueue(&gauge, &area, 2, at(5*time.Minute))   // 2 cars from before the warm-up ends
+	s.changeQueue(&gauge, &area, -1, at(20*time.Minute)) // 10 minutes of them count
+	s.changeQueue(&gauge, &area, 1, at(50*time.Minute))  // 30 minutes of 1 car
+	s.changeQueue(&gauge, &area, -2, at(70*time.Minute)) // 10 minutes of 2 cars before the end
+
+	if want := float32(2*600 + 1800 + 2*600); area != want {
+		t.Errorf("area %v car-seconds, want %v", area, want)
+	}
+	if gauge.length != 0 {
+		t.Errorf("%d cars left in the queue, want 0", gauge.length)
+	}
+}
@@ -0,0 +1,118 @@
+package sim
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LiveStatsConfig sets how often the live stats of realtime runs are printed and what they show
+type LiveStatsConfig struct {
+	Interval Duration `json:"interval,omitempty" yaml:"interval,omitempty"` // of wall-clock time, 1s when unset
+	Metrics  []string `json:"metrics,omitempty" yaml:"metrics,omitempty"`   // in the order printed, the counts of cars arrived, queued and checked out when unset
+}
+
+// liveMetric is a line of the live stats, from the stats and the simulated time so far;
+// undefined values such as rates before any time has passed are NaN
+type liveMetric struct {
+	label  string
+	format string // of the value in the text printout
+	value  func(stats Stats, elapsed time.Duration) float64
+}
+
+// perMinute returns the count per simulated minute of elapsed
+func perMinute(count int32, elapsed time.Duration) float64 {
+	if elapsed < time.Second {
+		return math.NaN()
+	}
+	return float64(count) / elapsed.Minutes()
+}
+
+func liveCount(label string, count func(Stats) int32) liveMetric {
+	return liveMetric{label, "%.0f", func(stats Stats, _ time.Duration) float64 { return float64(count(stats)) }}
+}
+
+// liveMetrics are the metrics the live stats can show, by the name they are listed with in live_stats.metrics
+var liveMetrics = map[string]liveMetric{
+	"simulated_time":         {"Simulated time:", "%.0f s", func(_ Stats, elapsed time.Duration) float64 { return elapsed.Seconds() }},
+	"cars_spawned":           liveCount("Cars spawned:", func(s Stats) int32 { return s.CarsSpawnedTotal }),
+	"cars_in_refuel_queue":   liveCount("Cars in queue to refuel:", func(s Stats) int32 { return s.CarsInRefuelQueue }),
+	"cars_in_checkout_queue": liveCount("Cars in queue to checkout:", func(s Stats) int32 { return s.CarsInCheckoutQueue }),
+	"cars_checked_out":       liveCount("Cars checked out:", func(s Stats) int32 { return s.Total().CarsCheckedOut }),
+	"cars_not_served":        liveCount("Cars not served:", func(s Stats) int32 { return s.CarsNotServed }),
+	"cars_balked":            liveCount("Cars balked:", func(s Stats) int32 { return s.CarsBalked }),
+	"cars_drove_off":         liveCount("Cars drove off:", func(s Stats) int32 { return s.CarsDroveOff }),
+	"stations_busy":          liveCount("Stations busy:", func(s Stats) int32 { return s.Total().StationsBusy }),
+	"stations_in_repair":     liveCount("Stations in repair:", func(s Stats) int32 { return s.Total().StationsInRepair }),
+	"registers_busy":         liveCount("Cash registers busy:", func(s Stats) int32 { return s.RegistersBusy }),
+	"cash":                   {"Cash taken:", "%.2f €", func(s Stats, _ time.Duration) float64 { return float64(s.Total().Cash) }},
+	"arrivals_per_minute":    {"Cars arriving per minute:", "%.2f", func(s Stats, elapsed time.Duration) float64 { return perMinute(s.CarsSpawnedTotal, elapsed) }},
+	"checkouts_per_minute":   {"Cars checked out per minute:", "%.2f", func(s Stats, elapsed time.Duration) float64 { return perMinute(s.Total().CarsCheckedOut, elapsed) }},
+	"checked_out_rate": {"Cars checked out rate:", "%.2f %%", func(s Stats, _ time.Duration) float64 {
+		return float64(s.Total().CarsCheckedOut) / float64(s.CarsSpawnedTotal) * 100
+	}},
+}
+
+// defaultLiveMetrics are shown by the live stats without live_stats.metrics
+var defaultLiveMetrics = []string{"simulated_time", "cars_spawned", "cars_in_refuel_queue", "cars_in_checkout_queue", "cars_checked_out"}
+
+// liveInterval returns the wall-clock time between two printouts of the live stats
+func (c *LiveStatsConfig) liveInterval() time.Duration {
+	if c == nil || c.Interval == 0 {
+		return time.Second
+	}
+	return time.Duration(c.Interval)
+}
+
+// liveMetricNames returns the metrics the live stats show
+func (c *LiveStatsConfig) liveMetricNames() []string {
+	if c == nil || len(c.Metrics) == 0 {
+		return defaultLiveMetrics
+	}
+	return c.Metrics
+}
+
+// validate checks the interval and the metric names of live_stats
+func (c *LiveStatsConfig) validate(invalid func(key, format string, args ...interface{})) {
+	if c.Interval < 0 {
+		invalid("live_stats.interval", "must not be negative, got %v", time.Duration(c.Interval))
+	}
+	for i, name := range c.Metrics {
+		if _, ok := liveMetrics[name]; !ok {
+			var known []string
+			for k := range liveMetrics {
+				known = append(known, k)
+			}
+			sort.Strings(known)
+			invalid(fmt.Sprintf("live_stats.metrics[%d]", i), "unknown metric %q, expected one of %s", name, strings.Join(known, ", "))
+		}
+	}
+}
+
+// printLiveStats writes a line for every metric of the live stats
+func printLiveStats(w io.Writer, names []string, stats Stats, elapsed time.Duration) {
+	for _, name := range names {
+		m := liveMetrics[name]
+		value := m.value(stats, elapsed)
+		if math.IsNaN(value) {
+			fmt.Fprintln(w, m.label, "-")
+			continue
+		}
+		fmt.Fprintln(w, m.label, fmt.Sprintf(m.format, value))
+	}
+}
+
+// writeLiveJSON writes the live stats as a JSON object on a line of its own, keyed by the metric names,
+// with the ID of the run, the wall-clock time of the printout and always the simulated time; undefined
+// values are null
+func writeLiveJSON(w io.Writer, run string, now time.Time, names []string, stats Stats, elapsed time.Duration) {
+	line := map[string]interface{}{"run": run, "time": now.Format(time.RFC3339Nano), "simulated_time": elapsed.Seconds()}
+	for _, name := range names {
+		line[name] = Number(liveMetrics[name].value(stats, elapsed))
+	}
+	json.NewEncoder(w).Encode(line)
+}
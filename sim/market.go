@@ -0,0 +1,238 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+// MarketConfig is the demand of an area shared by competing gas stations, every car picks one of
+// the sites selling its fuel by the logit of the weighted price, distance and queue length
+type MarketConfig struct {
+	ArrivalsPerHour  float32       `json:"arrivals_per_hour" yaml:"arrivals_per_hour"` // Poisson arrivals of the whole area
+	Choice           ChoiceWeights `json:"choice" yaml:"choice"`
+	SimulationLength Duration      `json:"simulation_length" yaml:"simulation_length"`
+	RandomSeed       int64         `json:"random_seed" yaml:"random_seed"` // 0 picks a seed from the current time
+}
+
+// ChoiceWeights are how much a unit of each attribute of a site puts the drivers off it
+type ChoiceWeights struct {
+	Price    float32 `json:"price" yaml:"price"`       // per unit of currency of the fuel price
+	Distance float32 `json:"distance" yaml:"distance"` // per unit of distance
+	Queue    float32 `json:"queue" yaml:"queue"`       // per car in the refuel queue of the fuel
+}
+
+// Site is a gas station of a market
+type Site struct {
+	Name     string
+	Config   Config
+	Distance float32
+}
+
+// Validate reports every invalid field of the market config
+func (c MarketConfig) Validate() error {
+	var errs []error
+	invalid := func(key, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+	if c.ArrivalsPerHour <= 0 {
+		invalid("arrivals_per_hour", "must be greater than 0, got %v", c.ArrivalsPerHour)
+	}
+	if c.Choice.Price < 0 || c.Choice.Distance < 0 || c.Choice.Queue < 0 {
+		invalid("choice", "weights must not be negative, got %+v", c.Choice)
+	}
+	if c.SimulationLength <= 0 {
+		invalid("simulation_length", "must be greater than 0, got %v", time.Duration(c.SimulationLength))
+	}
+	return errors.Join(errs...)
+}
+
+// Market simulates sites competing for the same cars on one virtual clock, create it with NewMarket
+type Market struct {
+	// Clock is the wall clock the market starts its simulated clock at and stamps its sites by,
+	// nil for the system clock
+	Clock Clock
+
+	config MarketConfig
+	sites  []*marketSite
+	demand *Simulation // draws the cars of the area, never run
+}
+
+type marketSite struct {
+	Site
+	sim    *Simulation
+	g      *virtualGasStation
+	chosen int
+}
+
+// SiteResults are the results of a site of a market and the share of the cars that chose it
+type SiteResults struct {
+	Name     string
+	Distance float32
+	Chosen   int
+	Results  Results
+}
+
+// NewMarket prepares a market of the sites, which run for the simulation_length of the market with seeds
+// following its random_seed. The cars are drawn with the fuel mix, vehicle classes and tank sizes of the
+// first site. The config and the sites are expected to pass Validate.
+func NewMarket(config MarketConfig, sites []Site) *Market {
+	if config.RandomSeed == 0 {
+		config.RandomSeed = time.Now().UnixNano()
+	}
+
+	m := new(Market)
+	m.config = config
+	demand := sites[0].Config
+	demand.RandomSeed = config.RandomSeed
+	m.demand = New(demand)
+	for i, site := range sites {
+		site.Config.SimulationLength = config.SimulationLength
+		site.Config.RandomSeed = config.RandomSeed + int64(i) + 1
+		site.Config.Realtime = false
+		m.sites = append(m.sites, &marketSite{Site: site, sim: New(site.Config)})
+	}
+	return m
+}
+
+// Run simulates the market on a virtual clock, returning early with the context error when ctx is cancelled.
+// A Market can only be run once.
+func (m *Market) Run(ctx context.Context) error {
+	clock := m.Clock
+	if clock == nil {
+		clock = systemClock{}
+	}
+	sched := newScheduler(clock.Now())
+	for _, site := range m.sites {
+		site.sim.Clock = m.Clock
+		site.g = newVirtualGasStation(site.sim, sched)
+		defer site.sim.endObservation()
+	}
+	m.scheduleArrival(sched)
+
+	length := time.Duration(m.config.SimulationLength)
+	if err := sched.run(ctx, length, nil); err != nil {
+		return err
+	}
+
+	// let the cars inside every site finish
+	var drain time.Duration
+	for _, site := range m.sites {
+		site.g.draining = true
+		drain = max(drain, site.sim.drainTimeout(ctx))
+	}
+	return sched.run(ctx, length+drain, func() bool {
+		for _, site := range m.sites {
+			if atomic.LoadInt32(&site.sim.carsInside) > 0 {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// scheduleArrival schedules the next car of the area, which schedules the one after it
+func (m *Market) scheduleArrival(sched *scheduler) {
+	next := time.Duration(m.demand.rng.ExpFloat64() * float64(time.Hour) / float64(m.config.ArrivalsPerHour))
+	sched.after(next, func() {
+		if sched.now >= time.Duration(m.config.SimulationLength) {
+			return
+		}
+		m.arrive(m.demand.drawCar(), sched.now)
+		m.scheduleArrival(sched)
+	})
+}
+
+// arrive sends the car to the site it chooses, a car no open site sells the fuel of is lost
+func (m *Market) arrive(car *Car, elapsed time.Duration) {
+	fuelName := m.demand.fuelNames[car.Fuel]
+	var options []*marketSite
+	var fuels []FuelType
+	var utilities []float64
+	for _, site := range m.sites {
+		fuel := slices.Index(site.sim.fuelNames, fuelName)
+		if fuel < 0 || !site.sim.isOpen(elapsed) {
+			continue
+		}
+		queue := site.sim.gauge(func(st *Stats) *int32 { return &st.Fuels[fuel].CarsInRefuelQueue })
+		utility := -m.config.Choice.Price*site.sim.fuelPrice(FuelType(fuel), elapsed) -
+			m.config.Choice.Distance*site.Distance - m.config.Choice.Queue*float32(queue)
+		options = append(options, site)
+		fuels = append(fuels, FuelType(fuel))
+		utilities = append(utilities, float64(utility))
+	}
+	if len(options) == 0 {
+		return
+	}
+
+	// multinomial logit, shifted by the best utility to keep exp in range
+	best := slices.Max(utilities)
+	total := 0.0
+	for i, u := range utilities {
+		utilities[i] = math.Exp(u - best)
+		total += utilities[i]
+	}
+	chance := m.demand.rng.Float64() * total
+	i := 0
+	for ; i < len(options)-1; i++ {
+		if chance -= utilities[i]; chance < 0 {
+			break
+		}
+	}
+
+	site := options[i]
+	site.chosen++
+	car.Fuel = fuels[i]
+	car.Class = max(slices.Index(site.sim.classes, m.demand.className(car.Class)), 0)
+	car.rng = site.sim.rng
+	site.sim.countSpawned(car)
+	site.sim.drawPayment(car)
+	site.g.arrive(car)
+}
+
+// className returns the name of the vehicle class, empty without classes
+func (s *Simulation) className(class int) string {
+	if len(s.classes) == 0 {
+		return ""
+	}
+	return s.classes[class]
+}
+
+// Results returns the results of every site so far, in the order of the sites
+func (m *Market) Results() []SiteResults {
+	results := make([]SiteResults, len(m.sites))
+	for i, site := range m.sites {
+		results[i] = SiteResults{Name: site.Name, Distance: site.Distance, Chosen: site.chosen, Results: site.sim.Results()}
+	}
+	return results
+}
+
+// PrintMarket writes the report of every site and how the cars of the market split between them
+func PrintMarket(w io.Writer, results []SiteResults) {
+	total := 0
+	for _, site := range results {
+		total += site.Chosen
+	}
+
+	for _, site := range results {
+		fmt.Fprintf(w, "=== %s ===\n", site.Name)
+		site.Results.Print(w)
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, "Market share:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "\tdistance\tcars\tshare\tchecked out\tcash\t")
+	for _, site := range results {
+		fuels := site.Results.Stats.Total()
+		fmt.Fprintf(tw, "%s\t%.2f\t%d\t%.2f %%\t%d\t%.2f\t\n", site.Name, site.Distance, site.Chosen,
+			float32(site.Chosen)/float32(total)*100, fuels.CarsCheckedOut, fuels.Cash)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,79 @@
+package sim
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// Metadata identifies a run in its reports and exports
+type Metadata struct {
+	ID         string    `json:"id"` // ULID, sorting by the start of the run
+	Started    time.Time `json:"started"`
+	Version    string    `json:"version"` // of the build, its git revision when built from a checkout
+	Seed       int64     `json:"seed"`
+	ConfigHash string    `json:"config_hash"` // of the effective config without the seed, shared by the runs of a config
+}
+
+// Metadata returns the ID, start and fingerprint of the run, stamped by Clock on the first call
+// or at the start of Run
+func (s *Simulation) Metadata() Metadata {
+	s.stamp.Do(func() {
+		s.configMu.RLock()
+		defer s.configMu.RUnlock()
+		s.metadata = newMetadata(s.config, s.wallClock().Now())
+	})
+	return s.metadata
+}
+
+// newMetadata stamps a run of the config starting at started
+func newMetadata(config Config, started time.Time) Metadata {
+	return Metadata{
+		ID:         ulid.MustNew(ulid.Timestamp(started), ulid.DefaultEntropy()).String(),
+		Started:    started.UTC().Truncate(time.Millisecond),
+		Version:    buildVersion(),
+		Seed:       config.RandomSeed,
+		ConfigHash: configHash(config),
+	}
+}
+
+// configHash returns the first 16 hex digits of the SHA-256 of the config as JSON, its seed left out
+func configHash(config Config) string {
+	config.RandomSeed = 0
+	content, _ := json.Marshal(config)
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:8])
+}
+
+// buildVersion returns the git revision the binary was built from, with -dirty for uncommitted
+// changes, or the module version without one, e.g. for go run
+var buildVersion = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	var revision, modified string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value
+		}
+	}
+	if revision == "" {
+		return info.Main.Version
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if modified == "true" {
+		revision += "-dirty"
+	}
+	return revision
+})
@@ -0,0 +1,58 @@
+package sim
+
+// Observer is told about the journeys of the cars of a run as they happen, with the events the trace
+// holds; the calls are made one at a time, from the goroutine of the car in realtime runs, and the run
+// waits for them to return
+type Observer interface {
+	OnCarSpawned(e TraceEvent)
+	OnRefuelStart(e TraceEvent)
+	OnRefuelEnd(e TraceEvent)
+	OnCheckout(e TraceEvent) // paid, at a cash register or at the pump
+	OnAbandon(e TraceEvent)  // left unserved, balked, turned away or drove off without paying
+	// OnSimEnd is called with the final results once the run has finished or was cancelled
+	OnSimEnd(r Results)
+}
+
+// BaseObserver ignores every event, embed it to implement only some methods of Observer
+type BaseObserver struct{}
+
+func (BaseObserver) OnCarSpawned(TraceEvent)  {}
+func (BaseObserver) OnRefuelStart(TraceEvent) {}
+func (BaseObserver) OnRefuelEnd(TraceEvent)   {}
+func (BaseObserver) OnCheckout(TraceEvent)    {}
+func (BaseObserver) OnAbandon(TraceEvent)     {}
+func (BaseObserver) OnSimEnd(Results)         {}
+
+// Observe registers an observer of the run, call it before Run
+func (s *Simulation) Observe(o Observer) {
+	s.observers = append(s.observers, o)
+}
+
+// notify hands the event to the observers, guarded by traceMu
+func (s *Simulation) notify(e TraceEvent) {
+	for _, o := range s.observers {
+		switch e.Event {
+		case EventSpawned:
+			o.OnCarSpawned(e)
+		case EventStartedFueling:
+			o.OnRefuelStart(e)
+		case EventFinishedFueling:
+			o.OnRefuelEnd(e)
+		case EventPaid:
+			o.OnCheckout(e)
+		case EventLeftUnserved, EventBalked, EventTurnedAway, EventDroveOff:
+			o.OnAbandon(e)
+		}
+	}
+}
+
+// endObservers hands the final results to the observers
+func (s *Simulation) endObservers() {
+	if len(s.observers) == 0 {
+		return
+	}
+	results := s.Results()
+	for _, o := range s.observers {
+		o.OnSimEnd(results)
+	}
+}
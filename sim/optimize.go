@@ -0,0 +1,244 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"math/rand"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Objective is a key metric the optimizer minimizes, or maximizes
+type Objective struct {
+	Metric   Metric
+	Maximize bool
+}
+
+// Objectives are the objectives of Optimize by name
+var Objectives = map[string]Objective{
+	"not_served":     {KeyMetrics[0], false},
+	"checked_out":    {KeyMetrics[1], true},
+	"checkout_queue": {KeyMetrics[2], false},
+	"at_station":     {KeyMetrics[4], false},
+	"revenue":        {KeyMetrics[5], true},
+	"profit":         {Metric{"Profit (€)", "profit €", func(r Results) float64 { return r.profit() }}, true},
+}
+
+// OptimizeConfig bounds the layouts Optimize searches and how long it searches
+type OptimizeConfig struct {
+	Objective    Objective
+	PumpBudget   int     // stations of all fuel types together, at most
+	MaxRegisters int     // cash registers, at most
+	Replications int     // runs with consecutive seeds every layout is evaluated with
+	Iterations   int     // layouts tried after the start
+	Temperature  float64 // of simulated annealing relative to the start objective, 0 climbs hills
+}
+
+// Layout is a number of stations of every fuel type with a station_count and of cash registers
+type Layout struct {
+	Stations  map[string]int
+	Registers int
+}
+
+// key identifies a layout for caching its evaluation
+func (l Layout) key() string {
+	var b strings.Builder
+	for _, name := range l.fuels() {
+		fmt.Fprintf(&b, "%s=%d ", name, l.Stations[name])
+	}
+	fmt.Fprintf(&b, "registers=%d", l.Registers)
+	return b.String()
+}
+
+// fuels returns the fuel types of the layout in name order
+func (l Layout) fuels() []string {
+	var fuels []string
+	for name := range l.Stations {
+		fuels = append(fuels, name)
+	}
+	slices.Sort(fuels)
+	return fuels
+}
+
+func (l Layout) pumps() int {
+	pumps := 0
+	for _, n := range l.Stations {
+		pumps += n
+	}
+	return pumps
+}
+
+// Overrides returns the layout as config overrides, the way --set takes them
+func (l Layout) Overrides() []string {
+	var overrides []string
+	for _, name := range l.fuels() {
+		overrides = append(overrides, fmt.Sprintf("fuels.%s.station_count=%d", name, l.Stations[name]))
+	}
+	return append(overrides, fmt.Sprintf("cash_register_count=%d", l.Registers))
+}
+
+// apply returns the config with the layout
+func (l Layout) apply(config Config) Config {
+	config.Fuels = maps.Clone(config.Fuels)
+	for name, n := range l.Stations {
+		fc := config.Fuels[name]
+		fc.StationCount = n
+		config.Fuels[name] = fc
+	}
+	config.CashRegisterCount = l.Registers
+	return config
+}
+
+// OptimizeStep is a layout evaluated by Optimize
+type OptimizeStep struct {
+	Layout   Layout
+	Estimate Estimate // of the objective
+	Accepted bool     // the search moved on to the layout
+	Best     bool     // the layout is the best so far
+}
+
+// Optimize searches the station counts of the fuel types and the cash register count of the config for
+// the best objective within the budgets by simulated annealing, starting from the layout of the config.
+// Fuel types listing their stations one by one or standing in lanes keep their stations. Every layout is
+// evaluated on virtual time with the same seeds. Step, when set, is called with every layout evaluated.
+func Optimize(ctx context.Context, config Config, opt OptimizeConfig, step func(OptimizeStep)) (OptimizeStep, error) {
+	if config.RandomSeed == 0 {
+		config.RandomSeed = time.Now().UnixNano()
+	}
+	config.Realtime = false
+	rng := rand.New(rand.NewSource(config.RandomSeed))
+	evaluated := make(map[string]Estimate)
+	evaluate := func(l Layout) (Estimate, error) {
+		if e, ok := evaluated[l.key()]; ok {
+			return e, nil
+		}
+		runs, err := Replicate(ctx, l.apply(config), opt.Replications)
+		if err != nil {
+			return Estimate{}, err
+		}
+		values := make([]float64, len(runs))
+		for i, r := range runs {
+			values[i] = opt.Objective.Metric.Value(r)
+		}
+		mean, halfWidth := confidenceInterval(values)
+		e := Estimate{opt.Objective.Metric.Name, Number(mean), Number(halfWidth)}
+		evaluated[l.key()] = e
+		return e, nil
+	}
+	// cost is minimized, NaN objectives of runs without cars are the worst
+	cost := func(e Estimate) float64 {
+		c := float64(e.Mean)
+		if opt.Objective.Maximize {
+			c = -c
+		}
+		if math.IsNaN(c) {
+			return math.Inf(1)
+		}
+		return c
+	}
+
+	current := Layout{Stations: make(map[string]int), Registers: config.CashRegisterCount}
+	for name, fc := range config.Fuels {
+		if len(fc.Stations) == 0 && len(fc.Lanes) == 0 {
+			current.Stations[name] = fc.StationCount
+		}
+	}
+	fixedPumps := 0
+	for _, fc := range config.Fuels {
+		fixedPumps += len(fc.StationConfigs())
+	}
+	fixedPumps -= current.pumps()
+
+	e, err := evaluate(current)
+	if err != nil {
+		return OptimizeStep{}, err
+	}
+	best := OptimizeStep{Layout: current, Estimate: e, Accepted: true, Best: true}
+	if step != nil {
+		step(best)
+	}
+	scale := math.Abs(cost(e))
+	if scale == 0 || math.IsInf(scale, 0) {
+		scale = 1
+	}
+
+	fuels := current.fuels()
+	for i := 0; i < opt.Iterations; i++ {
+		next, ok := neighbor(current, fuels, opt.PumpBudget-fixedPumps, opt.MaxRegisters, rng)
+		if !ok {
+			break
+		}
+		candidate := next.apply(config)
+		if candidate.Validate() != nil {
+			continue
+		}
+		e, err := evaluate(next)
+		if err != nil {
+			return best, err
+		}
+
+		s := OptimizeStep{Layout: next, Estimate: e}
+		delta := (cost(e) - cost(evaluated[current.key()])) / scale
+		temperature := opt.Temperature * (1 - float64(i)/float64(opt.Iterations))
+		if delta <= 0 || (temperature > 0 && rng.Float64() < math.Exp(-delta/temperature)) {
+			s.Accepted = true
+			current = next
+		}
+		if c := cost(e); c < cost(best.Estimate) ||
+			(c == cost(best.Estimate) && next.pumps()+next.Registers < best.Layout.pumps()+best.Layout.Registers) {
+			s.Best = true
+			best = s
+		}
+		if step != nil {
+			step(s)
+		}
+	}
+	return best, nil
+}
+
+// neighbor draws a layout one change away from l: a station moved between fuel types, added or removed,
+// or a cash register added or removed, keeping at least one of each and the pumps and registers within
+// their budgets
+func neighbor(l Layout, fuels []string, pumpBudget, maxRegisters int, rng *rand.Rand) (Layout, bool) {
+	var moves []func(n *Layout)
+	for _, from := range fuels {
+		if l.Stations[from] > 1 {
+			moves = append(moves, func(n *Layout) { n.Stations[from]-- })
+			for _, to := range fuels {
+				if to != from {
+					moves = append(moves, func(n *Layout) { n.Stations[from]--; n.Stations[to]++ })
+				}
+			}
+		}
+		if l.pumps() < pumpBudget {
+			moves = append(moves, func(n *Layout) { n.Stations[from]++ })
+		}
+	}
+	if l.Registers > 1 {
+		moves = append(moves, func(n *Layout) { n.Registers-- })
+	}
+	if l.Registers < maxRegisters {
+		moves = append(moves, func(n *Layout) { n.Registers++ })
+	}
+	if len(moves) == 0 {
+		return l, false
+	}
+
+	n := Layout{Stations: maps.Clone(l.Stations), Registers: l.Registers}
+	moves[rng.Intn(len(moves))](&n)
+	return n, true
+}
+
+// PrintOptimized writes the best layout Optimize found
+func PrintOptimized(w io.Writer, best OptimizeStep, tried int) {
+	fmt.Fprintln(w, "-----------------------------------------------------------------")
+	fmt.Fprintf(w, "Layouts tried: %d\n", tried)
+	fmt.Fprintf(w, "Best layout: %s\n", best.Layout.key())
+	fmt.Fprintf(w, "%s: %.2f ± %.2f\n", best.Estimate.Name, best.Estimate.Mean, best.Estimate.HalfWidth)
+	fmt.Fprintf(w, "Apply with: --set %s\n", strings.Join(best.Layout.Overrides(), " --set "))
+	fmt.Fprintln(w, "-----------------------------------------------------------------")
+}
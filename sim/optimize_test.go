@@ -0,0 +1,24 @@
+package sim
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestOptimizeRunsOnVirtualTime(t *testing.T) {
+	config := harnessConfig()
+	config.Realtime = true
+	config.RandomSeed = 1
+	opt := OptimizeConfig{Objective: Objectives["not_served"], PumpBudget: 10, MaxRegisters: 4, Replications: 1, Iterations: 2}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	best, err := Optimize(ctx, config, opt, nil)
+	if err != nil {
+		t.Fatalf("an hour of realtime layouts didn't finish on virtual time: %v", err)
+	}
+	if best.Layout.pumps() == 0 {
+		t.Error("the best layout has no pumps")
+	}
+}
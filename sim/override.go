@@ -0,0 +1,137 @@
+package sim
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigKey is a top level config file key that can be overridden with Config.Set
+type ConfigKey struct {
+	Name string
+	Type reflect.Type
+}
+
+// ConfigKeys lists the top level config keys in the order of the Config fields
+func ConfigKeys() []ConfigKey {
+	t := reflect.TypeOf(Config{})
+
+	var keys []ConfigKey
+	for i := 0; i < t.NumField(); i++ {
+		if name := keyName(t.Field(i)); name != "" {
+			keys = append(keys, ConfigKey{Name: name, Type: t.Field(i).Type})
+		}
+	}
+	return keys
+}
+
+// Set overrides a single field addressed by its config file key, e.g. "cash_register_count",
+// "checkout_time.max" or "fuels.gas.station_count", with [index] suffixes addressing array
+// elements. The value uses YAML syntax, so "[1, 2]", "{min: 1, max: 3}" and "2h" all work
+// where the field type allows them. The brackets of arrays may be left out.
+func (c *Config) Set(key, value string) error {
+	return setKey(reflect.ValueOf(c).Elem(), strings.Split(key, "."), key, value)
+}
+
+// setKey walks the remaining key parts down from v and decodes value into the field they address
+func setKey(v reflect.Value, parts []string, key, value string) error {
+	if len(parts) == 0 {
+		return decodeValue(v, key, value)
+	}
+
+	name, index, hasIndex := strings.Cut(parts[0], "[")
+	switch v.Kind() {
+	case reflect.Ptr:
+		// optional sections such as surge pricing are created when a key inside them is set,
+		// existing ones are copied as they may be shared with other configs
+		elem := reflect.New(v.Type().Elem())
+		if !v.IsNil() {
+			elem.Elem().Set(v.Elem())
+		}
+		v.Set(elem)
+		return setKey(elem.Elem(), parts, key, value)
+	case reflect.Struct:
+		field, ok := fieldByKey(v, name)
+		if !ok {
+			return fmt.Errorf("unknown config key %q", key)
+		}
+		v = field
+	case reflect.Map:
+		if hasIndex {
+			return fmt.Errorf("invalid index in config key %q", key)
+		}
+
+		// map values aren't addressable, change a copy and store it back
+		mapKey := reflect.ValueOf(name).Convert(v.Type().Key())
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if existing := v.MapIndex(mapKey); existing.IsValid() {
+			elem.Set(existing)
+		}
+		if err := setKey(elem, parts[1:], key, value); err != nil {
+			return err
+		}
+
+		// copy the map too, it may be shared with other configs
+		updated := reflect.MakeMapWithSize(v.Type(), v.Len()+1)
+		iter := v.MapRange()
+		for iter.Next() {
+			updated.SetMapIndex(iter.Key(), iter.Value())
+		}
+		updated.SetMapIndex(mapKey, elem)
+		v.Set(updated)
+		return nil
+	default:
+		return fmt.Errorf("unknown config key %q", key)
+	}
+
+	if hasIndex {
+		i, err := strconv.Atoi(strings.TrimSuffix(index, "]"))
+		if err != nil || !strings.HasSuffix(index, "]") {
+			return fmt.Errorf("invalid index in config key %q", key)
+		}
+		if (v.Kind() != reflect.Array && v.Kind() != reflect.Slice) || i < 0 || i >= v.Len() {
+			return fmt.Errorf("index out of range in config key %q", key)
+		}
+		v = v.Index(i)
+	}
+
+	return setKey(v, parts[1:], key, value)
+}
+
+func decodeValue(v reflect.Value, key, value string) error {
+	kind := v.Kind()
+	if (kind == reflect.Array || kind == reflect.Slice) && !strings.HasPrefix(strings.TrimSpace(value), "[") {
+		value = "[" + value + "]"
+	}
+
+	// decode into a copy so a partial struct like {max: 5} keeps the other fields
+	decoded := reflect.New(v.Type())
+	decoded.Elem().Set(v)
+	if err := yaml.Unmarshal([]byte(value), decoded.Interface()); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	v.Set(decoded.Elem())
+
+	return nil
+}
+
+func fieldByKey(v reflect.Value, name string) (reflect.Value, bool) {
+	for i := 0; i < v.NumField(); i++ {
+		if keyName(v.Type().Field(i)) == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// keyName returns the config file key of a struct field
+func keyName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
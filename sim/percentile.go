@@ -0,0 +1,42 @@
+package sim
+
+import (
+	"math"
+	"sort"
+)
+
+// Samples are the observed values of a measure, kept whole so any percentile can be read off
+type Samples []float32
+
+// Percentiles are the quantiles of Samples reported for tail latencies, NaN without samples
+type Percentiles struct {
+	P50 Number `json:"p50"`
+	P90 Number `json:"p90"`
+	P99 Number `json:"p99"`
+}
+
+// Percentiles returns the median, 90th and 99th percentile of the samples
+func (s Samples) Percentiles() Percentiles {
+	sorted := append(Samples(nil), s...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return Percentiles{sorted.percentile(50), sorted.percentile(90), sorted.percentile(99)}
+}
+
+// percentile returns the p-th percentile of the sorted samples by the nearest rank method
+func (s Samples) percentile(p float64) Number {
+	if len(s) == 0 {
+		return Number(math.NaN())
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(s))))
+	return Number(s[max(rank, 1)-1])
+}
+
+func (s Samples) sum() float64 {
+	var sum float64
+	for _, v := range s {
+		sum += float64(v)
+	}
+	return sum
+}
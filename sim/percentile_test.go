@@ -0,0 +1,44 @@
+package sim
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPercentiles(t *testing.T) {
+	hundred := make(Samples, 100)
+	for i := range hundred {
+		hundred[i] = float32(100 - i) // 100 down to 1, unsorted
+	}
+	tests := []struct {
+		name    string
+		samples Samples
+		want    Percentiles
+	}{
+		{"one sample", Samples{4}, Percentiles{4, 4, 4}},
+		{"nearest rank", Samples{5, 1, 4, 2, 3}, Percentiles{3, 5, 5}},
+		{"ties", Samples{2, 2, 2, 7}, Percentiles{2, 7, 7}},
+		{"hundred", hundred, Percentiles{50, 90, 99}},
+		{"ten", Samples{10, 9, 8, 7, 6, 5, 4, 3, 2, 1}, Percentiles{5, 9, 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := append(Samples(nil), tt.samples...)
+			if got := tt.samples.Percentiles(); got != tt.want {
+				t.Errorf("Percentiles() = %+v, want %+v", got, tt.want)
+			}
+			for i := range before {
+				if tt.samples[i] != before[i] {
+					t.Fatalf("Percentiles sorted the samples in place")
+				}
+			}
+		})
+	}
+}
+
+func TestPercentilesWithoutSamples(t *testing.T) {
+	got := Samples(nil).Percentiles()
+	if !math.IsNaN(float64(got.P50)) || !math.IsNaN(float64(got.P90)) || !math.IsNaN(float64(got.P99)) {
+		t.Errorf("Percentiles() of no samples = %+v, want NaN", got)
+	}
+}
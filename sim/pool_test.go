@@ -0,0 +1,59 @@
+package sim
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTakenCarStartsAfresh(t *testing.T) {
+	s := New(DefaultConfig())
+	for i := 0; i < 100; i++ {
+		s.carPool.Put(&Car{
+			ID: 99, Fuel: 2, Units: 40, Receipt: 50, Arrived: time.Now(), CheckoutTicket: 7, Register: 3,
+			PayAtPump: true, Prepaid: true, heldPump: new(Station), Loyal: true, Discount: 2, Payment: 1,
+			events: 1, gone: true, TargetLevel: 0.9,
+		})
+		car := s.takeCar()
+		car.draw(i, 1, Range{Min: 40, Max: 60}, 100, s.rng)
+
+		want := Car{ID: i, Fuel: 1, WaitTime: car.WaitTime, FuelTankSize: car.FuelTankSize}
+		if *car != want {
+			t.Fatalf("car %d keeps state of a previous car: %+v", i, *car)
+		}
+	}
+}
+
+// TestPooledRunMatchesUnpooled checks that reusing the cars of a run changes none of its stats,
+// with prepaying and paying at the pump to carry over as well
+func TestPooledRunMatchesUnpooled(t *testing.T) {
+	for _, config := range []Config{poolConfig(false), poolConfig(true)} {
+		pooled, err := RunDeterministic(context.Background(), config, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		golden, err := pooled.GoldenStats()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		config.RandomSeed = 3
+		unpooled := New(config)
+		unpooled.carPool = nil
+		if err := unpooled.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if err := unpooled.Results().CompareGolden(golden); err != nil {
+			t.Errorf("prepay %v: the pooled run differs from the unpooled one: %v", config.Prepay, err)
+		}
+	}
+}
+
+// poolConfig is a few hours of the default config with a share of the customers paying at the pump
+func poolConfig(prepay bool) Config {
+	config := DefaultConfig()
+	config.SimulationLength = Duration(6 * time.Hour)
+	config.Prepay = prepay
+	config.PayAtPump = &PayAtPump{Share: 0.3, Time: TimeRange{Min: 20, Max: 40}}
+	return config
+}
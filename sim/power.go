@@ -0,0 +1,168 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SharedPower is the power budget the chargers of a fuel share, every charging car slows down evenly once
+// all of them together would draw more than the site can supply
+type SharedPower struct {
+	ChargerPower float32 `json:"charger_power" yaml:"charger_power"` // a single charger at full power, e.g. in kW
+	SitePower    float32 `json:"site_power" yaml:"site_power"`       // all chargers together
+}
+
+func validateSharedPower(p *SharedPower, key string, invalid func(key, format string, args ...interface{})) {
+	if p == nil {
+		return
+	}
+	if p.ChargerPower <= 0 {
+		invalid(key+".shared_power.charger_power", "must be greater than 0, got %v", p.ChargerPower)
+	}
+	if p.SitePower <= 0 {
+		invalid(key+".shared_power.site_power", "must be greater than 0, got %v", p.SitePower)
+	}
+}
+
+// share returns the share of full power every one of the charging cars gets
+func (p *SharedPower) share(charging int) float64 {
+	demand := float64(charging) * float64(p.ChargerPower)
+	if demand <= float64(p.SitePower) {
+		return 1
+	}
+	return float64(p.SitePower) / demand
+}
+
+// powerBank is the shared power of the chargers of a fuel in a realtime run, guarded by mu
+type powerBank struct {
+	power    *SharedPower
+	mu       sync.Mutex
+	charging int
+	changed  chan struct{} // closed when a car starts or stops charging
+}
+
+func newPowerBanks(s *Simulation) []*powerBank {
+	banks := make([]*powerBank, len(s.fuelNames))
+	for i, name := range s.fuelNames {
+		if p := s.config.Fuels[name].SharedPower; p != nil {
+			banks[i] = &powerBank{power: p, changed: make(chan struct{})}
+		}
+	}
+	return banks
+}
+
+// join adds a charging car, or removes one for -1, and wakes the others to pick up their new share
+func (b *powerBank) join(delta int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.charging += delta
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// state returns the current share of full power and a channel closed once it changes
+func (b *powerBank) state() (float64, <-chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.power.share(b.charging), b.changed
+}
+
+// chargeShared charges a car for work seconds at full power at a charger of the bank, slower while it
+// shares the power, and reports whether it finished before ctx was cancelled
+func (s *Simulation) chargeShared(ctx context.Context, bank *powerBank, work float32) bool {
+	bank.join(1)
+	defer bank.join(-1)
+
+	remaining := float64(work)
+	for remaining > 0 {
+		share, changed := bank.state()
+		start := s.realtimeElapsed()
+		select {
+		case <-s.clock.after(secondsToDuration(float32(remaining / share))):
+			remaining = 0
+		case <-changed:
+			remaining -= (s.realtimeElapsed() - start).Seconds() * share
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// virtualPowerBank is the shared power of the chargers of a fuel in a virtual run, reschedules the end of
+// every session when a car starts or stops charging
+type virtualPowerBank struct {
+	power    *SharedPower
+	sessions []*chargingSession
+	since    time.Duration // of the current share
+}
+
+// chargingSession is a car charging at a shared power bank
+type chargingSession struct {
+	remaining float64 // seconds at full power
+	round     int     // only the latest scheduled end of the session counts
+	fueling   *fueling
+}
+
+func newVirtualPowerBanks(s *Simulation) []*virtualPowerBank {
+	banks := make([]*virtualPowerBank, len(s.fuelNames))
+	for i, name := range s.fuelNames {
+		if p := s.config.Fuels[name].SharedPower; p != nil {
+			banks[i] = &virtualPowerBank{power: p}
+		}
+	}
+	return banks
+}
+
+// charge starts a session of the refuel time at full power for the fueling, which finishes once it is over
+func (g *virtualGasStation) charge(bank *virtualPowerBank, f *fueling) {
+	g.advancePower(bank)
+	bank.sessions = append(bank.sessions, &chargingSession{remaining: float64(f.refuelTime), fueling: f})
+	g.schedulePower(bank)
+}
+
+// advancePower takes the charging done since the share last changed off the remaining work of the sessions
+func (g *virtualGasStation) advancePower(bank *virtualPowerBank) {
+	share := bank.power.share(len(bank.sessions))
+	done := (g.sched.now - bank.since).Seconds() * share
+	for _, session := range bank.sessions {
+		session.remaining = max(0, session.remaining-done)
+	}
+	bank.since = g.sched.now
+}
+
+// schedulePower schedules the end of every session at the current share
+func (g *virtualGasStation) schedulePower(bank *virtualPowerBank) {
+	share := bank.power.share(len(bank.sessions))
+	for _, session := range bank.sessions {
+		session.round++
+		g.schedule(secondsToDuration(float32(session.remaining/share)), event{kind: eventCharged, session: session, id: session.round})
+	}
+}
+
+// endSession ends the charging session scheduled to end in the round, unless it was rescheduled since
+func (g *virtualGasStation) endSession(session *chargingSession, round int) {
+	if session.round != round {
+		return
+	}
+	f := session.fueling
+	bank := g.powerBanks[f.car.Fuel]
+	g.advancePower(bank)
+	for i, s := range bank.sessions {
+		if s == session {
+			bank.sessions = append(bank.sessions[:i], bank.sessions[i+1:]...)
+			break
+		}
+	}
+	g.schedulePower(bank)
+	g.finishFueling(f, float32((g.sched.now - f.start).Seconds()))
+}
+
+// powerSummary describes how much the shared power slowed down the charging of a fuel for the report
+func powerSummary(f FuelStats) string {
+	return fmt.Sprintf("%.2f s per car on average, %.1f %% of the charging time", f.TimeWaitingForPower/float32(f.CarsRefueled), f.TimeWaitingForPower/f.TimeRefueling*100)
+}
@@ -0,0 +1,31 @@
+package sim
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// joinPrepayQueue counts the car arriving at now as waiting to pay at a cash register before it queues
+// for a station
+func (s *Simulation) joinPrepayQueue(car *Car, now time.Time) {
+	s.enter(car, now)
+	s.waitForCheckout(car, now)
+}
+
+// leaveUnpaid records a car that gave up waiting in the checkout queue to prepay at now, it leaves without fuel
+func (s *Simulation) leaveUnpaid(car *Car, now time.Time) {
+	atomic.AddInt32(&car.stats.CarsInCheckoutQueue, -1)
+	s.leaveCheckoutLine(car, false)
+	s.changeQueue(&s.checkoutWaiting, &s.stats.CheckoutQueueArea, -1, now)
+	waited := now.Sub(car.CheckoutQueueStart)
+	atomic.AddInt32(&car.stats.CarsLeftUnpaid, 1)
+	atomicAddFloat32(&car.stats.TimeBeforeUnpaid, float32(waited.Milliseconds())/1000.0)
+	s.countNotServed(car, waited, now)
+}
+
+// settlePrepaid records the prepaid car driving away refueled at now, its fuel counts as revenue then
+// as the pump stopped at what it paid for
+func (s *Simulation) settlePrepaid(car *Car, now time.Time) {
+	s.collect(car, now)
+	s.leave(car, now)
+}
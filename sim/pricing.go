@@ -0,0 +1,63 @@
+package sim
+
+import (
+	"fmt"
+	"time"
+)
+
+// PricePeriod changes the price of a fuel from a time of day until the next period starts
+type PricePeriod struct {
+	From       Duration `json:"from" yaml:"from"`             // time of day, e.g. "7h" or "17h30m"
+	Multiplier float32  `json:"multiplier" yaml:"multiplier"` // applied to the base pricing
+}
+
+func (p PricePeriod) start() Duration { return p.From }
+
+// SurgePricing raises the price of a fuel while many cars are waiting for its stations
+type SurgePricing struct {
+	QueueLength int     `json:"queue_length" yaml:"queue_length"` // cars waiting in the refuel queue before the surge starts
+	Multiplier  float32 `json:"multiplier" yaml:"multiplier"`
+}
+
+// validatePricing checks the price rules of the fuel at key
+func validatePricing(fc FuelConfig, key string, invalid func(key, format string, args ...interface{})) {
+	validateSchedule(fc.PriceSchedule, key+".price_schedule", invalid)
+	for i, period := range fc.PriceSchedule {
+		if period.Multiplier < 0 {
+			invalid(fmt.Sprintf("%s.price_schedule[%d].multiplier", key, i), "must not be negative, got %v", period.Multiplier)
+		}
+	}
+
+	if fc.Surge != nil {
+		if fc.Surge.QueueLength <= 0 {
+			invalid(key+".surge.queue_length", "must be greater than 0, got %v", fc.Surge.QueueLength)
+		}
+		if fc.Surge.Multiplier < 0 {
+			invalid(key+".surge.multiplier", "must not be negative, got %v", fc.Surge.Multiplier)
+		}
+	}
+}
+
+// DynamicPricing reports whether the price of the fuel changes during a run
+func (fc FuelConfig) DynamicPricing() bool {
+	return len(fc.PriceSchedule) > 0 || fc.Surge != nil
+}
+
+// scheduleMultiplier returns the multiplier of the price period in effect elapsed into the run
+func (fc FuelConfig) scheduleMultiplier(elapsed time.Duration) float32 {
+	if len(fc.PriceSchedule) == 0 {
+		return 1
+	}
+	return periodAt(fc.PriceSchedule, elapsed).Multiplier
+}
+
+// fuelPrice returns the price per unit of the fuel in effect elapsed into the run
+func (s *Simulation) fuelPrice(fuel FuelType, elapsed time.Duration) float32 {
+	fc := s.fuelConfig(fuel)
+	price := fc.Pricing * fc.scheduleMultiplier(elapsed)
+
+	if fc.Surge != nil && int(s.gauge(func(st *Stats) *int32 { return &st.Fuels[fuel].CarsInRefuelQueue })) >= fc.Surge.QueueLength {
+		price *= fc.Surge.Multiplier
+	}
+	return price
+}
@@ -0,0 +1,62 @@
+package sim
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFuelPrice(t *testing.T) {
+	schedule := []PricePeriod{{From: Duration(7 * time.Hour), Multiplier: 1.2}, {From: Duration(20 * time.Hour), Multiplier: 0.9}}
+	surge := &SurgePricing{QueueLength: 3, Multiplier: 1.5}
+	tests := []struct {
+		name     string
+		schedule []PricePeriod
+		surge    *SurgePricing
+		elapsed  time.Duration
+		queue    int32
+		want     float32
+	}{
+		{name: "base price", elapsed: 10 * time.Hour, want: 2},
+		{name: "morning period", schedule: schedule, elapsed: 7 * time.Hour, want: 2.4},
+		{name: "evening period", schedule: schedule, elapsed: 21 * time.Hour, want: 1.8},
+		{name: "evening period goes on past midnight", schedule: schedule, elapsed: 3 * time.Hour, want: 1.8},
+		{name: "next day", schedule: schedule, elapsed: day + 8*time.Hour, want: 2.4},
+		{name: "short queue", surge: surge, elapsed: time.Hour, queue: 2, want: 2},
+		{name: "surge", surge: surge, elapsed: time.Hour, queue: 3, want: 3},
+		{name: "surge on the period price", schedule: schedule, surge: surge, elapsed: 8 * time.Hour, queue: 5, want: 3.6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			changeFuel(&config, "gas", func(fc *FuelConfig) {
+				fc.Pricing = 2
+				fc.PriceSchedule = tt.schedule
+				fc.Surge = tt.surge
+			})
+			s := New(config)
+			s.newShards(1)
+			gas := fuelIndex(t, s, "gas")
+			s.shared().Fuels[gas].CarsInRefuelQueue = tt.queue
+
+			if got := s.fuelPrice(gas, tt.elapsed); !closeTo(got, tt.want) {
+				t.Errorf("fuelPrice at %v with %d cars waiting = %v, want %v", tt.elapsed, tt.queue, got, tt.want)
+			}
+		})
+	}
+}
+
+func fuelIndex(t *testing.T, s *Simulation, name string) FuelType {
+	t.Helper()
+	for i, fuel := range s.fuelNames {
+		if fuel == name {
+			return FuelType(i)
+		}
+	}
+	t.Fatalf("no fuel %s", name)
+	return 0
+}
+
+// closeTo reports whether got is want up to the rounding of float32
+func closeTo(got, want float32) bool {
+	return got-want < 1e-5 && want-got < 1e-5
+}
@@ -0,0 +1,609 @@
+package sim
+
+import (
+	"context"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// runRealtime runs the simulation on a pool of car workers, sleeping for every service time
+func (s *Simulation) runRealtime(ctx context.Context) error {
+	// every goroutine of the run stops once it returns
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	s.streams = true
+
+	stations := s.newStations()
+	stationCounts := make([]int, len(s.fuelNames))
+	for _, station := range stations {
+		stationCounts[station.Fuel]++
+	}
+	s.stationChs = make([]chan Station, len(s.fuelNames))
+	for fuel, count := range stationCounts {
+		s.stationChs[fuel] = make(chan Station, count)
+	}
+	s.carWork = make(chan Car)
+	s.powerBanks = newPowerBanks(s)
+	s.checkoutChannel = make(chan Car)
+	if s.config.registerQueues() {
+		s.registerChs = make([]chan Car, s.config.CashRegisterCount)
+		for id := range s.registerChs {
+			s.registerChs[id] = make(chan Car)
+		}
+	}
+	s.checkoutSlots = make(chan struct{}, s.config.CheckoutQueueCapacity)
+	s.cashRegisterChannel = make(chan CashRegister, s.config.CashRegisterCount)
+	s.spawningStopped = make(chan struct{})
+	s.attendantCh = make(chan int, s.config.AttendantCount)
+	for _, attendant := range s.newAttendants() {
+		s.attendantCh <- attendant
+	}
+	s.newStaffPools()
+	s.shiftCh = make(chan struct{})
+	var free []Station
+	s.forecourts, free = newForecourts(s, stations) // a token for every lane
+	registers := s.newRegisters()
+	s.carWorkers = s.config.MaxCarWorkers
+	if s.carWorkers == 0 {
+		s.carWorkers = defaultMaxCarWorkers
+	}
+	s.newShards(realtimeShards(s.carWorkers, len(registers)))
+
+	s.start = s.wallClock().Now()
+	s.clock.start(s.wallClock())
+	s.goWorker(func() { s.clock.runTimers(runCtx) })
+	if s.config.Weather != nil {
+		weather := s.workerRand(streamWeather, 0)
+		until := s.changeWeather(weather, 0)
+		s.goWorker(func() { s.runWeather(runCtx, weather, until) })
+	}
+
+	if s.config.Warmup > 0 {
+		s.goWorker(func() {
+			select {
+			case <-s.clock.after(time.Duration(s.config.Warmup)):
+				s.endWarmup()
+			case <-runCtx.Done():
+			}
+		})
+	}
+
+	if s.config.SampleInterval > 0 {
+		s.goWorker(func() { s.sampleQueuesRealtime(runCtx) })
+	}
+	if s.source != nil {
+		s.goWorker(func() { s.sourceCars(runCtx) })
+	} else {
+		s.goWorker(func() { s.spawnCars(runCtx) })
+	}
+	s.goWorker(func() { s.manageGasStation(runCtx, free, registers) })
+	for i := 0; i < s.carWorkers; i++ {
+		shard := s.shard(i)
+		s.goWorker(func() { s.carWorker(runCtx, shard) })
+	}
+	if len(s.config.Shifts) > 0 {
+		s.goWorker(func() { s.runShifts(runCtx) })
+	}
+	if s.config.PumpFailures.MTBF > 0 {
+		for i, station := range stations {
+			rng := s.workerRand(streamStation, i)
+			s.goWorker(func() { s.breakDownStations(runCtx, rng, station.Fuel) })
+		}
+	}
+	stopDashboard := make(chan struct{})
+	dashboardFinished := make(chan struct{})
+	if s.Dashboard != nil {
+		go s.runDashboard(stationCounts, stopDashboard, dashboardFinished)
+	} else {
+		close(dashboardFinished)
+	}
+	if (s.Dashboard == nil && s.LiveStats != nil) || s.LiveJSON != nil {
+		s.goWorker(func() { s.printCurrentStats(runCtx) })
+	}
+
+	select {
+	case <-s.clock.after(time.Duration(s.config.SimulationLength)):
+	case <-ctx.Done():
+	}
+	close(s.spawningStopped)
+	s.drain(ctx)
+	s.endObservation()
+	close(stopDashboard)
+	<-dashboardFinished
+
+	// cars that outlasted the drain timeout give up, the stats are final once every goroutine returned
+	stop()
+	s.workers.Wait()
+
+	return ctx.Err()
+}
+
+// goWorker runs f in a goroutine of the realtime run, which the run waits for before it returns
+func (s *Simulation) goWorker(f func()) {
+	s.workers.Add(1)
+	go func() {
+		defer s.workers.Done()
+		f()
+	}()
+}
+
+// drain waits for the cars inside to leave, at most for the drain timeout
+func (s *Simulation) drain(ctx context.Context) {
+	timeout := s.drainTimeout(ctx)
+	if timeout <= 0 {
+		return
+	}
+
+	deadline := s.clock.after(timeout)
+	poll := s.wallClock().NewTicker(10 * time.Millisecond)
+	defer poll.Stop()
+	for atomic.LoadInt32(&s.carsInside) > 0 {
+		select {
+		case <-poll.Chan():
+		case <-deadline:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Simulation) checkoutCar(ctx context.Context, cashReg CashRegister) {
+	// an idle cash register hands itself back when the shift changes, to close if it's no longer needed
+	shiftChanged := s.shiftChanged()
+	if s.registerPool.excess() {
+		s.cashRegisterChannel <- cashReg
+		return
+	}
+
+	// take out the car, from the queue of the register when it has one
+	checkout := s.checkoutChannel
+	if s.registerChs != nil {
+		checkout = s.registerChs[cashReg.ID]
+	}
+	var car Car
+	select {
+	case car = <-checkout:
+		car.stats = s.registerShard(cashReg.ID)
+	case <-shiftChanged:
+		s.cashRegisterChannel <- cashReg
+		return
+	case <-ctx.Done():
+		return
+	}
+	checkoutTime := s.beginCheckout(&car, cashReg, s.realtimeNow())
+	s.occupyRegister(&car, 1)
+	s.trace(s.realtimeElapsed(), EventStartedCheckout, &car, nil, &cashReg)
+
+	//fmt.Printf("Checking out car ID: %v, at cash register ID: %v, for %vs %v\n", car.ID, cashReg.ID, checkoutTime, time.Now())
+	if !s.clock.wait(ctx, secondsToDuration(checkoutTime)) {
+		return
+	}
+
+	s.checkedOut(&car, cashReg, s.realtimeNow())
+	s.trace(s.realtimeElapsed(), EventPaid, &car, nil, &cashReg)
+	s.occupyRegister(&car, -1)
+	if car.Prepaid {
+		car.paid <- car // back to the worker of the car, waiting for it to refuel
+	}
+	s.returnHeldPump(&car)
+	s.cashRegisterChannel <- cashReg
+}
+
+// prepayCar sends the arrived car to pay at a cash register first, it balks while the checkout queue is full
+func (s *Simulation) prepayCar(ctx context.Context, car Car) {
+	select {
+	case s.checkoutSlots <- struct{}{}:
+	default:
+		s.balk(&car, s.realtimeNow())
+		s.trace(s.realtimeElapsed(), EventBalked, &car, nil, nil)
+		return
+	}
+
+	s.joinPrepayQueue(&car, s.realtimeNow())
+	atomic.AddInt32(&car.stats.CarsInCheckoutQueue, 1)
+	s.trace(s.realtimeElapsed(), EventJoinedCheckout, &car, nil, nil)
+	car.paid = make(chan Car, 1)
+	if !s.awaitCheckout(ctx, car, s.checkoutPatience(&car)) {
+		return
+	}
+
+	select {
+	case paid := <-car.paid:
+		paid.stats = car.stats // back on the shard of the worker
+		s.refuelCar(ctx, paid)
+	case <-ctx.Done():
+	}
+}
+
+// refuelCar queues the car for a station and refuels it, a prepaid car comes from its cash register
+// and waits even if the queue is full
+func (s *Simulation) refuelCar(ctx context.Context, car Car) {
+	if !car.Prepaid && len(s.getStationCh(car.Fuel)) == 0 && s.queueFull(car.Fuel, int(s.gauge(func(st *Stats) *int32 { return &st.Fuels[car.Fuel].CarsInRefuelQueue }))) {
+		s.balk(&car, s.realtimeNow())
+		s.trace(s.realtimeElapsed(), EventBalked, &car, nil, nil)
+		return
+	}
+
+	// car is waiting for a station to free up
+	s.joinRefuelQueue(&car, s.realtimeNow())
+	s.trace(s.realtimeElapsed(), EventJoinedRefuelQueue, &car, nil, nil)
+
+	// assign correct station
+	select {
+	case station := <-s.getStationCh(car.Fuel):
+		station = s.enterLane(station)
+		// car moves from queue to station
+		s.startFueling(&car, station, s.realtimeNow())
+		attendant := -1
+		if station.Attended {
+			select {
+			case attendant = <-s.attendantCh:
+				s.attendantArrived(&car, attendant, s.realtimeNow(), false)
+			default:
+				select {
+				case attendant = <-s.attendantCh:
+					s.attendantArrived(&car, attendant, s.realtimeNow(), true)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+		// refuel the car for random time within bounds
+		refuelTime := s.drawRefuelTime(&car, station)
+		unitPrice := s.fuelPrice(car.Fuel, s.realtimeElapsed())
+		s.trace(s.realtimeElapsed(), EventStartedFueling, &car, &station, nil)
+		//fmt.Printf("Refueling car ID: %v, fuel type: %v, for %vs\n", car.ID, s.fuelNames[car.Fuel], refuelTime)
+		fuelingTime := refuelTime
+		if bank := s.powerBanks[car.Fuel]; bank != nil {
+			start := s.realtimeElapsed()
+			if !s.chargeShared(ctx, bank, refuelTime) {
+				return
+			}
+			fuelingTime = float32((s.realtimeElapsed() - start).Seconds())
+		} else if !s.clock.wait(ctx, secondsToDuration(refuelTime)) {
+			return
+		}
+
+		s.chargeRefuel(&car, station, refuelTime, fuelingTime, unitPrice)
+		s.trace(s.realtimeElapsed(), EventFinishedFueling, &car, &station, nil)
+		if attendant >= 0 {
+			s.attendantLeft(attendant, s.realtimeNow())
+			if s.attendantPool.stayOnDuty(attendant) {
+				s.attendantCh <- attendant
+			}
+		}
+		if car.PayAtPump {
+			car.CheckoutQueueStart = s.realtimeNow() // no queue, paying starts right away
+			payTime := s.randomInRange(car.rng, s.config.PayAtPump.Time)
+			if !s.clock.wait(ctx, secondsToDuration(payTime)) {
+				return
+			}
+			s.paidAtPump(&car, payTime, s.realtimeNow())
+			s.trace(s.realtimeElapsed(), EventPaid, &car, &station, nil)
+			s.vacate(car.stats, station)
+			return
+		}
+		if car.Prepaid {
+			s.settlePrepaid(&car, s.realtimeNow())
+			s.vacate(car.stats, station)
+			return
+		}
+
+		// forward car to checkout queue
+		s.waitForCheckout(&car, s.realtimeNow())
+		patience := s.checkoutPatience(&car)
+		if !s.enterCheckoutQueue(ctx, &car, station, patience) {
+			return
+		}
+		atomic.AddInt32(&car.stats.CarsInCheckoutQueue, 1)
+		s.trace(s.realtimeElapsed(), EventJoinedCheckout, &car, nil, nil)
+
+		// return station back to channel, or once the car paid when it holds it until then
+		if s.config.HoldPump {
+			s.holdPump(&car, station)
+		} else {
+			s.vacate(car.stats, station)
+		}
+
+		s.awaitCheckout(ctx, car, patience)
+	case <-s.clock.after(secondsToDuration(car.WaitTime)):
+		// car left without refueling
+		s.leaveUnserved(&car, s.realtimeNow())
+		s.trace(s.realtimeElapsed(), EventLeftUnserved, &car, nil, nil)
+	case <-ctx.Done():
+	}
+}
+
+// checkoutPatience returns a channel closed once the car has waited to check out for as long as it will,
+// nil for cars that wait forever
+func (s *Simulation) checkoutPatience(car *Car) <-chan struct{} {
+	if car.CheckoutWaitTime <= 0 {
+		return nil
+	}
+	return s.clock.after(secondsToDuration(car.CheckoutWaitTime))
+}
+
+// enterCheckoutQueue takes a place in the checkout queue for the refueled car, waiting at its station
+// while the queue is full. It returns false when the car ran out of patience and drove off first,
+// giving back the station, or when ctx is cancelled.
+func (s *Simulation) enterCheckoutQueue(ctx context.Context, car *Car, station Station, patience <-chan struct{}) bool {
+	select {
+	case s.checkoutSlots <- struct{}{}:
+		return true
+	default:
+	}
+
+	s.blockPump(car)
+	select {
+	case s.checkoutSlots <- struct{}{}:
+		s.unblockPump(car, s.realtimeNow())
+		return true
+	case <-patience:
+		s.unblockPump(car, s.realtimeNow())
+		s.driveOff(car, s.realtimeNow(), false)
+		s.trace(s.realtimeElapsed(), EventDroveOff, car, &station, nil)
+		s.vacate(car.stats, station)
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// awaitCheckout hands the car in the checkout queue to the next free cash register, in the order the cars
+// joined, unless it drives off first, or leaves without fuel when prepaying. Its place in the queue is freed
+// either way. It reports whether a cash register took the car.
+func (s *Simulation) awaitCheckout(ctx context.Context, car Car, patience <-chan struct{}) bool {
+	defer func() { <-s.checkoutSlots }()
+
+	s.joinCheckoutLine(&car)
+	checkout := s.checkoutChannel
+	if s.registerChs != nil {
+		checkout = s.registerChs[car.Register]
+	}
+	select {
+	case checkout <- car:
+		return true
+	case <-patience:
+		if car.Prepaid {
+			s.leaveUnpaid(&car, s.realtimeNow())
+			s.trace(s.realtimeElapsed(), EventLeftUnserved, &car, nil, nil)
+			return false
+		}
+		s.driveOff(&car, s.realtimeNow(), true)
+		s.trace(s.realtimeElapsed(), EventDroveOff, &car, nil, nil)
+		s.returnHeldPump(&car)
+	case <-ctx.Done():
+	}
+	return false
+}
+
+// returnHeldPump hands the pump the car held until it paid or drove off back to its channel, if it held one
+func (s *Simulation) returnHeldPump(car *Car) {
+	if station := s.releasePump(car, s.realtimeNow()); station != nil {
+		s.vacate(car.stats, *station)
+	}
+}
+
+// enterLane takes the car with the token of a lane to its station, handing the token on while the lane
+// has room; stations without lanes are taken as they are
+func (s *Simulation) enterLane(token Station) Station {
+	f := s.forecourts[token.Fuel]
+	if f == nil {
+		return token
+	}
+	station, next := f.enter(token)
+	if next != nil {
+		s.getStationCh(token.Fuel) <- *next
+	}
+	return station
+}
+
+// vacate lets the car at the station drive away and hands the station back, in a lane once the cars
+// in front of it left; the car waits in its lane without a goroutine while its driver goes on. The
+// station is counted as free in the shard of the caller.
+func (s *Simulation) vacate(shard *Stats, station Station) {
+	f := s.forecourts[station.Fuel]
+	if f == nil {
+		s.leaveStation(shard, station, s.realtimeNow())
+		s.getStationCh(station.Fuel) <- station
+		return
+	}
+	if f.finish(station, s.realtimeNow()) {
+		s.leaveLane(shard, f, station)
+	}
+}
+
+// leaveLane frees the station in its lane, the refueled cars behind it that waited for it leave along,
+// and hands the token of the lane back once it opened up again
+func (s *Simulation) leaveLane(shard *Stats, f *forecourt, station Station) {
+	s.leaveStation(shard, station, s.realtimeNow())
+	blocked, unblocked, token := f.leave(station, s.realtimeNow())
+	s.leftLane(blocked)
+	for _, behind := range unblocked {
+		s.leaveLane(shard, f, behind)
+	}
+	if token != nil {
+		s.getStationCh(station.Fuel) <- *token
+	}
+}
+
+func (s *Simulation) manageGasStation(ctx context.Context, stations []Station, registers []CashRegister) {
+	// spawn stations
+	for _, station := range stations {
+		s.getStationCh(station.Fuel) <- station
+	}
+
+	for _, register := range registers {
+		s.cashRegisterChannel <- register
+	}
+
+	for {
+		select {
+		case cashReg := <-s.cashRegisterChannel:
+			if s.registerPool.stayOnDuty(cashReg.ID) {
+				s.goWorker(func() { s.checkoutCar(ctx, cashReg) })
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// breakDownStations repeatedly takes the next free station of the fuel type out of service for a repair,
+// one goroutine runs per station
+func (s *Simulation) breakDownStations(ctx context.Context, rng *rand.Rand, fuel FuelType) {
+	for {
+		if !s.clock.wait(ctx, s.timeToFailure(rng)) {
+			return
+		}
+		var station Station
+		select {
+		case station = <-s.getStationCh(fuel):
+		case <-ctx.Done():
+			return
+		}
+
+		elapsed := s.realtimeElapsed()
+		if elapsed >= time.Duration(s.config.SimulationLength) {
+			// cars draining after the end still need it
+			s.getStationCh(fuel) <- station
+			return
+		}
+		if !s.clock.wait(ctx, s.breakStation(rng, fuel, elapsed)) {
+			return
+		}
+
+		s.repaired(fuel)
+		s.getStationCh(fuel) <- station
+	}
+}
+
+// sourceCars sends the arrivals of the car source to the station at their time
+func (s *Simulation) sourceCars(ctx context.Context) {
+	for {
+		a, ok := s.source.Next(s.realtimeElapsed(), s.rng)
+		if !ok {
+			return
+		}
+		select {
+		case <-s.clock.after(a.Time - s.realtimeElapsed()):
+			if !s.isOpen(a.Time) {
+				continue
+			}
+			if car := s.sourcedCar(a); car != nil {
+				s.sendCar(ctx, car)
+			}
+		case <-s.spawningStopped:
+			return
+		}
+	}
+}
+
+func (s *Simulation) spawnCars(ctx context.Context) {
+	// ticks are kept on a fixed grid so sleeping overhead doesn't add up
+	var tick time.Duration
+	for {
+		if next, ok := s.nextArrival(s.realtimeElapsed()); ok {
+			select {
+			case <-s.clock.after(next):
+				if s.isOpen(s.realtimeElapsed()) {
+					s.sendCar(ctx, s.spawnCar())
+				}
+			case <-s.spawningStopped:
+				return
+			}
+			tick = s.realtimeElapsed()
+			continue
+		}
+
+		tick += spawnInterval
+		select {
+		case <-s.clock.at(tick):
+			if s.isOpen(s.realtimeElapsed()) && s.rng.Float32() < s.carSpawnChance(s.realtimeElapsed()) {
+				s.sendCar(ctx, s.spawnCar())
+			}
+		case <-s.spawningStopped:
+			return
+		}
+	}
+}
+
+// sendCar hands a spawned car to a free car worker, dropping it when the run is over. The car waits at
+// the entrance while every worker is busy, holding up the ones after it.
+func (s *Simulation) sendCar(ctx context.Context, car *Car) {
+	s.trace(s.realtimeElapsed(), EventSpawned, car, nil, nil)
+	select {
+	case s.carWork <- *car:
+	case <-ctx.Done():
+	}
+}
+
+// carWorker takes the cars of a realtime run through their visit one after the other, each from the
+// entrance until it left or a cash register took it, refueling a prepaid car once it paid; their
+// counters go to the shard of the worker
+func (s *Simulation) carWorker(ctx context.Context, shard *Stats) {
+	for {
+		select {
+		case car := <-s.carWork:
+			car.stats = shard
+			switch {
+			case s.siteFull():
+				s.turnAway(&car, s.realtimeNow())
+				s.trace(s.realtimeElapsed(), EventTurnedAway, &car, nil, nil)
+			case car.Prepaid:
+				s.prepayCar(ctx, car)
+			default:
+				s.refuelCar(ctx, car)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// printCurrentStats writes the live stats to LiveStats and LiveJSON every live_stats.interval
+func (s *Simulation) printCurrentStats(ctx context.Context) {
+	// own ticker, sharing the spawn ticker would steal every tick printed on from spawnCars
+	statsTicker := s.wallClock().NewTicker(s.config.LiveStats.liveInterval())
+	defer statsTicker.Stop()
+
+	for {
+		select {
+		case <-statsTicker.Chan():
+			// the numbers don't change while paused
+			if s.Paused() {
+				continue
+			}
+			stats, elapsed, names := s.Results().Stats, s.realtimeElapsed(), s.config.LiveStats.liveMetricNames()
+			if s.LiveStats != nil && s.Dashboard == nil {
+				printLiveStats(s.LiveStats, names, stats, elapsed)
+			}
+			if s.LiveJSON != nil {
+				writeLiveJSON(s.LiveJSON, s.metadata.ID, s.wallClock().Now(), names, stats, elapsed)
+			}
+		case <-s.spawningStopped:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Simulation) getStationCh(fuel FuelType) chan Station {
+	if fuel < 0 || int(fuel) >= len(s.stationChs) {
+		return nil
+	}
+	return s.stationChs[fuel]
+}
+
+// realtimeElapsed returns the simulated time since the start of a realtime run
+func (s *Simulation) realtimeElapsed() time.Duration {
+	return s.clock.elapsed()
+}
+
+// realtimeNow returns the simulated time of a realtime run
+func (s *Simulation) realtimeNow() time.Time {
+	return s.start.Add(s.clock.elapsed())
+}
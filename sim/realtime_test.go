@@ -0,0 +1,54 @@
+package sim
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+// TestRealtimeGoroutinesBounded runs realtime hours on a fake clock and checks that the goroutines of
+// the run stay within its car workers, cash registers and the handful of goroutines of every run, also
+// with cars waiting in lanes and prepaid cars coming back from the cash registers
+func TestRealtimeGoroutinesBounded(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(*Config)
+	}{
+		{"lanes", func(c *Config) {
+			gas := c.Fuels["gas"]
+			gas.Lanes = []int{2, 2}
+			c.Fuels["gas"] = gas
+		}},
+		{"prepay", func(c *Config) { c.Prepay = true }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := harnessConfig()
+			config.Realtime = true
+			config.RandomSeed = 1
+			config.MaxCarWorkers = 8
+			config.CarSpawnChance = SpawnChance{Chance: 1}
+			tt.change(&config)
+			if err := config.Validate(); err != nil {
+				t.Fatal(err)
+			}
+
+			simulation := New(config)
+			before := runtime.NumGoroutine()
+			peak := 0
+			clock := NewFakeClock(fakeStart)
+			simulation.Clock = clock
+			err := runAdvancing(simulation, clock, time.Second, func() { peak = max(peak, runtime.NumGoroutine()-before) })
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if limit := config.MaxCarWorkers + config.CashRegisterCount + 16; peak > limit {
+				t.Errorf("the run took up to %d goroutines, expected at most %d", peak, limit)
+			}
+			if stats := simulation.Results().Stats; stats.Total().CarsCheckedOut == 0 {
+				t.Error("no car checked out")
+			}
+		})
+	}
+}
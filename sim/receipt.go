@@ -0,0 +1,61 @@
+package sim
+
+import "time"
+
+// Receipt is the transaction of a car that paid, times are simulated seconds since the start
+type Receipt struct {
+	Car             int     `json:"car"`
+	Fuel            string  `json:"fuel"`
+	Units           float32 `json:"units"`
+	UnitPrice       float32 `json:"unit_price"`
+	Amount          float32 `json:"amount"`            // for the fuel
+	Shop            float32 `json:"shop"`              // spent in the shop
+	Payment         string  `json:"payment,omitempty"` // method at the cash register, with payment methods
+	Arrived         float64 `json:"arrived"`
+	FuelingStarted  float64 `json:"fueling_started"`
+	FuelingFinished float64 `json:"fueling_finished"`
+	Paid            float64 `json:"paid"`
+}
+
+// paymentName returns how the car paid, "pump" for paying at the pump and empty
+// without payment methods
+func (s *Simulation) paymentName(car *Car) string {
+	switch {
+	case car.PayAtPump:
+		return "pump"
+	case len(s.payments) > 0:
+		return s.payments[car.Payment]
+	}
+	return ""
+}
+
+// receipt passes the receipt of the car that paid at now, or drove away refueled after prepaying, to Receipts,
+// if it is set
+func (s *Simulation) receipt(car *Car, now time.Time) {
+	if s.Receipts == nil {
+		return
+	}
+
+	since := func(t time.Time) float64 { return t.Sub(s.start).Seconds() }
+	finished, paid := car.CheckoutQueueStart, now
+	if car.Prepaid {
+		finished, paid = now, car.Paid
+	}
+	r := Receipt{
+		Car:             car.ID,
+		Fuel:            s.fuelNames[car.Fuel],
+		Units:           car.Units,
+		UnitPrice:       car.UnitPrice,
+		Amount:          car.Receipt,
+		Shop:            car.ShopAmount,
+		Payment:         s.paymentName(car),
+		Arrived:         since(car.Arrived),
+		FuelingStarted:  since(car.FuelingStart),
+		FuelingFinished: since(finished),
+		Paid:            since(paid),
+	}
+
+	s.receiptsMu.Lock()
+	defer s.receiptsMu.Unlock()
+	s.Receipts(r)
+}
@@ -0,0 +1,100 @@
+package sim
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+// Arrival is a recorded car arriving at the station
+type Arrival struct {
+	Time     time.Duration // since the start
+	Fuel     string
+	TankSize float32
+	WaitTime float32
+	Class    string // vehicle class, empty without classes
+}
+
+// ReadArrivals reads the spawned events of an NDJSON trace written to Simulation.Trace
+func ReadArrivals(r io.Reader) ([]Arrival, error) {
+	var arrivals []Arrival
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var e TraceEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if e.Event != EventSpawned {
+			continue
+		}
+		if e.TankSize == nil || e.WaitTime == nil {
+			return nil, fmt.Errorf("line %d: spawned event without tank_size and wait_time", line)
+		}
+
+		arrivals = append(arrivals, Arrival{
+			Time:     time.Duration(e.Time * float64(time.Second)),
+			Fuel:     e.Fuel,
+			TankSize: *e.TankSize,
+			WaitTime: *e.WaitTime,
+			Class:    e.Class,
+		})
+	}
+
+	return arrivals, scanner.Err()
+}
+
+// Replay makes cars arrive as recorded instead of spawning them at random, arrivals after
+// the simulation length are ignored. It has to be called before Run.
+func (s *Simulation) Replay(arrivals []Arrival) error {
+	for i, a := range arrivals {
+		if _, ok := s.config.Fuels[a.Fuel]; !ok {
+			return fmt.Errorf("arrival %d: fuel type %q is not configured", i, a.Fuel)
+		}
+		if _, ok := s.config.VehicleClasses[a.Class]; len(s.classes) > 0 && !ok {
+			return fmt.Errorf("arrival %d: vehicle class %q is not configured", i, a.Class)
+		}
+		if i > 0 && a.Time < arrivals[i-1].Time {
+			return fmt.Errorf("arrival %d: arrivals must be sorted by time", i)
+		}
+	}
+
+	s.source = &arrivalList{arrivals: arrivals}
+	return nil
+}
+
+// replayCar creates the car of a recorded arrival and counts it as spawned
+func (s *Simulation) replayCar(a Arrival) *Car {
+	car := s.takeCar()
+	*car = Car{
+		ID:           s.carID,
+		Fuel:         s.fuelType(a.Fuel),
+		FuelTankSize: a.TankSize,
+		WaitTime:     a.WaitTime,
+		Class:        max(0, slices.Index(s.classes, a.Class)),
+		rng:          s.workerRand(streamCar, s.carID),
+	}
+
+	s.carID++
+	s.countSpawned(car)
+	s.drawPayment(car)
+
+	return car
+}
+
+// fuelType returns the FuelType of the configured fuel
+func (s *Simulation) fuelType(name string) FuelType {
+	for i, n := range s.fuelNames {
+		if n == name {
+			return FuelType(i)
+		}
+	}
+	return -1
+}
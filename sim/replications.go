@@ -0,0 +1,139 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// Replicate runs the config n times with consecutive seeds starting at its random_seed,
+// or at a seed picked from the current time when that's 0
+func Replicate(ctx context.Context, config Config, n int) ([]Results, error) {
+	if config.RandomSeed == 0 {
+		config.RandomSeed = time.Now().UnixNano()
+	}
+
+	results := make([]Results, 0, n)
+	for i := 0; i < n; i++ {
+		replication := config
+		replication.RandomSeed = config.RandomSeed + int64(i)
+
+		simulation := New(replication)
+		if err := simulation.Run(ctx); err != nil {
+			return results, err
+		}
+		results = append(results, simulation.Results())
+	}
+	return results, nil
+}
+
+// Metric is a key figure of a run reported across replications
+type Metric struct {
+	Name   string
+	Column string // short name for tables
+	Value  func(r Results) float64
+}
+
+// KeyMetrics are summarized over replications
+var KeyMetrics = []Metric{
+	{"Cars not served rate (%)", "not served %", func(r Results) float64 {
+		return float64(r.Stats.CarsNotServed) / float64(r.Stats.CarsSpawnedTotal) * 100
+	}},
+	{"Cars checked out rate (%)", "checked out %", func(r Results) float64 {
+		return float64(r.Stats.Total().CarsCheckedOut) / float64(r.Stats.CarsSpawnedTotal) * 100
+	}},
+	{"Average time in checkout queue (s)", "checkout queue s", func(r Results) float64 {
+		return float64(r.Stats.TimeInCheckoutQueue) / float64(r.Stats.registerCars())
+	}},
+	{"Average time before leaving unserved (s)", "before leaving s", func(r Results) float64 {
+		return float64(r.Stats.TimeBeforeLeaving) / float64(r.Stats.CarsNotServed)
+	}},
+	{"Average time spent at gas station (s)", "at station s", func(r Results) float64 {
+		return float64(r.Stats.timeAtStation()) / float64(r.Stats.Total().CarsCheckedOut)
+	}},
+	{"Revenue (€)", "revenue €", func(r Results) float64 {
+		return float64(r.Stats.Total().Cash)
+	}},
+	{"Average receipt (€)", "receipt €", func(r Results) float64 {
+		total := r.Stats.Total()
+		return float64(total.Cash) / float64(total.CarsCheckedOut)
+	}},
+}
+
+// Estimate is the mean of a metric over replications with the half width of its 95% confidence interval
+type Estimate struct {
+	Name      string `json:"name"`
+	Mean      Number `json:"mean"`
+	HalfWidth Number `json:"half_width"`
+}
+
+// Summarize estimates the key metrics over the results of replications
+func Summarize(results []Results) []Estimate {
+	estimates := make([]Estimate, len(KeyMetrics))
+	for i, metric := range KeyMetrics {
+		values := make([]float64, len(results))
+		for j, r := range results {
+			values[j] = metric.Value(r)
+		}
+		mean, halfWidth := confidenceInterval(values)
+		estimates[i] = Estimate{metric.Name, Number(mean), Number(halfWidth)}
+	}
+	return estimates
+}
+
+// PrintSummary writes the estimates of the key metrics over the results of replications
+func PrintSummary(w io.Writer, results []Results) {
+	fmt.Fprintln(w, "-----------------------------------------------------------------")
+	fmt.Fprintf(w, "Replications: %d, seeds %d to %d\n", len(results), results[0].Config.RandomSeed, results[len(results)-1].Config.RandomSeed)
+	for _, e := range Summarize(results) {
+		fmt.Fprintf(w, "%-42s %10.2f ± %.2f\n", e.Name+":", e.Mean, e.HalfWidth)
+	}
+	fmt.Fprintln(w, "-----------------------------------------------------------------")
+}
+
+// confidenceInterval returns the mean and the half width of the 95% confidence interval
+// of the values using Student's t distribution, NaN values of runs without cars are skipped
+func confidenceInterval(values []float64) (mean, halfWidth float64) {
+	var n int
+	var sum float64
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			sum += v
+			n++
+		}
+	}
+	if n == 0 {
+		return math.NaN(), math.NaN()
+	}
+	mean = sum / float64(n)
+	if n == 1 {
+		return mean, math.NaN()
+	}
+
+	var squares float64
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			squares += (v - mean) * (v - mean)
+		}
+	}
+	stddev := math.Sqrt(squares / float64(n-1))
+
+	return mean, tCritical(n-1) * stddev / math.Sqrt(float64(n))
+}
+
+// two-sided 95% critical values of Student's t distribution by degrees of freedom
+var tTable = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+func tCritical(df int) float64 {
+	if df <= len(tTable) {
+		return tTable[df-1]
+	}
+	// close enough to the normal distribution
+	return 1.96
+}
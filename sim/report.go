@@ -0,0 +1,215 @@
+package sim
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// Report is the final report of a run for encoding, e.g. as JSON
+type Report struct {
+	Run        *Metadata   `json:"run,omitempty"`
+	Config     Config      `json:"config"`
+	Stats      Stats       `json:"stats"`
+	Averages   Averages    `json:"averages"`
+	Histograms *Histograms `json:"histograms,omitempty"` // with histogram_buckets set
+	Resources  *Resources  `json:"resources,omitempty"`  // of the process while the run went on
+}
+
+// Averages are the figures derived from the stats, undefined ones such as the average receipt
+// of a run without checked out cars are NaN
+type Averages struct {
+	CheckedOutRate      Number `json:"checked_out_rate"` // percent of spawned cars
+	NotServedRate       Number `json:"not_served_rate"`
+	BalkedRate          Number `json:"balked_rate"`
+	DriveOffRate        Number `json:"drive_off_rate"` // percent of refueled cars
+	Receipt             Number `json:"receipt"`
+	ShopPurchaseRate    Number `json:"shop_purchase_rate"`  // percent of checked out cars
+	LoyaltyPenetration  Number `json:"loyalty_penetration"` // percent of checked out cars
+	ShopReceipt         Number `json:"shop_receipt"`
+	ShopRevenueShare    Number `json:"shop_revenue_share"` // percent of fuel and shop revenue
+	TimeRefueling       Number `json:"time_refueling"`     // seconds
+	TimeInRefuel        Number `json:"time_in_refuel_queue"`
+	TimeCheckingOut     Number `json:"time_checking_out"` // at a cash register
+	TimeInCheckout      Number `json:"time_in_checkout_queue"`
+	TimeBeforeLeaving   Number `json:"time_before_leaving"`
+	TimeAtStation       Number `json:"time_at_station"`
+	BlockedRate         Number `json:"blocked_rate"` // percent of refueled cars that waited at their pump for room in the checkout queue
+	TimeBlocked         Number `json:"time_blocked"` // of the blocked cars
+	Utilization         Number `json:"utilization"`  // percent of the observed time the pumps had a car
+	RegisterUtilization Number `json:"register_utilization"`
+
+	RefuelQueueWait   Percentiles `json:"refuel_queue_wait"` // seconds, of the cars that got a station
+	CheckoutQueueWait Percentiles `json:"checkout_queue_wait"`
+
+	RefuelQueueLittlesLaw   LittlesLaw `json:"refuel_queue_littles_law"`
+	CheckoutQueueLittlesLaw LittlesLaw `json:"checkout_queue_littles_law"`
+
+	Fuels     []FuelAverages     `json:"fuels"`     // indexed by FuelType
+	Stations  []StationAverages  `json:"stations"`  // indexed by station ID
+	Registers []RegisterAverages `json:"registers"` // indexed by cash register ID
+	Payments  []PaymentAverages  `json:"payments,omitempty"`
+
+	AttendantUtilization    Number              `json:"attendant_utilization"`
+	TimeWaitingForAttendant Number              `json:"time_waiting_for_attendant"` // of the cars that waited
+	Attendants              []AttendantAverages `json:"attendants,omitempty"`       // indexed by attendant ID
+
+	Costs *CostReport `json:"costs,omitempty"` // with costs set
+}
+
+// AttendantAverages are the averages of a single forecourt attendant
+type AttendantAverages struct {
+	ID          int    `json:"id"`
+	Utilization Number `json:"utilization"`
+}
+
+// PaymentAverages are the averages of the checkouts paid by a single payment method
+type PaymentAverages struct {
+	Name            string `json:"name"`
+	Share           Number `json:"share"` // percent of the checkouts at the cash registers
+	TimeCheckingOut Number `json:"time_checking_out"`
+}
+
+// FuelAverages are the averages of the cars of a single fuel type
+type FuelAverages struct {
+	Name          string `json:"name"`
+	Unit          string `json:"unit"`
+	Receipt       Number `json:"receipt"`
+	Units         Number `json:"units"`
+	Price         Number `json:"price"`           // per unit
+	NotServedRate Number `json:"not_served_rate"` // percent of the spawned cars of the fuel type
+	BalkedRate    Number `json:"balked_rate"`
+	TimeRefueling Number `json:"time_refueling"`
+	TimeInRefuel  Number `json:"time_in_refuel_queue"`
+	Utilization   Number `json:"utilization"`
+
+	RefuelQueueWait   Percentiles `json:"refuel_queue_wait"`
+	CheckoutQueueWait Percentiles `json:"checkout_queue_wait"`
+}
+
+// StationAverages are the averages of a single pump
+type StationAverages struct {
+	ID          int    `json:"id"`
+	Fuel        string `json:"fuel"`
+	Utilization Number `json:"utilization"`
+}
+
+// RegisterAverages are the averages of a single cash register
+type RegisterAverages struct {
+	ID             int    `json:"id"`
+	Utilization    Number `json:"utilization"`
+	TimeInCheckout Number `json:"time_in_checkout_queue"` // of the cars it checked out
+}
+
+// Number is a float that encodes NaN and infinities as JSON null
+type Number float64
+
+func (n Number) MarshalJSON() ([]byte, error) {
+	if math.IsNaN(float64(n)) || math.IsInf(float64(n), 0) {
+		return []byte("null"), nil
+	}
+	return json.Marshal(float64(n))
+}
+
+// Report derives the averages of the run
+func (r Results) Report() Report {
+	stats := r.Stats
+	total := stats.Total()
+	div := func(a, b float32) Number { return Number(float64(a) / float64(b)) }
+
+	averages := Averages{
+		CheckedOutRate:     div(float32(total.CarsCheckedOut), float32(stats.CarsSpawnedTotal)) * 100,
+		NotServedRate:      div(float32(stats.CarsNotServed), float32(stats.CarsSpawnedTotal)) * 100,
+		BalkedRate:         div(float32(stats.CarsBalked), float32(stats.CarsSpawnedTotal)) * 100,
+		DriveOffRate:       div(float32(stats.CarsDroveOff), float32(total.CarsRefueled)) * 100,
+		Receipt:            div(total.Cash, float32(total.CarsCheckedOut)),
+		ShopPurchaseRate:   div(float32(stats.ShopPurchases), float32(total.CarsCheckedOut)) * 100,
+		LoyaltyPenetration: div(float32(stats.LoyaltyCustomers), float32(total.CarsCheckedOut)) * 100,
+		ShopReceipt:        div(stats.ShopRevenue, float32(stats.ShopPurchases)),
+		ShopRevenueShare:   div(stats.ShopRevenue, total.Cash+stats.ShopRevenue) * 100,
+		TimeRefueling:      div(total.TimeRefueling, float32(total.CarsRefueled)),
+		TimeInRefuel:       div(total.TimeInRefuelQueue, float32(total.CarsRefueled)),
+		TimeCheckingOut:    div(stats.CheckoutTimeTotal, float32(stats.registerCars())),
+		TimeInCheckout:     div(stats.TimeInCheckoutQueue, float32(stats.registerCars())),
+		TimeBeforeLeaving:  div(stats.TimeBeforeLeaving, float32(stats.CarsNotServed)),
+		TimeAtStation:      div(stats.timeAtStation(), float32(total.CarsCheckedOut)),
+		BlockedRate:        div(float32(stats.CarsBlocked), float32(total.CarsRefueled)) * 100,
+		TimeBlocked:        div(stats.TimeBlocked, float32(stats.CarsBlocked)),
+		RefuelQueueWait:    total.RefuelQueueWaits.Percentiles(),
+		CheckoutQueueWait:  total.CheckoutQueueWaits.Percentiles(),
+		Utilization:        r.utilization(stationsBusyTime(stats.Stations), len(stats.Stations)),
+
+		RefuelQueueLittlesLaw: r.littlesLaw(stats.RefuelQueueArea, len(total.RefuelQueueWaits)+int(stats.CarsNotServed-stats.CarsLeftUnpaid),
+			total.RefuelQueueWaits.sum()+float64(stats.TimeBeforeLeaving-stats.TimeBeforeUnpaid)),
+		CheckoutQueueLittlesLaw: r.littlesLaw(stats.CheckoutQueueArea, len(total.CheckoutQueueWaits)+int(stats.CarsDroveOff+stats.CarsLeftUnpaid),
+			total.CheckoutQueueWaits.sum()+float64(stats.TimeBeforeDriveOff+stats.TimeBeforeUnpaid)),
+	}
+	for _, f := range stats.Fuels {
+		averages.Fuels = append(averages.Fuels, FuelAverages{
+			Name:          f.Name,
+			Unit:          r.Config.FuelUnit(f.Name),
+			Receipt:       div(f.Cash, float32(f.CarsCheckedOut)),
+			Units:         div(f.Units, float32(f.CarsCheckedOut)),
+			Price:         div(f.Cash, f.Units),
+			NotServedRate: div(float32(f.CarsNotServed), float32(f.CarsSpawned)) * 100,
+			BalkedRate:    div(float32(f.CarsBalked), float32(f.CarsSpawned)) * 100,
+			TimeRefueling: div(f.TimeRefueling, float32(f.CarsRefueled)),
+			TimeInRefuel:  div(f.TimeInRefuelQueue, float32(f.CarsRefueled)),
+			Utilization:   r.utilization(stationsBusyTime(stats.FuelStations(f.Name)), len(stats.FuelStations(f.Name))),
+
+			RefuelQueueWait:   f.RefuelQueueWaits.Percentiles(),
+			CheckoutQueueWait: f.CheckoutQueueWaits.Percentiles(),
+		})
+	}
+
+	for _, st := range stats.Stations {
+		averages.Stations = append(averages.Stations, StationAverages{ID: st.ID, Fuel: st.Fuel, Utilization: r.utilization(float64(st.BusyTime), 1)})
+	}
+
+	var registersBusy float64
+	for _, reg := range stats.Registers {
+		registersBusy += float64(reg.BusyTime)
+		averages.Registers = append(averages.Registers, RegisterAverages{
+			ID:             reg.ID,
+			Utilization:    r.utilization(float64(reg.BusyTime), 1),
+			TimeInCheckout: div(reg.TimeInCheckoutQueue, float32(reg.CarsCheckedOut)),
+		})
+	}
+	averages.RegisterUtilization = r.utilization(registersBusy, len(stats.Registers))
+
+	var attendantsBusy float64
+	for _, a := range stats.Attendants {
+		attendantsBusy += float64(a.BusyTime)
+		averages.Attendants = append(averages.Attendants, AttendantAverages{ID: a.ID, Utilization: r.utilization(float64(a.BusyTime), 1)})
+	}
+	averages.AttendantUtilization = r.utilization(attendantsBusy, len(stats.Attendants))
+	averages.TimeWaitingForAttendant = div(stats.TimeWaitingForAttendant, float32(stats.CarsWaitedForAttendant))
+	averages.Costs = r.costs()
+
+	var checkouts int32
+	for _, p := range stats.Payments {
+		checkouts += p.Checkouts
+	}
+	for _, p := range stats.Payments {
+		averages.Payments = append(averages.Payments, PaymentAverages{
+			Name:            p.Name,
+			Share:           div(float32(p.Checkouts), float32(checkouts)) * 100,
+			TimeCheckingOut: div(p.CheckoutTime, float32(p.Checkouts)),
+		})
+	}
+
+	return Report{Run: r.Metadata, Config: r.Config, Stats: stats, Averages: averages, Histograms: stats.histograms(r.Config.HistogramBuckets), Resources: r.Resources}
+}
+
+// utilization returns the percent of the observed time count pumps or registers, busy for busy seconds
+// together, had a car, NaN without any
+func (r Results) utilization(busy float64, count int) Number {
+	return Number(busy / (r.Config.observedTime() * float64(count)) * 100)
+}
+
+func stationsBusyTime(stations []StationStats) float64 {
+	var busy float64
+	for _, st := range stations {
+		busy += float64(st.BusyTime)
+	}
+	return busy
+}
@@ -0,0 +1,68 @@
+package sim
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/metrics"
+	"time"
+)
+
+// how often a run samples the goroutines and the heap of the process
+const resourceSampleInterval = 20 * time.Millisecond
+
+// heapMetric is the bytes taken by heap objects, live or not yet swept
+const heapMetric = "/memory/classes/heap/objects:bytes"
+
+// Resources is what a run took of the process, the peaks are those of the whole process,
+// also of other simulations running alongside in it
+type Resources struct {
+	WallTime       float64 `json:"wall_time"` // seconds
+	PeakGoroutines int     `json:"peak_goroutines"`
+	PeakHeap       uint64  `json:"peak_heap"` // bytes
+}
+
+// watchResources samples the process until the returned function is called, which returns the peaks
+func watchResources() func() *Resources {
+	start := time.Now()
+	r := new(Resources)
+	sample := []metrics.Sample{{Name: heapMetric}}
+	measure := func() {
+		r.PeakGoroutines = max(r.PeakGoroutines, runtime.NumGoroutine())
+		metrics.Read(sample)
+		if sample[0].Value.Kind() == metrics.KindUint64 {
+			r.PeakHeap = max(r.PeakHeap, sample[0].Value.Uint64())
+		}
+	}
+	measure()
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(resourceSampleInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				measure()
+			}
+		}
+	}()
+
+	return func() *Resources {
+		close(done)
+		<-stopped
+		measure()
+		r.WallTime = time.Since(start).Seconds()
+		return r
+	}
+}
+
+// print writes the resources to the final report
+func (r *Resources) print(w io.Writer) {
+	fmt.Fprintf(w, "Run took %.2f s of wall-clock time, peak %d goroutines and %.1f MiB heap\n",
+		r.WallTime, r.PeakGoroutines, float64(r.PeakHeap)/(1<<20))
+}
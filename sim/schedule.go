@@ -0,0 +1,125 @@
+package sim
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// day is the length of daily schedules, simulations start at midnight
+const day = 24 * time.Hour
+
+// dailyPeriod is an entry of a daily schedule, lasting from its start until the next entry starts
+type dailyPeriod interface {
+	start() Duration
+}
+
+// periodAt returns the period of the schedule in effect elapsed into the run,
+// before the first period of the day the last one of the previous day still applies
+func periodAt[P dailyPeriod](schedule []P, elapsed time.Duration) P {
+	timeOfDay := Duration(elapsed % day)
+	current := schedule[len(schedule)-1]
+	for _, period := range schedule {
+		if period.start() > timeOfDay {
+			break
+		}
+		current = period
+	}
+	return current
+}
+
+// untilNextPeriod returns how long after elapsed into the run the next period of the schedule starts
+func untilNextPeriod[P dailyPeriod](schedule []P, elapsed time.Duration) time.Duration {
+	timeOfDay := elapsed % day
+	for _, period := range schedule {
+		if start := time.Duration(period.start()); start > timeOfDay {
+			return start - timeOfDay
+		}
+	}
+	return day - timeOfDay + time.Duration(schedule[0].start())
+}
+
+// validateSchedule checks the start times of a daily schedule at key
+func validateSchedule[P dailyPeriod](schedule []P, key string, invalid func(key, format string, args ...interface{})) {
+	for i, period := range schedule {
+		periodKey := fmt.Sprintf("%s[%d].from", key, i)
+		if period.start() < 0 || time.Duration(period.start()) >= day {
+			invalid(periodKey, "must be a time of day between 0 and 24h, got %v", time.Duration(period.start()))
+		}
+		if i > 0 && period.start() <= schedule[i-1].start() {
+			invalid(periodKey, "periods must be sorted by time of day")
+		}
+	}
+}
+
+// SpawnChance is the chance of a car arriving, given in config files either as a number
+// or as a daily schedule like [{from: 0h, chance: 0.1}, {from: 7h, chance: 0.8}]
+type SpawnChance struct {
+	Chance   float32 // used without a schedule
+	Schedule []ChancePeriod
+}
+
+// ChancePeriod sets the spawn chance from a time of day until the next period starts
+type ChancePeriod struct {
+	From   Duration `json:"from" yaml:"from"`
+	Chance float32  `json:"chance" yaml:"chance"`
+}
+
+func (p ChancePeriod) start() Duration { return p.From }
+
+// At returns the spawn chance elapsed into the run
+func (c SpawnChance) At(elapsed time.Duration) float32 {
+	if len(c.Schedule) == 0 {
+		return c.Chance
+	}
+	return periodAt(c.Schedule, elapsed).Chance
+}
+
+func (c *SpawnChance) UnmarshalJSON(b []byte) error {
+	var schedule []ChancePeriod
+	if err := json.Unmarshal(b, &schedule); err == nil {
+		*c = SpawnChance{Schedule: schedule}
+		return nil
+	}
+
+	var chance float32
+	if err := json.Unmarshal(b, &chance); err != nil {
+		return fmt.Errorf("invalid spawn chance %s, expected a number or a list of periods", b)
+	}
+	*c = SpawnChance{Chance: chance}
+	return nil
+}
+
+func (c *SpawnChance) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.SequenceNode {
+		var schedule []ChancePeriod
+		if err := value.Decode(&schedule); err != nil {
+			return err
+		}
+		*c = SpawnChance{Schedule: schedule}
+		return nil
+	}
+
+	var chance float32
+	if err := value.Decode(&chance); err != nil {
+		return fmt.Errorf("invalid spawn chance %q, expected a number or a list of periods", value.Value)
+	}
+	*c = SpawnChance{Chance: chance}
+	return nil
+}
+
+func (c SpawnChance) MarshalJSON() ([]byte, error) {
+	if len(c.Schedule) > 0 {
+		return json.Marshal(c.Schedule)
+	}
+	return json.Marshal(c.Chance)
+}
+
+func (c SpawnChance) MarshalYAML() (interface{}, error) {
+	if len(c.Schedule) > 0 {
+		return c.Schedule, nil
+	}
+	return c.Chance, nil
+}
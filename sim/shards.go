@@ -0,0 +1,87 @@
+package sim
+
+import (
+	"math"
+	"reflect"
+	"runtime"
+	"sync/atomic"
+)
+
+// newShards splits the counters of the stats into n shards once the stations, cash registers and
+// attendants are created. The first one is shared, by the car spawning, the weather, the breakdowns and
+// everything of a virtual run; every car worker and cash register of a realtime run updates one of the
+// others, so the cores don't contend for the cache lines of the same counters. Results adds them up.
+func (s *Simulation) newShards(n int) {
+	s.shards = make([]Stats, n)
+	for i := range s.shards {
+		s.shards[i] = Stats{
+			Fuels:      make([]FuelStats, len(s.stats.Fuels)),
+			Registers:  make([]RegisterStats, len(s.stats.Registers)),
+			Payments:   make([]PaymentStats, len(s.stats.Payments)),
+			Attendants: make([]AttendantStats, len(s.stats.Attendants)),
+			Weather:    make([]WeatherStats, len(s.stats.Weather)),
+		}
+	}
+}
+
+// realtimeShards returns how many shards a realtime run of the car workers and cash registers takes,
+// a few per core are enough to keep them apart
+func realtimeShards(workers, registers int) int {
+	return 1 + min(workers+registers, 4*runtime.GOMAXPROCS(0))
+}
+
+// shared returns the shard of the updates that don't come from a car worker or cash register
+func (s *Simulation) shared() *Stats {
+	return &s.shards[0]
+}
+
+// shard returns the shard of the car worker with the index, the cash registers following the workers
+func (s *Simulation) shard(i int) *Stats {
+	if len(s.shards) == 1 {
+		return s.shared()
+	}
+	return &s.shards[1+i%(len(s.shards)-1)]
+}
+
+// registerShard returns the shard of the cash register with the ID
+func (s *Simulation) registerShard(id int) *Stats {
+	return s.shard(s.carWorkers + id)
+}
+
+// gauge sums a live count over the shards, loading it atomically
+func (s *Simulation) gauge(count func(shard *Stats) *int32) int32 {
+	var total int32
+	for i := range s.shards {
+		total += atomic.LoadInt32(count(&s.shards[i]))
+	}
+	return total
+}
+
+// add adds the counters of the shard to the snapshot, loading them atomically as the run updates them
+// meanwhile; the names and IDs are those of the snapshot
+func (st *Stats) add(shard *Stats) {
+	addAtomically(reflect.ValueOf(st).Elem(), reflect.ValueOf(shard).Elem())
+}
+
+// addAtomically adds the int32 and float32 values in src, loaded atomically, to those in dst, also those
+// in its structs and the elements of its slices of structs; its other slices are left out
+func addAtomically(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Int32:
+		dst.SetInt(dst.Int() + int64(atomic.LoadInt32((*int32)(src.Addr().UnsafePointer()))))
+	case reflect.Float32:
+		value := math.Float32frombits(atomic.LoadUint32((*uint32)(src.Addr().UnsafePointer())))
+		dst.SetFloat(float64(float32(dst.Float()) + value))
+	case reflect.Struct:
+		for i := 0; i < src.NumField(); i++ {
+			addAtomically(dst.Field(i), src.Field(i))
+		}
+	case reflect.Slice:
+		if src.Type().Elem().Kind() != reflect.Struct {
+			return
+		}
+		for i := 0; i < min(src.Len(), dst.Len()); i++ {
+			addAtomically(dst.Index(i), src.Index(i))
+		}
+	}
+}
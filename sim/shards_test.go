@@ -0,0 +1,105 @@
+package sim
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStatsAdd(t *testing.T) {
+	stats := Stats{
+		CarsSpawnedTotal: 3,
+		RefuelQueueArea:  1.5,
+		Fuels:            []FuelStats{{Name: "gas", CarsSpawned: 3, Cash: 10, RefuelQueueWaits: Samples{1, 2}}},
+		Registers:        []RegisterStats{{ID: 4, BusyTime: 60}},
+	}
+	shard := Stats{
+		CarsSpawnedTotal: 2,
+		Fuels:            []FuelStats{{CarsSpawned: 2, Cash: 5.5, CarsInRefuelQueue: -1}},
+		Registers:        []RegisterStats{{CarsCheckedOut: 1, TimeInCheckoutQueue: 8}},
+	}
+	stats.add(&shard)
+
+	want := Stats{
+		CarsSpawnedTotal: 5,
+		RefuelQueueArea:  1.5,
+		Fuels:            []FuelStats{{Name: "gas", CarsSpawned: 5, Cash: 15.5, CarsInRefuelQueue: -1}},
+		Registers:        []RegisterStats{{ID: 4, CarsCheckedOut: 1, BusyTime: 60, TimeInCheckoutQueue: 8}},
+	}
+	if stats.CarsSpawnedTotal != want.CarsSpawnedTotal || stats.RefuelQueueArea != want.RefuelQueueArea {
+		t.Errorf("got %d cars and %v car-seconds, expected %d and %v",
+			stats.CarsSpawnedTotal, stats.RefuelQueueArea, want.CarsSpawnedTotal, want.RefuelQueueArea)
+	}
+	if f, w := stats.Fuels[0], want.Fuels[0]; f.Name != w.Name || f.CarsSpawned != w.CarsSpawned || f.Cash != w.Cash ||
+		f.CarsInRefuelQueue != w.CarsInRefuelQueue || len(f.RefuelQueueWaits) != 2 {
+		t.Errorf("got fuel %+v, expected %+v with the 2 samples kept", f, w)
+	}
+	if stats.Registers[0] != want.Registers[0] {
+		t.Errorf("got register %+v, expected %+v", stats.Registers[0], want.Registers[0])
+	}
+}
+
+// TestShardsAddUp runs realtime hours on a fake clock with the cars spread over the shards of the car
+// workers and cash registers and checks that the merged stats add up, also while the run goes on
+func TestShardsAddUp(t *testing.T) {
+	config := harnessConfig()
+	config.Realtime = true
+	config.RandomSeed = 1
+	config.MaxCarWorkers = 8
+	config.CarSpawnChance = SpawnChance{Chance: 1}
+	config.SampleInterval = Duration(time.Minute)
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	simulation := New(config)
+	// the queues are sampled once the run is set up, like the live stats are printed
+	var started atomic.Bool
+	simulation.QueueSamples = func(QueueSample) { started.Store(true) }
+	snapshots := 0
+	clock := NewFakeClock(fakeStart)
+	simulation.Clock = clock
+	err := runAdvancing(simulation, clock, time.Second, func() {
+		if !started.Load() {
+			return
+		}
+		stats := simulation.Results().Stats
+		if total := stats.Total(); total.CarsSpawned != stats.CarsSpawnedTotal {
+			t.Fatalf("%d cars spawned by fuel type, %d in total", total.CarsSpawned, stats.CarsSpawnedTotal)
+		}
+		checkGauges(t, stats)
+		snapshots++
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(simulation.shards); n < 2 {
+		t.Fatalf("the realtime run took %d shards", n)
+	}
+	stats := simulation.Results().Stats
+	total := stats.Total()
+	if total.CarsSpawned != stats.CarsSpawnedTotal || total.CarsCheckedOut == 0 || snapshots == 0 {
+		t.Errorf("%d cars spawned by fuel type, %d in total, %d checked out in %d snapshots",
+			total.CarsSpawned, stats.CarsSpawnedTotal, total.CarsCheckedOut, snapshots)
+	}
+	if total.CarsCheckedOut != stats.registerCars()+stats.CarsPaidAtPump {
+		t.Errorf("%d cars checked out, %d at the cash registers and %d at the pump",
+			total.CarsCheckedOut, stats.registerCars(), stats.CarsPaidAtPump)
+	}
+	checkGauges(t, stats)
+}
+
+// checkGauges fails the test unless the live counts of the stats are possible ones, the shards of the
+// cash registers count the cars leaving the checkout queue the car workers counted joining it
+func checkGauges(t *testing.T, stats Stats) {
+	t.Helper()
+	total := stats.Total()
+	if total.CarsInRefuelQueue < 0 || total.CarsInRefuelQueue != stats.CarsInRefuelQueue ||
+		total.StationsBusy < 0 || int(total.StationsBusy) > len(stats.Stations) ||
+		stats.RegistersBusy < 0 || int(stats.RegistersBusy) > len(stats.Registers) || stats.CarsInCheckoutQueue < 0 {
+		t.Fatalf("%d cars in the refuel queue (%d by fuel type), %d of %d stations busy, %d of %d cash registers busy, %d cars in the checkout queue",
+			stats.CarsInRefuelQueue, total.CarsInRefuelQueue, total.StationsBusy, len(stats.Stations),
+			stats.RegistersBusy, len(stats.Registers), stats.CarsInCheckoutQueue)
+	}
+}
@@ -0,0 +1,153 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Shift sets the cash registers and attendants on duty from a time of day until the next shift starts
+type Shift struct {
+	From          Duration `json:"from" yaml:"from"`
+	CashRegisters int      `json:"cash_registers" yaml:"cash_registers"` // up to cash_register_count
+	Attendants    int      `json:"attendants" yaml:"attendants"`         // up to attendant_count
+}
+
+func (s Shift) start() Duration { return s.From }
+
+func validateShifts(c *Config, invalid func(key, format string, args ...interface{})) {
+	validateSchedule(c.Shifts, "shifts", invalid)
+	for i, shift := range c.Shifts {
+		key := fmt.Sprintf("shifts[%d]", i)
+		if shift.CashRegisters < 1 || shift.CashRegisters > c.CashRegisterCount {
+			invalid(key+".cash_registers", "must be between 1 and cash_register_count %d, got %v", c.CashRegisterCount, shift.CashRegisters)
+		}
+		if shift.Attendants < 0 || shift.Attendants > c.AttendantCount {
+			invalid(key+".attendants", "must be between 0 and attendant_count %d, got %v", c.AttendantCount, shift.Attendants)
+		}
+	}
+}
+
+// staffPool tracks how many cash registers or attendants are on duty as the shifts change,
+// a busy member only goes off duty once it is free
+type staffPool struct {
+	mu      sync.Mutex
+	wanted  int
+	onDuty  int
+	offDuty []int // IDs
+}
+
+func newStaffPool(size int) *staffPool {
+	return &staffPool{wanted: size, onDuty: size}
+}
+
+// stayOnDuty is called with a member that came free, it reports whether the member stays on duty
+// or goes off duty as more are on duty than wanted
+func (p *staffPool) stayOnDuty(id int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.onDuty <= p.wanted {
+		return true
+	}
+	p.onDuty--
+	p.offDuty = append(p.offDuty, id)
+	return false
+}
+
+// resize sets how many members are wanted on duty and returns the ones coming back on duty
+func (p *staffPool) resize(wanted int) []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.wanted = wanted
+	var back []int
+	for p.onDuty < p.wanted && len(p.offDuty) > 0 {
+		back = append(back, p.offDuty[0])
+		p.offDuty = p.offDuty[1:]
+		p.onDuty++
+	}
+	return back
+}
+
+// excess reports whether more members are on duty than wanted
+func (p *staffPool) excess() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.onDuty > p.wanted
+}
+
+// newStaffPools creates the pools of the cash registers and attendants, all on duty
+func (s *Simulation) newStaffPools() {
+	s.registerPool = newStaffPool(s.config.CashRegisterCount)
+	s.attendantPool = newStaffPool(s.config.AttendantCount)
+}
+
+// shiftChanged returns a channel closed at the next change of shifts
+func (s *Simulation) shiftChanged() <-chan struct{} {
+	s.shiftMu.Lock()
+	defer s.shiftMu.Unlock()
+
+	return s.shiftCh
+}
+
+// runShifts changes the staff on duty of a realtime run by the shifts until ctx is cancelled
+func (s *Simulation) runShifts(ctx context.Context) {
+	for {
+		elapsed := s.realtimeElapsed()
+		shift := periodAt(s.config.Shifts, elapsed)
+
+		for _, id := range s.registerPool.resize(shift.CashRegisters) {
+			s.cashRegisterChannel <- CashRegister{ID: id}
+		}
+		for _, id := range s.attendantPool.resize(shift.Attendants) {
+			s.attendantCh <- id
+		}
+		// free attendants go off duty right away, busy ones once their car is refueled
+	idle:
+		for s.attendantPool.excess() {
+			select {
+			case id := <-s.attendantCh:
+				if s.attendantPool.stayOnDuty(id) {
+					s.attendantCh <- id
+				}
+			default:
+				break idle
+			}
+		}
+		// wakes the idle cash registers to check whether they are still on duty
+		s.shiftMu.Lock()
+		close(s.shiftCh)
+		s.shiftCh = make(chan struct{})
+		s.shiftMu.Unlock()
+
+		if !s.clock.wait(ctx, untilNextPeriod(s.config.Shifts, elapsed)) {
+			return
+		}
+	}
+}
+
+// applyShift changes the staff on duty of a virtual run to the shift in effect
+func (g *virtualGasStation) applyShift() {
+	shift := periodAt(g.config.Shifts, g.sched.now)
+
+	for _, id := range g.registerPool.resize(shift.CashRegisters) {
+		g.freeRegisters = append(g.freeRegisters, CashRegister{ID: id})
+	}
+	for len(g.freeRegisters) > 0 && g.registerPool.excess() {
+		g.registerPool.stayOnDuty(g.freeRegisters[len(g.freeRegisters)-1].ID)
+		g.freeRegisters = g.freeRegisters[:len(g.freeRegisters)-1]
+	}
+
+	for _, id := range g.attendantPool.resize(shift.Attendants) {
+		g.dispatchAttendant(id)
+	}
+	for len(g.freeAttendants) > 0 && g.attendantPool.excess() {
+		g.attendantPool.stayOnDuty(g.freeAttendants[len(g.freeAttendants)-1])
+		g.freeAttendants = g.freeAttendants[:len(g.freeAttendants)-1]
+	}
+
+	g.dispatchCheckout()
+	g.schedule(untilNextPeriod(g.config.Shifts, g.sched.now), event{kind: eventShift})
+}
@@ -0,0 +1,135 @@
+package sim
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStaffPool(t *testing.T) {
+	type step struct {
+		resize int   // wanted on duty, -1 for a member coming free
+		free   int   // the member coming free
+		back   []int // members resize brings back on duty
+		stay   bool  // whether the free member stays on duty
+		onDuty int
+		excess bool
+	}
+	tests := []struct {
+		name  string
+		size  int
+		steps []step
+	}{
+		{"all stay on duty", 3, []step{
+			{resize: -1, free: 0, stay: true, onDuty: 3},
+		}},
+		{"smaller shift lets free members go", 3, []step{
+			{resize: 1, onDuty: 3, excess: true},
+			{resize: -1, free: 2, onDuty: 2, excess: true},
+			{resize: -1, free: 0, onDuty: 1},
+			{resize: -1, free: 1, stay: true, onDuty: 1},
+		}},
+		{"larger shift brings them back in order", 3, []step{
+			{resize: 1, onDuty: 3, excess: true},
+			{resize: -1, free: 2, onDuty: 2, excess: true},
+			{resize: -1, free: 0, onDuty: 1},
+			{resize: 2, back: []int{2}, onDuty: 2},
+			{resize: 3, back: []int{0}, onDuty: 3},
+		}},
+		{"shift smaller again before anyone left", 2, []step{
+			{resize: 1, onDuty: 2, excess: true},
+			{resize: 2, onDuty: 2},
+			{resize: -1, free: 1, stay: true, onDuty: 2},
+		}},
+		{"no attendants on a shift", 1, []step{
+			{resize: 0, onDuty: 1, excess: true},
+			{resize: -1, free: 0, onDuty: 0},
+			{resize: 1, back: []int{0}, onDuty: 1},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newStaffPool(tt.size)
+			for i, step := range tt.steps {
+				if step.resize >= 0 {
+					if back := p.resize(step.resize); !reflect.DeepEqual(back, step.back) {
+						t.Errorf("step %d: resize(%d) brought back %v, want %v", i, step.resize, back, step.back)
+					}
+				} else if stay := p.stayOnDuty(step.free); stay != step.stay {
+					t.Errorf("step %d: stayOnDuty(%d) = %v, want %v", i, step.free, stay, step.stay)
+				}
+				if p.onDuty != step.onDuty || p.excess() != step.excess {
+					t.Errorf("step %d: %d on duty with excess %v, want %d and %v", i, p.onDuty, p.excess(), step.onDuty, step.excess)
+				}
+			}
+		})
+	}
+}
+
+func TestShiftSchedule(t *testing.T) {
+	shifts := []Shift{
+		{From: Duration(6 * time.Hour), CashRegisters: 2, Attendants: 1},
+		{From: Duration(14 * time.Hour), CashRegisters: 3, Attendants: 2},
+		{From: Duration(22 * time.Hour), CashRegisters: 1},
+	}
+	tests := []struct {
+		elapsed   time.Duration
+		registers int
+		next      time.Duration
+	}{
+		{0, 1, 6 * time.Hour}, // the night shift of the previous day
+		{6 * time.Hour, 2, 8 * time.Hour},
+		{13*time.Hour + 30*time.Minute, 2, 30 * time.Minute},
+		{14 * time.Hour, 3, 8 * time.Hour},
+		{23 * time.Hour, 1, 7 * time.Hour},
+		{day + 7*time.Hour, 2, 7 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := periodAt(shifts, tt.elapsed).CashRegisters; got != tt.registers {
+			t.Errorf("%d cash registers on duty at %v, want %d", got, tt.elapsed, tt.registers)
+		}
+		if got := untilNextPeriod(shifts, tt.elapsed); got != tt.next {
+			t.Errorf("next shift %v after %v, want %v", got, tt.elapsed, tt.next)
+		}
+	}
+}
+
+// TestShiftsInVirtualRun runs an hour with a single cash register on duty, for the first half hour or
+// all of it, and checks that the registers off duty all the time checked out no cars
+func TestShiftsInVirtualRun(t *testing.T) {
+	config := harnessConfig()
+	config.RandomSeed = 3
+	config.CashRegisterCount = 3
+	config.Shifts = []Shift{{From: 0, CashRegisters: 1}, {From: Duration(30 * time.Minute), CashRegisters: 3}}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	night := config
+	night.Shifts = []Shift{{From: 0, CashRegisters: 1}}
+
+	for _, tt := range []struct {
+		name   string
+		config Config
+		busy   int // registers that checked out cars
+	}{
+		{"one register all the time", night, 1},
+		{"all registers after half an hour", config, 3},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := RunDeterministic(context.Background(), tt.config, tt.config.RandomSeed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			busy := 0
+			for _, register := range results.Stats.Registers {
+				if register.CarsCheckedOut > 0 {
+					busy++
+				}
+			}
+			if busy != tt.busy {
+				t.Errorf("%d cash registers checked out cars, want %d", busy, tt.busy)
+			}
+		})
+	}
+}
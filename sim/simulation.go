@@ -0,0 +1,1019 @@
+// Package sim simulates a gas station with fuel pumps of several fuel types and
+// a shared checkout, either on a virtual clock or in (scaled) wall-clock time.
+package sim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// Simulation is a single run of the gas station model, create it with New
+type Simulation struct {
+	// LiveStats receives the periodic stats printout of realtime runs, nil disables it
+	LiveStats io.Writer
+	// LiveJSON receives the periodic stats of realtime runs as NDJSON, an object per printout
+	LiveJSON io.Writer
+	// Dashboard receives a full-screen view of a realtime run for terminals, replacing LiveStats
+	Dashboard io.Writer
+	// Trace receives an NDJSON line for every step of every car's journey, nil disables it; the first
+	// write that fails ends the trace and Run returns its error
+	Trace io.Writer
+	// Step is called after every event of a virtual run that moved a car on, with the trace events
+	// of that event, the run waits for it to return
+	Step func(events []TraceEvent)
+	// TraceEvents is called with every trace event as it happens, one call at a time
+	TraceEvents func(TraceEvent)
+	// Receipts is called with the receipt of every car that paid, one call at a time
+	Receipts func(Receipt)
+	// QueueSamples is called with every sample of the queues as it is taken every sample_interval,
+	// one call at a time
+	QueueSamples func(QueueSample)
+	// Checkpoints is called with the state of a virtual run every CheckpointInterval of simulated time,
+	// for FromCheckpoint to go on from; the run waits for it to return and stops with its error
+	Checkpoints        func(*Checkpoint) error
+	CheckpointInterval time.Duration
+	// Clock is the wall clock realtime runs are timed by, both engines start their simulated clock
+	// at and the run is stamped by, nil for the system clock; set it before Run or Metadata
+	Clock Clock
+
+	observers    []Observer
+	traceMu      sync.Mutex
+	traceEncoder *json.Encoder
+	traceStopped bool
+	traceErr     error        // of the first Trace write that failed
+	stepEvents   []TraceEvent // produced by the current event for Step
+	receiptsMu   sync.Mutex
+
+	metadata  Metadata // stamped by Metadata
+	stamp     sync.Once
+	config    Config
+	configMu  sync.RWMutex   // guards the fields Reload may change while running
+	fuelNames []string       // indexed by FuelType
+	payments  []string       // payment method names, indexed by Car.Payment
+	weathers  []string       // weather state names, indexed by weather
+	classes   []string       // vehicle class names, indexed by Car.Class
+	weather   int32          // current weather state
+	rng       *rand.Rand     // all random draws of virtual runs and of the car spawning of realtime runs go through this
+	rngSource *lockedSource  // of rng
+	streams   bool           // the cars, stations and weather of realtime runs draw from random streams of their own
+	workers   sync.WaitGroup // goroutines of a realtime run
+	carID     int
+	carPool   *sync.Pool         // the cars a virtual run is done with, for the arrivals to come; nil leaves them to the GC
+	source    CarSource          // replaces random spawning when set
+	configErr error              // a problem of the config New found, which Run returns
+	resumed   *virtualGasStation // restored by FromCheckpoint, runVirtual goes on with it
+
+	distributions map[*DistributionConfig]Distribution // created on first use
+	distMu        sync.Mutex
+
+	stats      Stats      // names, IDs and the stats updated under mu, the counters are in the shards
+	shards     []Stats    // the counters updated atomically, the first one shared, see newShards
+	mu         sync.Mutex // guards the float stats
+	carsInside int32      // cars that arrived and haven't left yet, drained at the end
+	start      time.Time  // simulated wall-clock time of the start of the run
+	events     int        // events processed by a virtual run
+	resources  *Resources // taken by the finished run, guarded by mu
+
+	virtualElapsed atomic.Int64 // simulated time of a virtual run so far, for Progress
+
+	// guarded by mu
+	stationsBusySince   busyPeriods
+	registersBusySince  busyPeriods
+	attendantsBusySince busyPeriods
+	weatherSince        busyPeriods // indexed by weather state
+	refuelWaiting       queueGauge
+	checkoutWaiting     queueGauge
+	checkoutTickets     int
+	checkoutLine        []int // tickets of the cars in the checkout queue, in the order they joined
+	registerLoad        []int // cars queued or checking out at every cash register, with a queue per register
+
+	forecourts    []*forecourt // lanes of the stations, indexed by FuelType, nil for fuel types without lanes
+	registerPool  *staffPool   // cash registers on duty by the shifts
+	attendantPool *staffPool
+
+	series   []QueueSample
+	seriesMu sync.Mutex
+
+	// realtime engine
+	stationChs          []chan Station // indexed by FuelType
+	carWork             chan Car       // hands the arrived cars to the car workers
+	checkoutChannel     chan Car       // hands cars in the checkout queue to free cash registers
+	registerChs         []chan Car     // replace checkoutChannel with a queue per register, indexed by register ID
+	checkoutSlots       chan struct{}  // places in the checkout queue, filled by the cars waiting in it
+	cashRegisterChannel chan CashRegister
+	attendantCh         chan int      // free attendants by ID
+	powerBanks          []*powerBank  // indexed by FuelType, nil for fuels without shared power
+	shiftCh             chan struct{} // closed at the next change of shifts, guarded by shiftMu
+	shiftMu             sync.Mutex
+	spawningStopped     chan struct{} // closed when the simulated time is up
+	carWorkers          int           // the cash registers update the shards after those of the car workers
+	clock               *realtimeClock
+}
+
+// New prepares a simulation of the config, filling in the seed, time scale and checkout queue capacity when unset.
+// The config is expected to pass Validate, Run returns the error of a car source that can't be opened.
+func New(config Config) *Simulation {
+	if config.RandomSeed == 0 {
+		config.RandomSeed = time.Now().UnixNano()
+	}
+	if config.TimeScale <= 0 {
+		config.TimeScale = 1
+	}
+	if config.CheckoutQueueCapacity <= 0 {
+		config.CheckoutQueueCapacity = defaultCheckoutQueueCapacity
+	}
+
+	s := new(Simulation)
+	s.config = config
+	s.fuelNames = config.FuelNames()
+	s.payments = config.PaymentMethodNames()
+	s.classes = config.VehicleClassNames()
+	s.rngSource = newLockedSource(config.RandomSeed)
+	s.rng = rand.New(s.rngSource)
+	s.distributions = make(map[*DistributionConfig]Distribution)
+	s.carPool = &sync.Pool{New: func() any { return new(Car) }} // long batch runs see millions of cars
+	if config.CarSource != nil {
+		var err error
+		if s.source, err = config.CarSource.New(); err != nil {
+			s.configErr = fmt.Errorf("invalid car_source: %w", err)
+		}
+	}
+	s.clock = newRealtimeClock(config.TimeScale)
+
+	s.stats.Fuels = make([]FuelStats, len(s.fuelNames))
+	for i, name := range s.fuelNames {
+		s.stats.Fuels[i].Name = name
+	}
+	for _, name := range s.payments {
+		s.stats.Payments = append(s.stats.Payments, PaymentStats{Name: name})
+	}
+	for _, name := range s.classes {
+		s.stats.Classes = append(s.stats.Classes, GroupStats{Name: name})
+	}
+	if config.Weather != nil {
+		s.weathers = config.Weather.Names()
+		for _, name := range s.weathers {
+			s.stats.Weather = append(s.stats.Weather, WeatherStats{Name: name})
+			s.weatherSince = append(s.weatherSince, -1)
+		}
+	}
+	if len(config.DemandPeriods) > 0 {
+		for _, p := range config.DemandPeriods {
+			s.stats.Periods = append(s.stats.Periods, GroupStats{Name: p.Name})
+		}
+		s.stats.Periods = append(s.stats.Periods, GroupStats{Name: offPeak})
+	}
+
+	return s
+}
+
+// Run simulates the configured length and returns early with the context error when ctx is cancelled.
+// A Simulation can only be run once.
+func (s *Simulation) Run(ctx context.Context) error {
+	if s.configErr != nil {
+		return s.configErr
+	}
+	if s.Checkpoints != nil && (s.config.Realtime || s.source != nil) {
+		return errors.New("checkpoints need a virtual run without a car source")
+	}
+	s.Metadata()
+	stopWatching := watchResources()
+	var err error
+	if s.config.Realtime {
+		err = s.runRealtime(ctx)
+	} else {
+		err = s.runVirtual(ctx)
+	}
+	if traceErr := s.stopTrace(); err == nil {
+		err = traceErr
+	}
+	s.endObservers()
+
+	resources := stopWatching()
+	s.mu.Lock()
+	s.resources = resources
+	s.mu.Unlock()
+	return err
+}
+
+// Pause freezes a realtime run, no cars arrive and no service completes until Resume.
+// A simulation paused before Run starts paused.
+func (s *Simulation) Pause() error {
+	if !s.config.Realtime {
+		return errors.New("only realtime runs can be paused")
+	}
+	return s.clock.pause()
+}
+
+// Resume continues a paused realtime run
+func (s *Simulation) Resume() error {
+	if !s.config.Realtime {
+		return errors.New("only realtime runs can be paused")
+	}
+	return s.clock.resume()
+}
+
+// Paused reports whether the run is paused
+func (s *Simulation) Paused() bool {
+	return s.clock.isPaused()
+}
+
+// Progress returns the share of the simulated time of the run that has passed, from 0 to 1,
+// also while it is running; it stays at 1 while the cars inside finish
+func (s *Simulation) Progress() float64 {
+	elapsed := time.Duration(s.virtualElapsed.Load())
+	if s.config.Realtime {
+		elapsed = s.realtimeElapsed()
+	}
+	return min(1, elapsed.Seconds()/time.Duration(s.config.SimulationLength).Seconds())
+}
+
+// Results returns the effective config and the stats collected so far, and once the run has finished
+// the resources it took
+func (s *Simulation) Results() Results {
+	metadata := s.Metadata()
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.stats.Snapshot()
+	for i := range s.shards {
+		stats.add(&s.shards[i])
+	}
+	return Results{Metadata: &metadata, Config: s.config, Stats: stats, Resources: s.resources}
+}
+
+// Reload applies the tunable fields of config (car_spawn_chance, arrivals_per_hour, interarrival,
+// fuel pricing and checkout_time)
+// to the simulation, also while it is running. Other fields are ignored.
+func (s *Simulation) Reload(config Config) {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+
+	s.config.CarSpawnChance = config.CarSpawnChance
+	s.config.ArrivalsPerHour = config.ArrivalsPerHour
+	s.config.Interarrival = config.Interarrival
+	s.config.CheckoutTime = config.CheckoutTime
+
+	// copy the map, it's shared with every Results returned so far
+	fuels := make(map[string]FuelConfig, len(s.config.Fuels))
+	for name, fc := range s.config.Fuels {
+		if reloaded, ok := config.Fuels[name]; ok {
+			fc.Pricing = reloaded.Pricing
+			fc.PriceSchedule = reloaded.PriceSchedule
+			fc.Surge = reloaded.Surge
+		}
+		fuels[name] = fc
+	}
+	s.config.Fuels = fuels
+}
+
+// endWarmup resets the stats collected during the warm-up
+func (s *Simulation) endWarmup() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.reset()
+	for i := range s.shards {
+		s.shards[i].reset()
+	}
+}
+
+// carSpawnChance returns the spawn chance in effect elapsed into the run
+func (s *Simulation) carSpawnChance(elapsed time.Duration) float32 {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+
+	return min(1, s.config.CarSpawnChance.At(elapsed)*s.demandMultiplier(elapsed))
+}
+
+// nextArrival draws the time until the next car after elapsed into the run when cars arrive in a Poisson
+// process or by the interarrival distribution, ok is false when they arrive on spawn ticks instead
+func (s *Simulation) nextArrival(elapsed time.Duration) (next time.Duration, ok bool) {
+	s.configMu.RLock()
+	rate, interarrival := s.config.ArrivalsPerHour, s.config.Interarrival
+	s.configMu.RUnlock()
+
+	// the demand period the draw starts in scales the whole gap
+	multiplier := float64(s.demandMultiplier(elapsed))
+	switch {
+	case interarrival != nil:
+		return time.Duration(math.Max(0, s.sample(s.rng, interarrival)) * float64(time.Second) / multiplier), true
+	case rate > 0:
+		return time.Duration(s.rng.ExpFloat64() * float64(time.Hour) / float64(rate) / multiplier), true
+	}
+	return 0, false
+}
+
+// fuelConfig returns the current config of the fuel type
+func (s *Simulation) fuelConfig(fuel FuelType) FuelConfig {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+
+	return s.config.Fuels[s.fuelNames[fuel]]
+}
+
+// checkoutTimeRange returns the checkout time of the car's payment method, or checkout_time without payment methods
+func (s *Simulation) checkoutTimeRange(car *Car) TimeRange {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+
+	if len(s.payments) > 0 {
+		return s.config.PaymentMethods[s.payments[car.Payment]].CheckoutTime
+	}
+	return s.config.CheckoutTime
+}
+
+// atomicAddFloat32 adds value to the variable by compare-and-swap of its bits, so the stat updates of
+// different cars don't wait for each other
+func atomicAddFloat32(variable *float32, value float32) {
+	bits := (*uint32)(unsafe.Pointer(variable))
+	for {
+		old := atomic.LoadUint32(bits)
+		if atomic.CompareAndSwapUint32(bits, old, math.Float32bits(math.Float32frombits(old)+value)) {
+			return
+		}
+	}
+}
+
+// addRevenue adds the cash taken elapsed into the run to the hourly revenue of the fuel
+func (s *Simulation) addRevenue(fuel FuelType, elapsed time.Duration, cash float32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	revenue := &s.stats.Fuels[fuel].HourlyRevenue
+	hour := int(elapsed / time.Hour)
+	for len(*revenue) <= hour {
+		*revenue = append(*revenue, 0)
+	}
+	(*revenue)[hour] += cash
+	s.day(elapsed).Revenue += cash
+}
+
+func (s *Simulation) addSample(samples *Samples, value float32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	*samples = append(*samples, value)
+}
+
+// lockedSource makes a single rand.Source safe to share between goroutines. It counts the values
+// drawn, which along with the seed is the state of the stream a checkpoint keeps.
+type lockedSource struct {
+	mu    sync.Mutex
+	src   rand.Source64
+	draws uint64
+}
+
+func newLockedSource(seed int64) *lockedSource {
+	return &lockedSource{src: rand.NewSource(seed).(rand.Source64)}
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.draws++
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.draws++
+	return s.src.Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.src.Seed(seed)
+	s.draws = 0
+}
+
+// drawn returns the values drawn since the seed
+func (s *lockedSource) drawn() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.draws
+}
+
+// skip draws values until n were drawn since the seed, to go on with the stream where a checkpoint
+// left it; Int63 and Uint64 both take a single step of the generator
+func (s *lockedSource) skip(n uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ; s.draws < n; s.draws++ {
+		s.src.Uint64()
+	}
+}
+
+// kinds of the workers of a realtime run with random streams of their own
+const (
+	streamCar = iota
+	streamStation
+	streamWeather
+)
+
+// workerRand returns the random stream of the worker of the kind with the id, derived from the seed of the
+// run so the draws of a worker don't depend on when the goroutines of the others get to run. It goes without
+// a lock, only one goroutine of the worker draws from it at a time, a car handing itself on over a channel.
+// Virtual runs draw everything from the one stream in the order of their events.
+func (s *Simulation) workerRand(kind, id int) *rand.Rand {
+	if !s.streams {
+		return s.rng
+	}
+	return rand.New(rand.NewSource(streamSeed(s.config.RandomSeed, kind, id)))
+}
+
+// streamSeed mixes the seed of the run with the worker by splitmix64, so that neighboring workers
+// get unrelated streams
+func streamSeed(seed int64, kind, id int) int64 {
+	z := uint64(seed) + (uint64(kind)<<32|uint64(id)+1)*0x9e3779b97f4a7c15
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return int64(z ^ z>>31)
+}
+
+// spawnCar creates the next car and counts it as spawned
+func (s *Simulation) spawnCar() *Car {
+	car := s.drawCar()
+	s.countSpawned(car)
+	s.drawPayment(car)
+
+	return car
+}
+
+// drawCar creates the next car of the vehicle class and fuel mix
+func (s *Simulation) drawCar() *Car {
+	class := s.drawClass()
+	fuel := s.getFuelTypeByChance(class)
+	car := s.takeCar()
+	car.draw(s.carID, fuel, s.classTankSize(class, s.fuelConfig(fuel).TankSize), s.config.CarWaitTimeBias, s.rng)
+	car.Class = class
+	car.rng = s.workerRand(streamCar, car.ID)
+	s.carID++
+
+	return car
+}
+
+// drawPayment draws whether the car pays at the pump, is a loyalty customer, its payment method at the cash register and how
+// long it waits to check out before driving off without paying, cars wait forever without a
+// checkout_wait_time_bias
+func (s *Simulation) drawPayment(car *Car) {
+	if payAtPump := s.config.PayAtPump; payAtPump != nil {
+		car.PayAtPump = s.rng.Float32() < payAtPump.Share
+	}
+	car.Prepaid = s.config.Prepay && !car.PayAtPump
+	if loyalty := s.config.Loyalty; loyalty != nil {
+		car.Loyal = s.rng.Float32() < loyalty.Share
+	}
+	if len(s.payments) > 0 {
+		chance := s.rng.Float32()
+		for i, name := range s.payments {
+			car.Payment = i
+			if chance -= s.config.PaymentMethods[name].Share; chance < 0 {
+				break
+			}
+		}
+	}
+	if bias := s.config.CheckoutWaitTimeBias; bias > 0 {
+		car.CheckoutWaitTime = bias/1.5 + s.rng.Float32()*(bias*2-bias/1.5)
+	}
+}
+
+// countSpawned counts the car as spawned, in total and for its fuel type, in the shared shard its updates
+// go to until a car worker takes it
+func (s *Simulation) countSpawned(car *Car) {
+	car.stats = s.shared()
+	atomic.AddInt32(&car.stats.CarsSpawnedTotal, 1)
+	atomic.AddInt32(&car.stats.Fuels[car.Fuel].CarsSpawned, 1)
+}
+
+// queueFull reports whether the refuel queue of the fuel has no room for another car
+func (s *Simulation) queueFull(fuel FuelType, waiting int) bool {
+	capacity := s.fuelConfig(fuel).QueueCapacity
+	return capacity > 0 && waiting >= capacity
+}
+
+// balk records an arrived car that left right away as the refuel queue of its fuel was full
+func (s *Simulation) balk(car *Car, now time.Time) {
+	s.countArrival(car, now)
+	s.countGroups(car, func(g *GroupStats) { g.CarsBalked++ })
+	atomic.AddInt32(&car.stats.CarsBalked, 1)
+	atomic.AddInt32(&car.stats.Fuels[car.Fuel].CarsBalked, 1)
+}
+
+// siteFull reports whether the site has no room for another car
+func (s *Simulation) siteFull() bool {
+	return s.config.SiteCapacity > 0 && int(atomic.LoadInt32(&s.carsInside)) >= s.config.SiteCapacity
+}
+
+// turnAway records an arrived car that left right away as the site was full
+func (s *Simulation) turnAway(car *Car, now time.Time) {
+	s.countArrival(car, now)
+	atomic.AddInt32(&car.stats.CarsTurnedAway, 1)
+	atomic.AddInt32(&car.stats.Fuels[car.Fuel].CarsTurnedAway, 1)
+}
+
+// countArrival counts the car arriving at now in the stats of its day, demand period and weather
+func (s *Simulation) countArrival(car *Car, now time.Time) {
+	car.Arrived = now
+	s.countDay(now.Sub(s.start), func(d *DayStats) { d.CarsArrived++ })
+	if len(s.stats.Periods) > 0 {
+		car.Period = s.demandPeriod(now.Sub(s.start))
+	}
+	s.countGroups(car, func(g *GroupStats) { g.CarsArrived++ })
+	if len(s.stats.Weather) > 0 {
+		atomic.AddInt32(&car.stats.Weather[atomic.LoadInt32(&s.weather)].CarsArrived, 1)
+	}
+}
+
+// enter counts the car arriving at now as inside the station until it leaves
+func (s *Simulation) enter(car *Car, now time.Time) {
+	s.countArrival(car, now)
+	atomic.AddInt32(&s.carsInside, 1)
+}
+
+// exit counts a car that entered as gone, one way or another
+func (s *Simulation) exit() {
+	atomic.AddInt32(&s.carsInside, -1)
+}
+
+// joinRefuelQueue counts the car as waiting for a station since now, arriving then unless it prepaid
+func (s *Simulation) joinRefuelQueue(car *Car, now time.Time) {
+	car.RefuelQueueStart = now
+	if !car.Prepaid {
+		s.enter(car, now)
+	}
+	s.changeQueue(&s.refuelWaiting, &s.stats.RefuelQueueArea, 1, now)
+	atomic.AddInt32(&car.stats.CarsInRefuelQueue, 1)
+	atomic.AddInt32(&car.stats.Fuels[car.Fuel].CarsInRefuelQueue, 1)
+}
+
+// leaveRefuelQueue counts the car as no longer waiting for a station since now
+func (s *Simulation) leaveRefuelQueue(car *Car, now time.Time) {
+	s.changeQueue(&s.refuelWaiting, &s.stats.RefuelQueueArea, -1, now)
+	atomic.AddInt32(&car.stats.CarsInRefuelQueue, -1)
+	atomic.AddInt32(&car.stats.Fuels[car.Fuel].CarsInRefuelQueue, -1)
+}
+
+// startFueling moves the car from the refuel queue to the station and records how long it waited
+func (s *Simulation) startFueling(car *Car, station Station, now time.Time) {
+	s.leaveRefuelQueue(car, now)
+	s.occupyStation(car.stats, car.Fuel, 1)
+	s.startBusy(s.stationsBusySince, station.ID, now.Sub(s.start))
+	car.FuelingStart = now
+	wait := float32(now.Sub(car.RefuelQueueStart).Milliseconds()) / 1000.0
+	atomicAddFloat32(&car.stats.Fuels[car.Fuel].TimeInRefuelQueue, wait)
+	s.addSample(&s.stats.Fuels[car.Fuel].RefuelQueueWaits, wait)
+}
+
+// occupyStation counts the station of the fuel as having a car at it in the shard, or as free again for -1
+func (s *Simulation) occupyStation(shard *Stats, fuel FuelType, delta int32) {
+	atomic.AddInt32(&shard.Fuels[fuel].StationsBusy, delta)
+}
+
+// leaveStation frees the station its car drove away from at now, counted in the shard
+func (s *Simulation) leaveStation(shard *Stats, station Station, now time.Time) {
+	s.occupyStation(shard, station.Fuel, -1)
+	s.endBusy(s.stationsBusySince, station.ID, &s.stats.Stations[station.ID].BusyTime, now.Sub(s.start))
+}
+
+// occupyRegister counts a cash register as checking out the car, or as free again for -1
+func (s *Simulation) occupyRegister(car *Car, delta int32) {
+	atomic.AddInt32(&car.stats.RegistersBusy, delta)
+}
+
+// chargeRefuel prices the dispensed fuel at the price per unit in effect when fueling started
+// and records the refueling stats, fuelingTime is longer than refuelTime when the car shared the power of its charger
+func (s *Simulation) chargeRefuel(car *Car, station Station, refuelTime, fuelingTime, unitPrice float32) {
+	// calculate price of fuel
+	filled := s.fuelConfig(car.Fuel).Fill != nil
+	var units float32
+	switch {
+	case filled:
+		units = (car.TargetLevel - car.ArrivalLevel) * car.FuelTankSize
+	case station.DispenseRate > 0:
+		units = car.FuelTankSize // filled up from empty at the flow rate
+	default:
+		units = (refuelTime / (station.FuelingTime.Max * s.fuelingTimeMultiplier(car))) * car.FuelTankSize
+	}
+	if car.Loyal {
+		discount := min(s.config.Loyalty.Discount, unitPrice)
+		car.Discount = units * discount
+		unitPrice -= discount
+	}
+	price := units * unitPrice
+	car.Units, car.UnitPrice, car.Receipt = units, unitPrice, price
+
+	// stats
+	fuelStats := &car.stats.Fuels[car.Fuel]
+	atomicAddFloat32(&fuelStats.Units, units)
+	atomicAddFloat32(&fuelStats.TimeRefueling, fuelingTime)
+	s.addSample(&s.stats.Fuels[car.Fuel].FuelingTimes, fuelingTime)
+	if fuelingTime > refuelTime {
+		atomicAddFloat32(&fuelStats.TimeWaitingForPower, fuelingTime-refuelTime)
+	}
+	atomic.AddInt32(&fuelStats.CarsRefueled, 1)
+	if filled {
+		atomicAddFloat32(&fuelStats.ArrivalLevel, car.ArrivalLevel)
+		atomicAddFloat32(&fuelStats.TargetLevel, car.TargetLevel)
+	}
+}
+
+// waitForCheckout starts the checkout wait of the refueled car at now, at first possibly at its pump
+// until there is room in the checkout queue
+func (s *Simulation) waitForCheckout(car *Car, now time.Time) {
+	car.CheckoutQueueStart = now
+	s.changeQueue(&s.checkoutWaiting, &s.stats.CheckoutQueueArea, 1, now)
+}
+
+// blockPump counts the refueled car having to wait at its pump for room in the checkout queue
+func (s *Simulation) blockPump(car *Car) {
+	atomic.AddInt32(&car.stats.CarsBlocked, 1)
+}
+
+// unblockPump records how long the car blocked its pump once it got into the checkout queue at now
+func (s *Simulation) unblockPump(car *Car, now time.Time) {
+	atomicAddFloat32(&car.stats.TimeBlocked, float32(now.Sub(car.CheckoutQueueStart).Milliseconds())/1000.0)
+}
+
+// holdPump keeps the station occupied by the refueled car joining the checkout queue until it paid
+func (s *Simulation) holdPump(car *Car, station Station) {
+	car.heldPump = &station
+	atomic.AddInt32(&car.stats.CarsHeldPump, 1)
+}
+
+// releasePump lets go of the pump the car held once it paid or drove off at now and returns it to be
+// vacated, nil for a car that didn't hold one
+func (s *Simulation) releasePump(car *Car, now time.Time) *Station {
+	station := car.heldPump
+	if station == nil {
+		return nil
+	}
+	car.heldPump = nil
+	atomicAddFloat32(&car.stats.TimeHoldingPump, float32(now.Sub(car.CheckoutQueueStart).Milliseconds())/1000.0)
+	return station
+}
+
+// driveOff records a refueled car that ran out of patience waiting to check out and left at now without
+// paying, from the checkout queue when queued and otherwise from its pump
+func (s *Simulation) driveOff(car *Car, now time.Time, queued bool) {
+	if queued {
+		atomic.AddInt32(&car.stats.CarsInCheckoutQueue, -1)
+		s.leaveCheckoutLine(car, false)
+	}
+	s.changeQueue(&s.checkoutWaiting, &s.stats.CheckoutQueueArea, -1, now)
+	atomicAddFloat32(&car.stats.TimeBeforeDriveOff, float32(now.Sub(car.CheckoutQueueStart).Milliseconds())/1000.0)
+	atomic.AddInt32(&car.stats.CarsDroveOff, 1)
+	s.countGroups(car, func(g *GroupStats) { g.CarsDroveOff++ })
+	fuelStats := &car.stats.Fuels[car.Fuel]
+	atomic.AddInt32(&fuelStats.CarsDroveOff, 1)
+	atomicAddFloat32(&fuelStats.DriveOffLoss, car.Receipt)
+	s.exit()
+}
+
+// beginCheckout takes the car out of the checkout queue to the cash register and returns its checkout time in seconds
+func (s *Simulation) beginCheckout(car *Car, register CashRegister, now time.Time) float32 {
+	atomic.AddInt32(&car.stats.CarsInCheckoutQueue, -1)
+	s.changeQueue(&s.checkoutWaiting, &s.stats.CheckoutQueueArea, -1, now)
+	s.leaveCheckoutLine(car, true)
+	car.CheckoutQueueWait = float32(now.Sub(car.CheckoutQueueStart).Milliseconds()) / 1000.0
+	atomicAddFloat32(&car.stats.TimeInCheckoutQueue, car.CheckoutQueueWait)
+	s.addSample(&s.stats.Fuels[car.Fuel].CheckoutQueueWaits, car.CheckoutQueueWait)
+	s.startBusy(s.registersBusySince, register.ID, now.Sub(s.start))
+
+	checkoutTime := s.randomInRange(car.rng, s.checkoutTimeRange(car)) + s.shopPurchase(car)
+	atomicAddFloat32(&car.stats.CheckoutTimeTotal, checkoutTime)
+	if len(s.payments) > 0 {
+		payment := &car.stats.Payments[car.Payment]
+		atomic.AddInt32(&payment.Checkouts, 1)
+		atomicAddFloat32(&payment.CheckoutTime, checkoutTime)
+	}
+	if !car.Prepaid {
+		s.collect(car, now)
+	}
+
+	return checkoutTime
+}
+
+// collect takes the receipt of the car at now as revenue
+func (s *Simulation) collect(car *Car, now time.Time) {
+	atomicAddFloat32(&car.stats.Fuels[car.Fuel].Cash, car.Receipt)
+	s.addRevenue(car.Fuel, now.Sub(s.start), car.Receipt)
+	s.countLoyalty(car)
+}
+
+// countLoyalty records the purchase of a paying loyalty customer
+func (s *Simulation) countLoyalty(car *Car) {
+	if !car.Loyal {
+		return
+	}
+	atomic.AddInt32(&car.stats.LoyaltyCustomers, 1)
+	atomicAddFloat32(&car.stats.LoyaltyRevenue, car.Receipt)
+	atomicAddFloat32(&car.stats.LoyaltyDiscount, car.Discount)
+}
+
+// shopPurchase lets the customer buy shop items at the cash register by chance and returns the seconds
+// it adds to the checkout
+func (s *Simulation) shopPurchase(car *Car) float32 {
+	shop := s.config.Shop
+	if shop == nil || car.rng.Float32() >= shop.Chance {
+		return 0
+	}
+
+	car.ShopAmount = s.randomInRange(car.rng, shop.Amount)
+	atomic.AddInt32(&car.stats.ShopPurchases, 1)
+	atomicAddFloat32(&car.stats.ShopRevenue, car.ShopAmount)
+
+	return s.randomInRange(car.rng, shop.CheckoutTime)
+}
+
+// paidAtPump records a car that paid at its pump and left at now, after paying for payTime seconds
+func (s *Simulation) paidAtPump(car *Car, payTime float32, now time.Time) {
+	atomic.AddInt32(&car.stats.CarsPaidAtPump, 1)
+	atomicAddFloat32(&car.stats.TimePayingAtPump, payTime)
+	s.collect(car, now)
+	s.leave(car, now)
+}
+
+// leave records a car that paid and drove away at now
+func (s *Simulation) leave(car *Car, now time.Time) {
+	atomic.AddInt32(&car.stats.Fuels[car.Fuel].CarsCheckedOut, 1)
+	s.countDay(now.Sub(s.start), func(d *DayStats) { d.CarsCheckedOut++ })
+	s.countGroups(car, func(g *GroupStats) { g.CarsCheckedOut++ })
+	s.receipt(car, now)
+	s.addSample(&s.stats.Fuels[car.Fuel].TimesAtStation, float32(now.Sub(car.Arrived).Milliseconds())/1000.0)
+	s.exit()
+}
+
+// leaveUnserved records a car that gave up waiting for a free station at now
+func (s *Simulation) leaveUnserved(car *Car, now time.Time) {
+	s.countNotServed(car, now.Sub(car.RefuelQueueStart), now)
+	s.leaveRefuelQueue(car, now)
+}
+
+// countNotServed counts the car leaving at now without fuel after waiting for waited
+func (s *Simulation) countNotServed(car *Car, waited time.Duration, now time.Time) {
+	atomicAddFloat32(&car.stats.TimeBeforeLeaving, float32(waited.Milliseconds())/1000.0)
+	atomic.AddInt32(&car.stats.CarsNotServed, 1)
+	atomic.AddInt32(&car.stats.Fuels[car.Fuel].CarsNotServed, 1)
+	s.countDay(now.Sub(s.start), func(d *DayStats) { d.CarsNotServed++ })
+	s.countGroups(car, func(g *GroupStats) { g.CarsNotServed++ })
+	s.exit()
+}
+
+// checkedOut records a car that paid at the cash register at now, leaving unless it prepaid
+func (s *Simulation) checkedOut(car *Car, register CashRegister, now time.Time) {
+	car.Paid = now
+	registerStats := &car.stats.Registers[register.ID]
+	atomic.AddInt32(&registerStats.CarsCheckedOut, 1)
+	atomicAddFloat32(&registerStats.TimeInCheckoutQueue, car.CheckoutQueueWait)
+	s.endBusy(s.registersBusySince, register.ID, &s.stats.Registers[register.ID].BusyTime, now.Sub(s.start))
+	s.registerFreed(register)
+	if !car.Prepaid {
+		s.leave(car, now)
+	}
+}
+
+// drainTimeout returns how long the cars still inside at the end may take to leave, none for a cancelled run
+func (s *Simulation) drainTimeout(ctx context.Context) time.Duration {
+	if ctx.Err() != nil {
+		return 0
+	}
+	return time.Duration(s.config.DrainTimeout)
+}
+
+// timeToFailure draws how long a station works until it breaks down
+func (s *Simulation) timeToFailure(rng *rand.Rand) time.Duration {
+	return time.Duration(rng.ExpFloat64() * float64(s.config.PumpFailures.MTBF))
+}
+
+// breakStation records a station of the fuel going out of service elapsed into the run and
+// returns its repair time, downtime after the end of the run is not counted
+func (s *Simulation) breakStation(rng *rand.Rand, fuel FuelType, elapsed time.Duration) time.Duration {
+	repair := time.Duration(rng.ExpFloat64() * float64(s.config.PumpFailures.MTTR))
+	downtime := repair
+	if remaining := time.Duration(s.config.SimulationLength) - elapsed; downtime > remaining {
+		downtime = max(remaining, 0)
+	}
+
+	fuelStats := &s.shared().Fuels[fuel]
+	atomic.AddInt32(&fuelStats.PumpFailures, 1)
+	atomic.AddInt32(&fuelStats.StationsInRepair, 1)
+	atomicAddFloat32(&fuelStats.Downtime, float32(downtime.Seconds()))
+
+	return repair
+}
+
+// repaired counts a station of the fuel as back in service
+func (s *Simulation) repaired(fuel FuelType) {
+	atomic.AddInt32(&s.shared().Fuels[fuel].StationsInRepair, -1)
+}
+
+// newStations creates the stations of every fuel type, numbered in fuel type order, and their stats
+func (s *Simulation) newStations() []Station {
+	var stations []Station
+	id := 0
+	for i := range s.fuelNames {
+		fuel := FuelType(i)
+		fc := s.fuelConfig(fuel)
+		for _, sc := range fc.StationConfigs() {
+			station := NewStation(id, fuel, sc.FuelingTime(fc.FuelingTime))
+			station.Attended = fc.Attended || sc.Attended
+			station.DispenseRate = sc.DispenseRate(fc)
+			stations = append(stations, *station)
+			s.stats.Stations = append(s.stats.Stations, StationStats{ID: id, Fuel: s.fuelNames[i]})
+			s.stationsBusySince = append(s.stationsBusySince, -1)
+			id++
+		}
+	}
+	return stations
+}
+
+// newRegisters creates the cash registers and their stats
+func (s *Simulation) newRegisters() []CashRegister {
+	var registers []CashRegister
+	for id := 0; id < s.config.CashRegisterCount; id++ {
+		registers = append(registers, *NewCashRegister(id))
+		s.stats.Registers = append(s.stats.Registers, RegisterStats{ID: id})
+		if s.config.registerQueues() {
+			s.registerLoad = append(s.registerLoad, 0)
+		}
+		s.registersBusySince = append(s.registersBusySince, -1)
+	}
+	return registers
+}
+
+// takeCar returns a zeroed car for the next arrival, one the run is done with when it has any
+func (s *Simulation) takeCar() *Car {
+	if s.carPool == nil {
+		return new(Car)
+	}
+	c := s.carPool.Get().(*Car)
+	*c = Car{}
+
+	return c
+}
+
+// NewCar returns a car arriving with the fuel, drawing its tank size and patience
+func NewCar(id int, fuel FuelType, tankSize Range, waitTimeBias float32, r *rand.Rand) *Car {
+	c := new(Car)
+	c.draw(id, fuel, tankSize, waitTimeBias, r)
+
+	return c
+}
+
+// draw sets up the zeroed car as arriving with the fuel, drawing its tank size and patience
+func (c *Car) draw(id int, fuel FuelType, tankSize Range, waitTimeBias float32, r *rand.Rand) {
+	c.ID = id
+	c.Fuel = fuel
+
+	min := waitTimeBias / 1.5
+	max := waitTimeBias * 2
+	c.WaitTime = min + (r.Float32() * (max - min))
+	c.FuelTankSize = tankSize.Min + (r.Float32() * (tankSize.Max - tankSize.Min))
+}
+
+func NewStation(id int, fuel FuelType, time TimeRange) *Station {
+	s := new(Station)
+	s.ID = id
+	s.Fuel = fuel
+	s.FuelingTime = time
+
+	return s
+}
+
+func NewCashRegister(id int) *CashRegister {
+	c := new(CashRegister)
+	c.ID = id
+
+	return c
+}
+
+func (s *Simulation) randomInRange(rng *rand.Rand, r TimeRange) float32 {
+	if r.Distribution == nil {
+		return r.Min + (rng.Float32() * (r.Max - r.Min))
+	}
+
+	v := float32(s.sample(rng, r.Distribution))
+	if r.scale != 0 {
+		v *= r.scale
+	}
+	if r.Max > 0 && v > r.Max {
+		v = r.Max
+	}
+	if v < r.Min {
+		v = r.Min
+	}
+	return v
+}
+
+// sample draws from the configured distribution, the config is expected to pass Validate
+func (s *Simulation) sample(rng *rand.Rand, c *DistributionConfig) float64 {
+	s.distMu.Lock()
+	d, ok := s.distributions[c]
+	if !ok {
+		d, _ = c.New()
+		s.distributions[c] = d
+	}
+	s.distMu.Unlock()
+
+	return d.Sample(rng)
+}
+
+// secondsToDuration converts seconds to a duration with millisecond precision
+func secondsToDuration(seconds float32) time.Duration {
+	return time.Duration(seconds*1000) * time.Millisecond
+}
+
+// getFuelTypeByChance draws the fuel of a new car of the vehicle class
+func (s *Simulation) getFuelTypeByChance(class int) FuelType {
+	ranges := make([][2]float32, len(s.fuelNames))
+	var total float32 = 0.0
+	for i := range ranges {
+		chance := s.fuelConfig(FuelType(i)).Chance * s.fuelMix(FuelType(i))
+		if len(s.classes) > 0 && !s.config.classUses(s.classes[class], s.fuelNames[i]) {
+			chance = 0
+		}
+		ranges[i][0] = total
+		ranges[i][1] = total + chance
+		total += chance
+	}
+
+	probability := s.rng.Float32()
+	if s.config.Weather != nil || len(s.classes) > 0 {
+		probability *= total // the weather and the fuels open to the class change the sum of the chances
+	}
+
+	var selected int = 0
+	for i := range ranges {
+		if probability >= ranges[i][0] && probability <= ranges[i][1] {
+			selected = i
+		}
+	}
+
+	return FuelType(selected)
+}
+
+type Car struct {
+	ID                 int
+	Fuel               FuelType
+	WaitTime           float32 // max waiting time when all pumps busy
+	FuelTankSize       float32 // in the unit of the fuel
+	Units              float32 // refueled
+	UnitPrice          float32
+	Receipt            float32 // for the fuel
+	ShopAmount         float32 // spent in the shop
+	Arrived            time.Time
+	RefuelQueueStart   time.Time
+	FuelingStart       time.Time // got a station, and once an attendant came at attended stations
+	CheckoutQueueStart time.Time
+	CheckoutQueueWait  float32    // seconds
+	CheckoutTicket     int        // order the car joined the checkout queue in
+	Register           int        // cash register whose queue the car joined, with a queue per register
+	Paid               time.Time  // at the cash register, before fueling for prepaid cars
+	CheckoutWaitTime   float32    // max seconds waiting to check out before driving off, 0 waits forever
+	PayAtPump          bool       // skips the cash registers
+	Prepaid            bool       // pays at a cash register before queueing for a station
+	heldPump           *Station   // still occupied while checking out, with hold_pump
+	paid               chan Car   // hands a prepaid car of a realtime run back to its worker once it paid
+	Loyal              bool       // gets the loyalty discount
+	Discount           float32    // taken off the receipt by the loyalty program
+	Payment            int        // payment method at the cash register, indexes Config.PaymentMethodNames
+	Period             int        // demand period the car arrived in, indexes Stats.Periods
+	Class              int        // vehicle class, indexes Config.VehicleClassNames
+	rng                *rand.Rand // the draws of the car's visit, see workerRand
+	stats              *Stats     // the shard the car's counters go to, of the worker that has it
+	events             int        // pending events of a virtual run referring to the car
+	gone               bool       // left a virtual run, it is reused once no event refers to it anymore
+	ArrivalLevel       float32    // share of the tank left on arrival with fill levels, the state of charge of a battery
+	TargetLevel        float32
+}
+
+type Station struct {
+	ID           int
+	Fuel         FuelType
+	FuelingTime  TimeRange
+	Attended     bool    // needs an attendant to fuel a car
+	DispenseRate float32 // units per second by the flow rate, 0 fuels by FuelingTime
+}
+
+type CashRegister struct {
+	ID int
+}
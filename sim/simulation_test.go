@@ -0,0 +1,83 @@
+package sim
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// TestNextArrival draws many gaps between arrivals and checks they make the configured process:
+// exponential gaps of a Poisson process have a standard deviation as large as their mean, and
+// about 63 % of them are shorter than the mean
+func TestNextArrival(t *testing.T) {
+	const draws = 20000
+	rush := []DemandPeriod{{Name: "rush", From: Duration(7 * time.Hour), To: Duration(9 * time.Hour), Multiplier: 2}}
+	tests := []struct {
+		name         string
+		rate         float32
+		interarrival *DistributionConfig
+		demand       []DemandPeriod
+		elapsed      time.Duration
+		mean         time.Duration
+		exponential  bool
+	}{
+		{name: "arrivals per hour", rate: 120, mean: 30 * time.Second, exponential: true},
+		{name: "slow arrivals", rate: 2, mean: 30 * time.Minute, exponential: true},
+		{name: "demand period", rate: 120, demand: rush, elapsed: 8 * time.Hour, mean: 15 * time.Second, exponential: true},
+		{name: "outside the demand period", rate: 120, demand: rush, elapsed: 10 * time.Hour, mean: 30 * time.Second, exponential: true},
+		{name: "interarrival", interarrival: &DistributionConfig{Name: "constant", Params: map[string]float64{"value": 45}}, mean: 45 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.RandomSeed = 5
+			config.ArrivalsPerHour = tt.rate
+			config.Interarrival = tt.interarrival
+			config.DemandPeriods = tt.demand
+			if err := config.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			s := New(config)
+
+			var sum, sumSquares float64
+			shorter := 0
+			for i := 0; i < draws; i++ {
+				next, ok := s.nextArrival(tt.elapsed)
+				if !ok {
+					t.Fatal("no next arrival")
+				}
+				gap := next.Seconds()
+				sum += gap
+				sumSquares += gap * gap
+				if next < tt.mean {
+					shorter++
+				}
+			}
+			mean := sum / draws
+			stddev := math.Sqrt(sumSquares/draws - mean*mean)
+			want := tt.mean.Seconds()
+			if math.Abs(mean-want) > 0.03*want {
+				t.Errorf("mean gap %.2f s, expected %.2f s", mean, want)
+			}
+			if !tt.exponential {
+				if stddev > 1e-6 {
+					t.Errorf("gaps of a constant interarrival vary by %.3f s", stddev)
+				}
+				return
+			}
+			if math.Abs(stddev-want) > 0.05*want {
+				t.Errorf("standard deviation %.2f s, expected %.2f s of exponential gaps", stddev, want)
+			}
+			if share := float64(shorter) / draws; math.Abs(share-(1-1/math.E)) > 0.02 {
+				t.Errorf("%.3f of the gaps are shorter than the mean, expected %.3f", share, 1-1/math.E)
+			}
+		})
+	}
+}
+
+func TestNextArrivalOfSpawnChance(t *testing.T) {
+	s := New(DefaultConfig())
+	if _, ok := s.nextArrival(0); ok {
+		t.Error("a run of spawn chances has no gaps between arrivals")
+	}
+}
@@ -0,0 +1,149 @@
+package sim
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CarSource supplies the cars arriving at the station in place of the random spawning, such as
+// recorded traces, bursts or scripted fleets
+type CarSource interface {
+	// Next returns the next car arriving at or after elapsed into the run, false once no more come.
+	// An arrival without a fuel type is drawn like a spawned car, only its time is kept. Random
+	// draws should be taken from rng, which is seeded with the seed of the run.
+	Next(elapsed time.Duration, rng *rand.Rand) (Arrival, bool)
+}
+
+// CarSourceFactory creates a car source from its params
+type CarSourceFactory func(params map[string]float64) (CarSource, error)
+
+// CarSourceFunc adapts a function to the CarSource interface
+type CarSourceFunc func(elapsed time.Duration, rng *rand.Rand) (Arrival, bool)
+
+func (f CarSourceFunc) Next(elapsed time.Duration, rng *rand.Rand) (Arrival, bool) {
+	return f(elapsed, rng)
+}
+
+// CarSourceConfig selects a registered car source by name
+type CarSourceConfig struct {
+	Name   string             `json:"name" yaml:"name"`
+	Params map[string]float64 `json:"params,omitempty" yaml:"params,omitempty"`
+}
+
+var (
+	carSourcesMu sync.RWMutex
+	carSources   = map[string]CarSourceFactory{}
+)
+
+// RegisterCarSource makes a car source available to configs under name,
+// it panics when the name is taken
+func RegisterCarSource(name string, factory CarSourceFactory) {
+	carSourcesMu.Lock()
+	defer carSourcesMu.Unlock()
+
+	if _, ok := carSources[name]; ok {
+		panic("sim: car source " + name + " registered twice")
+	}
+	carSources[name] = factory
+}
+
+// CarSources lists the names of the registered car sources sorted
+func CarSources() []string {
+	carSourcesMu.RLock()
+	defer carSourcesMu.RUnlock()
+
+	names := make([]string, 0, len(carSources))
+	for name := range carSources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// New creates the configured car source
+func (c CarSourceConfig) New() (CarSource, error) {
+	carSourcesMu.RLock()
+	factory, ok := carSources[c.Name]
+	carSourcesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown car source %q, known are %v", c.Name, CarSources())
+	}
+
+	return factory(c.Params)
+}
+
+func init() {
+	// Poisson bursts of cars arriving one after the other, such as after a train or a match
+	RegisterCarSource("bursty", func(params map[string]float64) (CarSource, error) {
+		p, err := distributionParams(params, "bursts_per_hour", "size", "spacing")
+		if err != nil {
+			return nil, err
+		}
+		if p[0] <= 0 || p[1] < 1 || p[2] < 0 {
+			return nil, fmt.Errorf("expected bursts_per_hour > 0, size >= 1 and spacing >= 0, got %v, %v, %v", p[0], p[1], p[2])
+		}
+		var burst time.Duration
+		left := 0
+		return CarSourceFunc(func(elapsed time.Duration, rng *rand.Rand) (Arrival, bool) {
+			if left == 0 {
+				burst += time.Duration(rng.ExpFloat64() * float64(time.Hour) / p[0])
+				left = int(p[1])
+			}
+			left--
+			return Arrival{Time: burst + time.Duration((p[1]-float64(left)-1)*p[2]*float64(time.Second))}, true
+		}), nil
+	})
+	// a fleet of size cars arriving every so often from start, spacing seconds apart
+	RegisterCarSource("fleet", func(params map[string]float64) (CarSource, error) {
+		p, err := distributionParams(params, "start", "every", "size", "spacing")
+		if err != nil {
+			return nil, err
+		}
+		if p[0] < 0 || p[1] <= 0 || p[2] < 1 || p[3] < 0 {
+			return nil, fmt.Errorf("expected start >= 0, every > 0, size >= 1 and spacing >= 0, got %v, %v, %v, %v", p[0], p[1], p[2], p[3])
+		}
+		car := 0
+		return CarSourceFunc(func(time.Duration, *rand.Rand) (Arrival, bool) {
+			fleet, i := car/int(p[2]), car%int(p[2])
+			car++
+			return Arrival{Time: time.Duration((p[0] + float64(fleet)*p[1] + float64(i)*p[3]) * float64(time.Second))}, true
+		}), nil
+	})
+}
+
+// SetCarSource makes the cars arrive from source instead of spawning them at random, arrivals after
+// the simulation length are ignored. It has to be called before Run.
+func (s *Simulation) SetCarSource(source CarSource) {
+	s.source = source
+}
+
+// arrivalList replays recorded arrivals
+type arrivalList struct {
+	arrivals []Arrival
+	next     int
+}
+
+func (l *arrivalList) Next(time.Duration, *rand.Rand) (Arrival, bool) {
+	if l.next >= len(l.arrivals) {
+		return Arrival{}, false
+	}
+	l.next++
+	return l.arrivals[l.next-1], true
+}
+
+// sourcedCar creates the car of an arrival of the car source and counts it as spawned, it returns
+// nil for arrivals of fuel types or vehicle classes that aren't configured
+func (s *Simulation) sourcedCar(a Arrival) *Car {
+	if a.Fuel == "" {
+		return s.spawnCar()
+	}
+	if s.fuelType(a.Fuel) < 0 || (len(s.classes) > 0 && !slices.Contains(s.classes, a.Class)) {
+		return nil
+	}
+	return s.replayCar(a)
+}
@@ -0,0 +1,501 @@
+package sim
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+// Results is the outcome of a simulation run
+type Results struct {
+	Metadata  *Metadata // nil for results read from reports written without it
+	Config    Config    // effective config, including the seed that was used
+	Stats     Stats
+	Resources *Resources // nil until the run has finished
+}
+
+type Stats struct {
+	// car counts
+	CarsSpawnedTotal    int32 `json:"cars_spawned_total"`
+	CarsNotServed       int32 `json:"cars_not_served"`
+	CarsBalked          int32 `json:"cars_balked"`      // left right away as the refuel queue was full, not counted as not served
+	CarsTurnedAway      int32 `json:"cars_turned_away"` // left right away as the site was full, demand lost for lack of space
+	CarsDroveOff        int32 `json:"cars_drove_off"`   // refueled but left without paying, tired of waiting to check out
+	CarsLeftUnpaid      int32 `json:"cars_left_unpaid"` // gave up waiting to prepay, counted as not served
+	CarsInRefuelQueue   int32 `json:"cars_in_refuel_queue"`
+	CarsInCheckoutQueue int32 `json:"cars_in_checkout_queue"`
+	RegistersBusy       int32 `json:"registers_busy"` // live count while running
+	CarsBlocked         int32 `json:"cars_blocked"`   // refueled cars that waited at their pump for room in the checkout queue
+	// checkouts started while a car that joined the checkout queue earlier still waited
+	CheckoutsOutOfTurn int32 `json:"checkouts_out_of_turn"`
+	CarsHeldPump       int32 `json:"cars_held_pump"`       // refueled cars that kept their pump until they paid, with hold_pump
+	CarsBlockedInLane  int32 `json:"cars_blocked_in_lane"` // done at their pump but waiting for a car in front of them to leave
+
+	// money
+	CheckoutTimeTotal float32 `json:"checkout_time_total"`
+	ShopPurchases     int32   `json:"shop_purchases"`    // customers who bought something in the shop
+	ShopRevenue       float32 `json:"shop_revenue"`      // not part of the cash of the fuels
+	CarsPaidAtPump    int32   `json:"cars_paid_at_pump"` // counted as checked out, without a cash register
+	LoyaltyCustomers  int32   `json:"loyalty_customers"` // paying customers in the loyalty program
+	LoyaltyRevenue    float32 `json:"loyalty_revenue"`   // fuel cash of the loyalty customers, after discount
+	LoyaltyDiscount   float32 `json:"loyalty_discount"`  // taken off their receipts
+	TimePayingAtPump  float32 `json:"time_paying_at_pump"`
+
+	// general time
+	TimeBeforeLeaving   float32 `json:"time_before_leaving"`
+	TimeInCheckoutQueue float32 `json:"time_in_checkout_queue"`
+	TimeBlocked         float32 `json:"time_blocked"`      // seconds refueled cars blocked their pump
+	TimeHoldingPump     float32 `json:"time_holding_pump"` // seconds from fueling finished to freeing the pump, with hold_pump
+	TimeBlockedInLane   float32 `json:"time_blocked_in_lane"`
+	TimeBeforeDriveOff  float32 `json:"time_before_drive_off"`
+	TimeBeforeUnpaid    float32 `json:"time_before_unpaid"` // part of time_before_leaving spent in the checkout queue
+
+	CarsWaitedForAttendant  int32   `json:"cars_waited_for_attendant"` // at an attended station
+	TimeWaitingForAttendant float32 `json:"time_waiting_for_attendant"`
+
+	// car-seconds spent in the queues within the observed part of the run, the checkout queue
+	// including cars waiting at their pump for room in it
+	RefuelQueueArea   float32 `json:"refuel_queue_area"`
+	CheckoutQueueArea float32 `json:"checkout_queue_area"`
+
+	Fuels      []FuelStats      `json:"fuels"`                // indexed by FuelType
+	Stations   []StationStats   `json:"stations"`             // indexed by station ID
+	Registers  []RegisterStats  `json:"registers"`            // indexed by cash register ID
+	Payments   []PaymentStats   `json:"payments,omitempty"`   // in the order of Config.PaymentMethodNames
+	Attendants []AttendantStats `json:"attendants,omitempty"` // indexed by attendant ID
+	Days       []DayStats       `json:"days"`                 // indexed by simulated day since the start
+	Periods    []GroupStats     `json:"periods,omitempty"`    // by Config.DemandPeriods, the off-peak cars last
+	Weather    []WeatherStats   `json:"weather,omitempty"`    // in the order of Weather.Names
+	Classes    []GroupStats     `json:"classes,omitempty"`    // in the order of Config.VehicleClassNames
+}
+
+// PaymentStats are the checkouts at the cash registers paid by a single payment method
+type PaymentStats struct {
+	Name         string  `json:"name"`
+	Checkouts    int32   `json:"checkouts"`     // started at a cash register
+	CheckoutTime float32 `json:"checkout_time"` // seconds
+}
+
+// FuelStats are the stats of the cars of a single fuel type
+type FuelStats struct {
+	Name           string  `json:"name"`
+	CarsSpawned    int32   `json:"cars_spawned"`
+	CarsNotServed  int32   `json:"cars_not_served"`
+	CarsBalked     int32   `json:"cars_balked"`
+	CarsTurnedAway int32   `json:"cars_turned_away"`
+	CarsDroveOff   int32   `json:"cars_drove_off"`
+	DriveOffLoss   float32 `json:"drive_off_loss"` // receipts of the cars that drove off
+	CarsRefueled   int32   `json:"cars_refueled"`
+	CarsCheckedOut int32   `json:"cars_checked_out"`
+	Cash           float32 `json:"cash"`
+	Units          float32 `json:"units"`
+	TimeRefueling  float32 `json:"time_refueling"`
+
+	TimeInRefuelQueue float32 `json:"time_in_refuel_queue"` // of the cars that got a station
+
+	TimeWaitingForPower float32 `json:"time_waiting_for_power,omitempty"` // charging slowed down by shared power
+
+	// sums of the fill levels the refueled cars came and left with
+	ArrivalLevel float32 `json:"arrival_level,omitempty"`
+	TargetLevel  float32 `json:"target_level,omitempty"`
+
+	// live counts while running
+	CarsInRefuelQueue int32 `json:"cars_in_refuel_queue"`
+	StationsBusy      int32 `json:"stations_busy"`
+	StationsInRepair  int32 `json:"stations_in_repair"`
+
+	PumpFailures int32   `json:"pump_failures"`
+	Downtime     float32 `json:"downtime"` // seconds stations spent in repair
+
+	HourlyRevenue []float32 `json:"hourly_revenue"` // cash taken in every simulated hour since the start
+
+	// seconds every car waited, for percentiles
+	RefuelQueueWaits   Samples `json:"-"` // of the cars that got a station
+	CheckoutQueueWaits Samples `json:"-"`
+	FuelingTimes       Samples `json:"-"`
+	TimesAtStation     Samples `json:"-"` // from arriving to paying
+}
+
+// Snapshot returns a copy of the stats that later updates don't change, also while the run updates
+// them: the counters are loaded atomically and the caller holds off the updates made under a lock,
+// as Simulation.Results does. The samples of the waits are shared as they are only ever appended to.
+func (st *Stats) Snapshot() Stats {
+	var stats Stats
+	loadAtomically(reflect.ValueOf(&stats).Elem(), reflect.ValueOf(st).Elem())
+
+	return stats
+}
+
+// the type of the wait samples, which snapshots share
+var samplesType = reflect.TypeOf(Samples(nil))
+
+// loadAtomically sets dst to a copy of src, loading the int32 and float32 values in it atomically,
+// also those in its structs and slices
+func loadAtomically(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Int32:
+		dst.SetInt(int64(atomic.LoadInt32((*int32)(src.Addr().UnsafePointer()))))
+	case reflect.Float32:
+		dst.SetFloat(float64(math.Float32frombits(atomic.LoadUint32((*uint32)(src.Addr().UnsafePointer())))))
+	case reflect.Struct:
+		for i := 0; i < src.NumField(); i++ {
+			loadAtomically(dst.Field(i), src.Field(i))
+		}
+	case reflect.Slice:
+		if src.IsNil() || src.Type() == samplesType {
+			dst.Set(src)
+			return
+		}
+		dst.Set(reflect.MakeSlice(src.Type(), src.Len(), src.Len()))
+		for i := 0; i < src.Len(); i++ {
+			loadAtomically(dst.Index(i), src.Index(i))
+		}
+	default:
+		dst.Set(src)
+	}
+}
+
+// liveGauges are the counts of what is in the station at the moment, which reset keeps
+var liveGauges = map[string]bool{
+	"CarsInRefuelQueue":   true,
+	"CarsInCheckoutQueue": true,
+	"RegistersBusy":       true,
+	"StationsBusy":        true,
+	"StationsInRepair":    true,
+}
+
+// reset zeroes the stats, keeping the live gauges and the names and IDs. The counters are stored
+// atomically, as the cars of a realtime run update them meanwhile, the slices are guarded by mu,
+// which the caller holds
+func (st *Stats) reset() {
+	resetAtomically(reflect.ValueOf(st).Elem())
+	st.Days = nil
+}
+
+// resetAtomically zeroes the int32 and float32 values in v but the live gauges, also those in its
+// structs and the elements of its slices of structs, and empties its other slices
+func resetAtomically(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Int32:
+		atomic.StoreInt32((*int32)(v.Addr().UnsafePointer()), 0)
+	case reflect.Float32:
+		atomic.StoreUint32((*uint32)(v.Addr().UnsafePointer()), 0)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !liveGauges[v.Type().Field(i).Name] {
+				resetAtomically(v.Field(i))
+			}
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Struct {
+			v.SetZero()
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			resetAtomically(v.Index(i))
+		}
+	}
+}
+
+// Total sums the stats of all fuel types
+func (st *Stats) Total() FuelStats {
+	total := FuelStats{Name: "total"}
+	for _, f := range st.Fuels {
+		total.CarsSpawned += f.CarsSpawned
+		total.CarsNotServed += f.CarsNotServed
+		total.CarsBalked += f.CarsBalked
+		total.CarsDroveOff += f.CarsDroveOff
+		total.DriveOffLoss += f.DriveOffLoss
+		total.CarsRefueled += f.CarsRefueled
+		total.CarsCheckedOut += f.CarsCheckedOut
+		total.Cash += f.Cash
+		total.Units += f.Units
+		total.TimeRefueling += f.TimeRefueling
+		total.TimeInRefuelQueue += f.TimeInRefuelQueue
+		total.CarsInRefuelQueue += f.CarsInRefuelQueue
+		total.StationsBusy += f.StationsBusy
+		total.StationsInRepair += f.StationsInRepair
+		total.PumpFailures += f.PumpFailures
+		total.Downtime += f.Downtime
+		for len(total.HourlyRevenue) < len(f.HourlyRevenue) {
+			total.HourlyRevenue = append(total.HourlyRevenue, 0)
+		}
+		for hour, cash := range f.HourlyRevenue {
+			total.HourlyRevenue[hour] += cash
+		}
+		total.RefuelQueueWaits = append(total.RefuelQueueWaits, f.RefuelQueueWaits...)
+		total.CheckoutQueueWaits = append(total.CheckoutQueueWaits, f.CheckoutQueueWaits...)
+		total.FuelingTimes = append(total.FuelingTimes, f.FuelingTimes...)
+		total.TimesAtStation = append(total.TimesAtStation, f.TimesAtStation...)
+	}
+	return total
+}
+
+// registerCars returns the cars that paid at a cash register
+func (st *Stats) registerCars() int32 {
+	var cars int32
+	for _, reg := range st.Registers {
+		cars += reg.CarsCheckedOut
+	}
+	return cars
+}
+
+// timeAtStation returns the seconds the cars that paid spent refueling and paying, queues included
+func (st *Stats) timeAtStation() float32 {
+	return st.Total().TimeRefueling + st.TimeWaitingForAttendant + st.CheckoutTimeTotal + st.TimeInCheckoutQueue + st.TimePayingAtPump
+}
+
+// FuelStations returns the stats of the stations of the fuel type
+func (st *Stats) FuelStations(fuel string) []StationStats {
+	var stations []StationStats
+	for _, station := range st.Stations {
+		if station.Fuel == fuel {
+			stations = append(stations, station)
+		}
+	}
+	return stations
+}
+
+// Print writes the final report of the run
+func (r Results) Print(w io.Writer) {
+	stats := &r.Stats
+	total := stats.Total()
+
+	fmt.Fprintln(w, "-----------------------------------------------------------------")
+	fmt.Fprintln(w, "Random seed: ", r.Config.RandomSeed)
+	if m := r.Metadata; m != nil {
+		fmt.Fprintf(w, "Run %s started %s, version %s, config %s\n", m.ID, m.Started.Format(time.RFC3339), m.Version, m.ConfigHash)
+	}
+	if r.Config.Warmup > 0 {
+		fmt.Fprintf(w, "Warm-up excluded from stats: %v\n", time.Duration(r.Config.Warmup))
+	}
+	fmt.Fprintln(w, "Total cars: ", stats.CarsSpawnedTotal)
+	fmt.Fprintln(w, "Cars refueled total: ", total.CarsRefueled)
+	var refueled []string
+	for _, f := range stats.Fuels {
+		refueled = append(refueled, fmt.Sprintf("%s %d", f.Name, f.CarsRefueled))
+	}
+	fmt.Fprintln(w, "Cars refueled by fuel type: ", strings.Join(refueled, ", "))
+	fmt.Fprintln(w, "Cars checked out total: ", total.CarsCheckedOut)
+	fmt.Fprintln(w, "Cars not served: ", stats.CarsNotServed)
+	fmt.Fprintf(w, "Cars checked out rate: %.2f %%\n", float32(total.CarsCheckedOut)/float32(stats.CarsSpawnedTotal)*100)
+	if r.Config.PayAtPump != nil {
+		fmt.Fprintf(w, "Cars paid at the pump: %d (%.2f %% of checked out cars)\n", stats.CarsPaidAtPump, float32(stats.CarsPaidAtPump)/float32(total.CarsCheckedOut)*100)
+	}
+	fmt.Fprintf(w, "Cars not served rate: %.2f %%\n", float32(stats.CarsNotServed)/float32(stats.CarsSpawnedTotal)*100)
+	var notServed []string
+	for _, f := range stats.Fuels {
+		notServed = append(notServed, fmt.Sprintf("%s %d (%.2f %%)", f.Name, f.CarsNotServed, float32(f.CarsNotServed)/float32(f.CarsSpawned)*100))
+	}
+	fmt.Fprintln(w, "Cars not served by fuel type: ", strings.Join(notServed, ", "))
+	if stats.CarsBalked > 0 {
+		var balked []string
+		for _, f := range stats.Fuels {
+			balked = append(balked, fmt.Sprintf("%s %d", f.Name, f.CarsBalked))
+		}
+		queue := "refuel"
+		if r.Config.Prepay {
+			queue = "refuel or checkout"
+		}
+		fmt.Fprintf(w, "Cars balked at a full %s queue: %d (%.2f %%), by fuel type: %s\n",
+			queue, stats.CarsBalked, float32(stats.CarsBalked)/float32(stats.CarsSpawnedTotal)*100, strings.Join(balked, ", "))
+	}
+	if stats.CarsTurnedAway > 0 {
+		var turnedAway []string
+		for _, f := range stats.Fuels {
+			turnedAway = append(turnedAway, fmt.Sprintf("%s %d", f.Name, f.CarsTurnedAway))
+		}
+		fmt.Fprintf(w, "Cars turned away by a full site: %d (%.2f %%), by fuel type: %s\n",
+			stats.CarsTurnedAway, float32(stats.CarsTurnedAway)/float32(stats.CarsSpawnedTotal)*100, strings.Join(turnedAway, ", "))
+	}
+	averages := r.Report().Averages
+	fmt.Fprintf(w, "Pump utilization: %.2f %%\n", averages.Utilization)
+	for i, f := range stats.Fuels {
+		var pumps []string
+		for _, st := range averages.Stations {
+			if st.Fuel == f.Name {
+				pumps = append(pumps, fmt.Sprintf("#%d %.1f %%", st.ID, st.Utilization))
+			}
+		}
+		fmt.Fprintf(w, "Pump utilization %s: %.2f %% (%s)\n", f.Name, averages.Fuels[i].Utilization, strings.Join(pumps, ", "))
+	}
+	fmt.Fprintf(w, "Cash register utilization: %.2f %%\n", averages.RegisterUtilization)
+	for i, reg := range stats.Registers {
+		fmt.Fprintf(w, "Cash register #%d: %d cars, %.1f %% busy, %.2f s average checkout queue wait\n",
+			reg.ID, reg.CarsCheckedOut, averages.Registers[i].Utilization, averages.Registers[i].TimeInCheckout)
+	}
+	r.printCheckoutPolicy(w)
+	if stats.CarsDroveOff > 0 {
+		var droveOff []string
+		for _, f := range stats.Fuels {
+			droveOff = append(droveOff, fmt.Sprintf("%s %d (%.2f €)", f.Name, f.CarsDroveOff, f.DriveOffLoss))
+		}
+		fmt.Fprintf(w, "Cars drove off without paying: %d (%.2f %% of refueled cars), %.2f € unpaid, by fuel type: %s\n",
+			stats.CarsDroveOff, averages.DriveOffRate, total.DriveOffLoss, strings.Join(droveOff, ", "))
+	}
+	if len(stats.Attendants) > 0 {
+		var attendants []string
+		for i, a := range stats.Attendants {
+			attendants = append(attendants, fmt.Sprintf("#%d %d cars %.1f %%", a.ID, a.CarsServed, averages.Attendants[i].Utilization))
+		}
+		fmt.Fprintf(w, "Attendant utilization: %.2f %% (%s)\n", averages.AttendantUtilization, strings.Join(attendants, ", "))
+		if stats.CarsWaitedForAttendant > 0 {
+			fmt.Fprintf(w, "Cars waiting for an attendant at their pump: %d, %.2f s on average\n", stats.CarsWaitedForAttendant, averages.TimeWaitingForAttendant)
+		} else {
+			fmt.Fprintln(w, "Cars waiting for an attendant at their pump: 0")
+		}
+	}
+	if !r.Config.Prepay {
+		fmt.Fprintf(w, "Cars blocked at their pump by a full checkout queue: %d (%.2f %% of refueled cars), %.2f s on average\n",
+			stats.CarsBlocked, averages.BlockedRate, averages.TimeBlocked)
+	}
+	if r.Config.lanes() {
+		fmt.Fprintf(w, "Cars blocked in their lane by a car in front: %d, %.2f s on average\n",
+			stats.CarsBlockedInLane, stats.TimeBlockedInLane/float32(stats.CarsBlockedInLane))
+	}
+	if r.Config.HoldPump {
+		fmt.Fprintf(w, "Cars holding their pump until paid: %d, %.2f s on average after fueling\n",
+			stats.CarsHeldPump, stats.TimeHoldingPump/float32(stats.CarsHeldPump))
+	}
+	if r.Config.PumpFailures.MTBF > 0 {
+		fmt.Fprintf(w, "Pump failures total: %d, %.2f s of downtime\n", total.PumpFailures, total.Downtime)
+		var failures []string
+		for _, f := range stats.Fuels {
+			failures = append(failures, fmt.Sprintf("%s %d (%.2f s)", f.Name, f.PumpFailures, f.Downtime))
+		}
+		fmt.Fprintln(w, "Pump failures by fuel type: ", strings.Join(failures, ", "))
+	}
+	fmt.Fprintln(w, "-------------------------------")
+	fmt.Fprintf(w, "Average receipt: %.2f €\n", total.Cash/float32(total.CarsCheckedOut))
+	for _, f := range stats.Fuels {
+		fmt.Fprintf(w, "Average receipt %s: %.2f €\n", f.Name, f.Cash/float32(f.CarsCheckedOut))
+	}
+	if r.Config.Shop != nil {
+		fmt.Fprintf(w, "Shop purchases: %d (%.2f %% of checked out cars), %.2f € on average\n",
+			stats.ShopPurchases, averages.ShopPurchaseRate, averages.ShopReceipt)
+		fmt.Fprintf(w, "Revenue fuel / shop: %.2f € / %.2f € (%.2f %% shop)\n", total.Cash, stats.ShopRevenue, averages.ShopRevenueShare)
+	}
+	if r.Config.Loyalty != nil {
+		fmt.Fprintf(w, "Loyalty customers: %d (%.2f %% of checked out cars), %.2f € revenue after %.2f € discount\n",
+			stats.LoyaltyCustomers, averages.LoyaltyPenetration, stats.LoyaltyRevenue, stats.LoyaltyDiscount)
+	}
+	printCosts(w, averages.Costs)
+	printRevenueTimeline(w, stats, total)
+	if len(stats.Days) > 1 {
+		printDays(w, stats)
+	}
+	if len(stats.Periods) > 0 {
+		printPeriods(w, r.Config, stats)
+	}
+	if len(stats.Weather) > 0 {
+		printWeather(w, r.Config, stats)
+	}
+	if len(stats.Classes) > 0 {
+		printClasses(w, r.Config, stats)
+	}
+	fmt.Fprintln(w, "-------------------------------")
+	fmt.Fprintf(w, "Average units refueled: %.2f\n", total.Units/float32(total.CarsCheckedOut))
+	for _, f := range stats.Fuels {
+		fmt.Fprintf(w, "Average %s refueled: %.2f %s\n", f.Name, f.Units/float32(f.CarsCheckedOut), r.Config.FuelUnit(f.Name))
+	}
+	for _, f := range stats.Fuels {
+		if fc := r.Config.Fuels[f.Name]; fc.Fill != nil {
+			fmt.Fprintf(w, "Average %s filled %s\n", f.Name, fillSummary(f, fc.Charging != nil))
+		}
+		if r.Config.Fuels[f.Name].SharedPower != nil {
+			fmt.Fprintf(w, "Charging %s slowed down by shared power: %s\n", f.Name, powerSummary(f))
+		}
+	}
+	for _, f := range stats.Fuels {
+		if r.Config.Fuels[f.Name].DynamicPricing() {
+			fmt.Fprintf(w, "Average %s price: %.2f €/%s\n", f.Name, f.Cash/f.Units, r.Config.FuelUnit(f.Name))
+		}
+	}
+	fmt.Fprintln(w, "-------------------------------")
+	fmt.Fprintf(w, "Average time spent refueling: %.2f s\n", total.TimeRefueling/float32(total.CarsRefueled))
+	for _, f := range stats.Fuels {
+		fmt.Fprintf(w, "Average time spent %s: %.2f s\n", f.Name, f.TimeRefueling/float32(f.CarsRefueled))
+	}
+	fmt.Fprintf(w, "Average time spent in refuel queue: %.2f s\n", total.TimeInRefuelQueue/float32(total.CarsRefueled))
+	for _, f := range stats.Fuels {
+		fmt.Fprintf(w, "Average time spent in refuel queue %s: %.2f s\n", f.Name, f.TimeInRefuelQueue/float32(f.CarsRefueled))
+	}
+	fmt.Fprintf(w, "Average time spent checking out: %.2f s\n", stats.CheckoutTimeTotal/float32(stats.registerCars()))
+	if len(stats.Payments) > 0 {
+		var payments []string
+		for i, p := range stats.Payments {
+			payments = append(payments, fmt.Sprintf("%s %d (%.2f %%, %.2f s)", p.Name, p.Checkouts, averages.Payments[i].Share, averages.Payments[i].TimeCheckingOut))
+		}
+		fmt.Fprintln(w, "Checkouts by payment method: ", strings.Join(payments, ", "))
+	}
+	if stats.CarsPaidAtPump > 0 {
+		fmt.Fprintf(w, "Average time spent paying at the pump: %.2f s\n", stats.TimePayingAtPump/float32(stats.CarsPaidAtPump))
+	}
+	fmt.Fprintf(w, "Average time spent in queue before leaving: %.2f s\n", stats.TimeBeforeLeaving/float32(stats.CarsNotServed))
+	fmt.Fprintf(w, "Average time spent at gas station: %.2f s\n", stats.timeAtStation()/float32(total.CarsCheckedOut))
+	fmt.Fprintln(w, "-------------------------------")
+	printPercentiles(w, "Refuel queue wait", total.RefuelQueueWaits)
+	for _, f := range stats.Fuels {
+		printPercentiles(w, "Refuel queue wait "+f.Name, f.RefuelQueueWaits)
+	}
+	printPercentiles(w, "Checkout queue wait", total.CheckoutQueueWaits)
+	for _, f := range stats.Fuels {
+		printPercentiles(w, "Checkout queue wait "+f.Name, f.CheckoutQueueWaits)
+	}
+	averages.RefuelQueueLittlesLaw.print(w, "refuel queue")
+	averages.CheckoutQueueLittlesLaw.print(w, "checkout queue")
+	if h := stats.histograms(r.Config.HistogramBuckets); h != nil {
+		fmt.Fprintln(w, "-------------------------------")
+		h.RefuelQueueWait.print(w, "Refuel queue wait")
+		h.CheckoutQueueWait.print(w, "Checkout queue wait")
+		h.FuelingTime.print(w, "Fueling time")
+		h.TimeAtStation.print(w, "Time at station")
+	}
+	if r.Resources != nil {
+		fmt.Fprintln(w, "-------------------------------")
+		r.Resources.print(w)
+	}
+	fmt.Fprintln(w, "-----------------------------------------------------------------")
+}
+
+func printPercentiles(w io.Writer, name string, samples Samples) {
+	p := samples.Percentiles()
+	fmt.Fprintf(w, "%s p50/p90/p99: %.2f / %.2f / %.2f s\n", name, p.P50, p.P90, p.P99)
+}
+
+// printRevenueTimeline writes a row with the revenue of every fuel type per simulated hour
+func printRevenueTimeline(w io.Writer, stats *Stats, total FuelStats) {
+	fmt.Fprintln(w, "Revenue by hour:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "\t")
+	for _, f := range stats.Fuels {
+		fmt.Fprintf(tw, "%s\t", f.Name)
+	}
+	fmt.Fprintln(tw, "total\t")
+
+	for hour, cash := range total.HourlyRevenue {
+		fmt.Fprintf(tw, "%s\t", hourLabel(hour))
+		for _, f := range stats.Fuels {
+			var fuelCash float32
+			if hour < len(f.HourlyRevenue) {
+				fuelCash = f.HourlyRevenue[hour]
+			}
+			fmt.Fprintf(tw, "%.2f €\t", fuelCash)
+		}
+		fmt.Fprintf(tw, "%.2f €\t\n", cash)
+	}
+	tw.Flush()
+}
+
+// hourLabel names the simulated hour since the start, runs start at midnight
+func hourLabel(hour int) string {
+	if hour < 24 {
+		return fmt.Sprintf("%02d:00", hour)
+	}
+	return fmt.Sprintf("day %d %02d:00", hour/24+1, hour%24)
+}
@@ -0,0 +1,72 @@
+package sim
+
+import (
+	"context"
+	"time"
+)
+
+// QueueSample is the state of the queues, pumps and cash registers at a moment of the run
+type QueueSample struct {
+	Time                float64      `json:"time"` // simulated seconds since the start
+	CarsInRefuelQueue   int32        `json:"cars_in_refuel_queue"`
+	CarsInCheckoutQueue int32        `json:"cars_in_checkout_queue"`
+	RegistersBusy       int32        `json:"registers_busy"`
+	Fuels               []FuelSample `json:"fuels"` // indexed by FuelType
+}
+
+// FuelSample is the state of the pumps of a single fuel type
+type FuelSample struct {
+	Name              string `json:"name"`
+	CarsInRefuelQueue int32  `json:"cars_in_refuel_queue"`
+	StationsBusy      int32  `json:"stations_busy"`
+	StationsInRepair  int32  `json:"stations_in_repair"`
+}
+
+// TimeSeries returns the queue samples taken every sample_interval so far
+func (s *Simulation) TimeSeries() []QueueSample {
+	s.seriesMu.Lock()
+	defer s.seriesMu.Unlock()
+
+	return append([]QueueSample(nil), s.series...)
+}
+
+// sampleQueues records the current queue lengths elapsed into the run
+func (s *Simulation) sampleQueues(elapsed time.Duration) {
+	sample := QueueSample{
+		Time:                elapsed.Seconds(),
+		CarsInRefuelQueue:   s.gauge(func(st *Stats) *int32 { return &st.CarsInRefuelQueue }),
+		CarsInCheckoutQueue: s.gauge(func(st *Stats) *int32 { return &st.CarsInCheckoutQueue }),
+		RegistersBusy:       s.gauge(func(st *Stats) *int32 { return &st.RegistersBusy }),
+		Fuels:               make([]FuelSample, len(s.fuelNames)),
+	}
+	for i, name := range s.fuelNames {
+		sample.Fuels[i] = FuelSample{
+			Name:              name,
+			CarsInRefuelQueue: s.gauge(func(st *Stats) *int32 { return &st.Fuels[i].CarsInRefuelQueue }),
+			StationsBusy:      s.gauge(func(st *Stats) *int32 { return &st.Fuels[i].StationsBusy }),
+			StationsInRepair:  s.gauge(func(st *Stats) *int32 { return &st.Fuels[i].StationsInRepair }),
+		}
+	}
+
+	s.seriesMu.Lock()
+	s.series = append(s.series, sample)
+	s.seriesMu.Unlock()
+
+	if s.QueueSamples != nil {
+		s.QueueSamples(sample)
+	}
+}
+
+// sampleQueuesRealtime samples the queues every sample_interval of a realtime run until ctx is cancelled
+func (s *Simulation) sampleQueuesRealtime(ctx context.Context) {
+	interval := time.Duration(s.config.SampleInterval)
+	for next := time.Duration(0); ; next += interval {
+		select {
+		case <-s.clock.at(next):
+			// stamped with the grid time so samples line up for plotting
+			s.sampleQueues(next)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package sim
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// trace event names, in the order of a car's journey
+const (
+	EventSpawned           = "spawned"
+	EventJoinedRefuelQueue = "joined_refuel_queue"
+	EventStartedFueling    = "started_fueling"
+	EventFinishedFueling   = "finished_fueling"
+	EventJoinedCheckout    = "joined_checkout"
+	EventStartedCheckout   = "started_checkout"
+	EventPaid              = "paid"
+	EventLeftUnserved      = "left_unserved"
+	EventBalked            = "balked"      // left right away as the refuel queue was full
+	EventTurnedAway        = "turned_away" // left right away as the site was full
+	EventDroveOff          = "drove_off"   // ran out of patience waiting to check out and left without paying
+)
+
+// TraceEvent is a line of the event trace
+type TraceEvent struct {
+	Run      string   `json:"run"`  // ID of the run
+	Time     float64  `json:"time"` // simulated seconds since the start
+	Event    string   `json:"event"`
+	Car      int      `json:"car"`
+	Fuel     string   `json:"fuel"`
+	Station  *int     `json:"station,omitempty"`
+	Register *int     `json:"register,omitempty"`
+	Amount   *float32 `json:"amount,omitempty"` // receipt of finished_fueling and paid
+
+	// the car itself, written with spawned so the arrivals can be replayed
+	TankSize *float32 `json:"tank_size,omitempty"`
+	WaitTime *float32 `json:"wait_time,omitempty"`
+	Class    string   `json:"class,omitempty"` // vehicle class
+}
+
+// trace writes an event of the car to the Trace writer and hands it to TraceEvents, the observers
+// and Step, if they are set
+func (s *Simulation) trace(elapsed time.Duration, event string, car *Car, station *Station, register *CashRegister) {
+	if s.Trace == nil && s.Step == nil && s.TraceEvents == nil && len(s.observers) == 0 {
+		return
+	}
+
+	e := TraceEvent{Run: s.metadata.ID, Time: elapsed.Seconds(), Event: event, Car: car.ID, Fuel: s.fuelNames[car.Fuel]}
+	if station != nil {
+		e.Station = &station.ID
+	}
+	if register != nil {
+		e.Register = &register.ID
+	}
+	// copies, as the car is reused once it is gone
+	switch event {
+	case EventSpawned:
+		tankSize, waitTime := car.FuelTankSize, car.WaitTime
+		e.TankSize, e.WaitTime = &tankSize, &waitTime
+		if len(s.classes) > 0 {
+			e.Class = s.classes[car.Class]
+		}
+	case EventFinishedFueling, EventPaid:
+		amount := car.Receipt
+		e.Amount = &amount
+	}
+
+	s.traceMu.Lock()
+	defer s.traceMu.Unlock()
+	if s.traceStopped {
+		return
+	}
+	if s.Step != nil {
+		s.stepEvents = append(s.stepEvents, e)
+	}
+	if s.TraceEvents != nil {
+		s.TraceEvents(e)
+	}
+	s.notify(e)
+	if s.Trace == nil || s.traceErr != nil {
+		return
+	}
+	if s.traceEncoder == nil {
+		s.traceEncoder = json.NewEncoder(s.Trace)
+	}
+	if err := s.traceEncoder.Encode(e); err != nil {
+		s.traceErr = fmt.Errorf("writing the trace: %w", err)
+	}
+}
+
+// stopTrace keeps goroutines still running after a realtime run from writing to Trace and returns
+// the first error writing it, which ended the trace
+func (s *Simulation) stopTrace() error {
+	s.traceMu.Lock()
+	defer s.traceMu.Unlock()
+
+	s.traceStopped = true
+	return s.traceErr
+}
@@ -0,0 +1,31 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// failingWriter fails every write after the first n
+type failingWriter struct{ n int }
+
+var errDiskFull = errors.New("disk full")
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.n == 0 {
+		return 0, errDiskFull
+	}
+	w.n--
+	return len(p), nil
+}
+
+func TestRunReturnsTraceError(t *testing.T) {
+	config := harnessConfig()
+	config.RandomSeed = 1
+	simulation := New(config)
+	simulation.Trace = &failingWriter{n: 10}
+
+	if err := simulation.Run(context.Background()); !errors.Is(err, errDiskFull) {
+		t.Errorf("expected the trace write error, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package sim
+
+import "time"
+
+// StationStats are the stats of a single pump
+type StationStats struct {
+	ID       int     `json:"id"`
+	Fuel     string  `json:"fuel"`
+	BusyTime float32 `json:"busy_time"` // seconds a car was at the pump within the observed part of the run
+}
+
+// RegisterStats are the stats of a single cash register
+type RegisterStats struct {
+	ID                  int     `json:"id"`
+	CarsCheckedOut      int32   `json:"cars_checked_out"`
+	BusyTime            float32 `json:"busy_time"`              // seconds checking out within the observed part of the run
+	TimeInCheckoutQueue float32 `json:"time_in_checkout_queue"` // of the cars it checked out
+}
+
+// busyPeriods hold when each pump or cash register got its current car, indexed by ID and -1 while free
+type busyPeriods []time.Duration
+
+// startBusy marks the pump or register as having a car since elapsed into the run
+func (s *Simulation) startBusy(periods busyPeriods, id int, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	periods[id] = elapsed
+}
+
+// endBusy adds the time since the car arrived at the pump or register to busyTime, only the part
+// between the warm-up and the end of the run counts
+func (s *Simulation) endBusy(periods busyPeriods, id int, busyTime *float32, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	since := periods[id]
+	if since < 0 {
+		return
+	}
+	periods[id] = -1
+
+	from := max(since, time.Duration(s.config.Warmup))
+	to := min(elapsed, time.Duration(s.config.SimulationLength))
+	if to > from {
+		*busyTime += float32(to.Seconds() - from.Seconds())
+	}
+}
+
+// endObservation counts the pumps, registers and attendants still occupied at the end of the run as busy until the end
+// and the cars still in the queues as waiting until then
+func (s *Simulation) endObservation() {
+	end := time.Duration(s.config.SimulationLength)
+	s.changeQueue(&s.refuelWaiting, &s.stats.RefuelQueueArea, 0, s.start.Add(end))
+	s.changeQueue(&s.checkoutWaiting, &s.stats.CheckoutQueueArea, 0, s.start.Add(end))
+	for id := range s.stats.Stations {
+		s.endBusy(s.stationsBusySince, id, &s.stats.Stations[id].BusyTime, end)
+	}
+	for id := range s.stats.Registers {
+		s.endBusy(s.registersBusySince, id, &s.stats.Registers[id].BusyTime, end)
+	}
+	for id := range s.stats.Attendants {
+		s.endBusy(s.attendantsBusySince, id, &s.stats.Attendants[id].BusyTime, end)
+	}
+	for id := range s.stats.Weather {
+		s.endBusy(s.weatherSince, id, &s.stats.Weather[id].Time, end)
+	}
+}
+
+// observedTime returns the seconds of the run the stats cover
+func (c Config) observedTime() float64 {
+	return time.Duration(c.SimulationLength - c.Warmup).Seconds()
+}
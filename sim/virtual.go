@@ -0,0 +1,843 @@
+package sim
+
+import (
+	"container/heap"
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// eventKind is what an event of a virtual run does when it comes up
+type eventKind int
+
+const (
+	eventFunc       eventKind = iota // calls fn, the events of a market
+	eventEndWarmup                   // resets the stats of the warm-up
+	eventSample                      // samples the queues
+	eventArrival                     // a car of the arrival process
+	eventSpawnTick                   // a car arrives by the spawn chance
+	eventSourced                     // the arrival of the car source
+	eventRenege                      // car gives up waiting for a station
+	eventDriveOff                    // car gives up waiting to check out
+	eventFueled                      // car refueled at its station
+	eventCharged                     // charging session ends, unless rescheduled since
+	eventPaidAtPump                  // car paid at its station
+	eventFailure                     // a station of the fuel breaks down
+	eventRepaired                    // station back in service
+	eventCheckedOut                  // car paid at its cash register
+	eventShift                       // next shift starts
+	eventWeather                     // the weather changes
+)
+
+// event is a single action scheduled on the simulated clock. The events of a site are plain data,
+// what happens and to which car, station, cash register or fuel, so a checkpoint can write them out.
+type event struct {
+	at   time.Duration // simulated time since start
+	seq  int           // keeps events scheduled for the same time in FIFO order
+	kind eventKind
+	g    *virtualGasStation // the site, nil for eventFunc
+
+	car     *Car
+	id      int     // of the station, cash register or fuel type, the round of eventCharged
+	seconds float32 // spent paying at the pump
+	fueling *fueling
+	session *chargingSession
+	arrival *Arrival
+	fn      func()
+}
+
+type eventQueue []*event
+
+func (q eventQueue) Len() int { return len(q) }
+
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].at == q[j].at {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].at < q[j].at
+}
+
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *eventQueue) Push(x interface{}) { *q = append(*q, x.(*event)) }
+
+func (q *eventQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+// scheduler jumps the simulated clock from one event to the next instead of sleeping
+type scheduler struct {
+	start  time.Time
+	now    time.Duration
+	seq    int
+	events eventQueue
+	ran    int      // events processed so far
+	free   []*event // processed events, reused by after
+
+	afterEvent func()        // called after every event when set
+	published  *atomic.Int64 // set to the simulated time every so often when set, for other goroutines
+}
+
+// newScheduler starts the simulated time at the wall-clock time start
+func newScheduler(start time.Time) *scheduler {
+	s := new(scheduler)
+	s.start = start
+
+	return s
+}
+
+// Now returns the simulated wall-clock time
+func (s *scheduler) Now() time.Time {
+	return s.start.Add(s.now)
+}
+
+// after schedules fn to run d after the current simulated time
+func (s *scheduler) after(d time.Duration, fn func()) {
+	s.schedule(d, event{fn: fn})
+}
+
+// schedule schedules the event d after the current simulated time
+func (s *scheduler) schedule(d time.Duration, e event) {
+	var next *event
+	if n := len(s.free); n > 0 {
+		next, s.free = s.free[n-1], s.free[:n-1]
+	} else {
+		next = new(event)
+	}
+	e.at, e.seq = s.now+d, s.seq
+	*next = e
+	heap.Push(&s.events, next)
+	s.seq++
+}
+
+// run processes all events up to and including end in time order, stopping early when ctx is cancelled
+// or once done, when set, returns true
+func (s *scheduler) run(ctx context.Context, end time.Duration, done func() bool) error {
+	for processed := 0; len(s.events) > 0 && s.events[0].at <= end; processed++ {
+		// checking on every event would dominate the cost of cheap events
+		if processed%1024 == 0 {
+			s.publish()
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+		}
+		if done != nil && done() {
+			return nil
+		}
+
+		e := heap.Pop(&s.events).(*event)
+		s.now = e.at
+		if e.g != nil {
+			e.g.handle(e)
+		} else {
+			e.fn()
+		}
+		s.ran++
+		*e = event{}
+		s.free = append(s.free, e)
+		if s.afterEvent != nil {
+			s.afterEvent()
+		}
+	}
+	s.now = end
+	s.publish()
+
+	return nil
+}
+
+func (s *scheduler) publish() {
+	if s.published != nil {
+		s.published.Store(int64(s.now))
+	}
+}
+
+// virtualGasStation is the event driven counterpart of manageGasStation, refuelCar and checkoutCar
+type virtualGasStation struct {
+	*Simulation
+	sched *scheduler
+
+	stations       []Station   // indexed by station ID
+	freeStations   [][]Station // indexed by FuelType
+	refuelQueues   [][]*Car    // cars waiting for a free station
+	freeRegisters  []CashRegister
+	freeAttendants []int
+	powerBanks     []*virtualPowerBank // indexed by FuelType, nil for fuels without shared power
+	attendantQueue []blockedCar        // cars at attended stations waiting for an attendant
+	checkoutQueue  []*Car
+	registerQueues [][]*Car     // replace checkoutQueue with a queue per register, indexed by register ID
+	blocked        []blockedCar // refueled cars waiting for room in the checkout queue
+	failures       []int        // indexed by FuelType, broken down stations waiting for one to come free
+	draining       bool         // the simulated time is up, no more cars arrive and no stations break down
+}
+
+// blockedCar still occupies its station until it fits into the checkout queue
+type blockedCar struct {
+	car     *Car
+	station Station
+}
+
+// Events returns the events a virtual run has processed once it has finished, the work it did
+// for benchmarks, realtime runs have none
+func (s *Simulation) Events() int {
+	return s.events
+}
+
+// runVirtual runs the simulation on a simulated clock, finishing as fast as the events can be processed,
+// from the checkpoint when resumed
+func (s *Simulation) runVirtual(ctx context.Context) error {
+	g := s.resumed
+	if g == nil {
+		g = newVirtualGasStation(s, newScheduler(s.wallClock().Now()))
+		if s.source != nil {
+			g.scheduleSourced()
+		} else {
+			g.scheduleArrival()
+		}
+	}
+	g.sched.published = &s.virtualElapsed
+	if s.Step != nil {
+		g.sched.afterEvent = g.step
+	}
+	defer s.endObservation()
+	defer func() { s.events = g.sched.ran }()
+	length := time.Duration(s.config.SimulationLength)
+	if err := g.runTo(ctx, length); err != nil {
+		return err
+	}
+
+	// let the cars inside finish
+	g.draining = true
+	return g.sched.run(ctx, length+s.drainTimeout(ctx), func() bool { return atomic.LoadInt32(&s.carsInside) == 0 })
+}
+
+// newVirtualGasStation sets up the stations, staff and the events of the simulation other than
+// the arrivals on sched
+func newVirtualGasStation(s *Simulation, sched *scheduler) *virtualGasStation {
+	g := buildVirtualGasStation(s, sched)
+	if s.config.PumpFailures.MTBF > 0 {
+		for _, free := range g.freeStations {
+			for _, station := range free {
+				g.scheduleFailure(station.Fuel)
+			}
+		}
+	}
+	if len(s.config.Shifts) > 0 {
+		g.applyShift()
+	}
+	if s.config.Weather != nil {
+		g.scheduleWeather()
+	}
+
+	if s.config.Warmup > 0 {
+		g.schedule(time.Duration(s.config.Warmup), event{kind: eventEndWarmup})
+	}
+	if s.config.SampleInterval > 0 {
+		g.sampleQueues(0)
+		g.scheduleSample()
+	}
+	return g
+}
+
+// buildVirtualGasStation sets up the stations and staff of the simulation on sched, all of them free
+// and without any events
+func buildVirtualGasStation(s *Simulation, sched *scheduler) *virtualGasStation {
+	g := new(virtualGasStation)
+	g.Simulation = s
+	g.sched = sched
+	s.start = sched.start
+	g.freeStations = make([][]Station, len(s.fuelNames))
+	g.refuelQueues = make([][]*Car, len(s.fuelNames))
+	g.failures = make([]int, len(s.fuelNames))
+	g.powerBanks = newVirtualPowerBanks(s)
+
+	// spawn stations, a token for every lane
+	var free []Station
+	g.stations = s.newStations()
+	s.forecourts, free = newForecourts(s, g.stations)
+	for _, station := range free {
+		g.freeStations[station.Fuel] = append(g.freeStations[station.Fuel], station)
+	}
+
+	g.freeRegisters = s.newRegisters()
+	if s.config.registerQueues() {
+		g.registerQueues = make([][]*Car, len(g.freeRegisters))
+	}
+	g.freeAttendants = s.newAttendants()
+	s.newShards(1)
+	s.newStaffPools()
+	return g
+}
+
+// step hands the trace events of the last event to Step, events that didn't move a car are skipped
+func (g *virtualGasStation) step() {
+	if len(g.stepEvents) == 0 {
+		return
+	}
+
+	events := g.stepEvents
+	g.stepEvents = nil
+	g.Step(events)
+}
+
+// schedule schedules the event at the site d after the current simulated time
+func (g *virtualGasStation) schedule(d time.Duration, e event) {
+	e.g = g
+	g.sched.schedule(d, e)
+}
+
+// handle does what the event of the site is scheduled for
+func (g *virtualGasStation) handle(e *event) {
+	switch e.kind {
+	case eventEndWarmup:
+		g.endWarmup()
+	case eventSample:
+		g.sampleQueues(g.sched.now)
+		g.scheduleSample()
+	case eventArrival:
+		g.arrival()
+	case eventSpawnTick:
+		g.spawnTick()
+	case eventSourced:
+		g.sourced(*e.arrival)
+	case eventRenege:
+		g.renege(e.car)
+	case eventDriveOff:
+		g.driveOffImpatient(e.car)
+	case eventFueled:
+		g.finishFueling(e.fueling, e.fueling.refuelTime)
+	case eventCharged:
+		g.endSession(e.session, e.id)
+	case eventPaidAtPump:
+		g.finishPayAtPump(e.car, g.stations[e.id], e.seconds)
+	case eventFailure:
+		g.breakDown(FuelType(e.id))
+	case eventRepaired:
+		g.finishRepair(g.stations[e.id])
+	case eventCheckedOut:
+		g.finishCheckout(e.car, CashRegister{ID: e.id})
+	case eventShift:
+		g.applyShift()
+	case eventWeather:
+		g.scheduleWeather()
+	}
+}
+
+// scheduleSample samples the queues every sample_interval
+func (g *virtualGasStation) scheduleSample() {
+	g.schedule(time.Duration(g.config.SampleInterval), event{kind: eventSample})
+}
+
+// scheduleArrival schedules the next car of the arrival process or the next spawn tick,
+// checking every time as a reload may switch between them
+func (g *virtualGasStation) scheduleArrival() {
+	if next, ok := g.nextArrival(g.sched.now); ok {
+		g.schedule(next, event{kind: eventArrival})
+		return
+	}
+	g.schedule(spawnInterval, event{kind: eventSpawnTick})
+}
+
+// scheduleSourced schedules the next arrival of the car source, which schedules the one after it
+func (g *virtualGasStation) scheduleSourced() {
+	a, ok := g.source.Next(g.sched.now, g.rng)
+	if !ok {
+		return
+	}
+
+	g.schedule(max(a.Time-g.sched.now, 0), event{kind: eventSourced, arrival: &a})
+}
+
+// sourced lets the car of the arrival of the car source arrive
+func (g *virtualGasStation) sourced(a Arrival) {
+	if g.draining {
+		return
+	}
+	if g.isOpen(g.sched.now) {
+		if car := g.sourcedCar(a); car != nil {
+			g.arrive(car)
+		}
+	}
+	g.scheduleSourced()
+}
+
+func (g *virtualGasStation) spawnTick() {
+	if g.draining {
+		return
+	}
+	if g.isOpen(g.sched.now) && g.rng.Float32() < g.carSpawnChance(g.sched.now) {
+		g.arrive(g.spawnCar())
+	}
+
+	g.scheduleArrival()
+}
+
+// arrival spawns a car of the Poisson or interarrival process
+func (g *virtualGasStation) arrival() {
+	if g.draining {
+		return
+	}
+	if g.isOpen(g.sched.now) {
+		g.arrive(g.spawnCar())
+	}
+	g.scheduleArrival()
+}
+
+func (g *virtualGasStation) arrive(car *Car) {
+	g.trace(g.sched.now, EventSpawned, car, nil, nil)
+	if g.siteFull() {
+		g.turnAway(car, g.sched.Now())
+		g.trace(g.sched.now, EventTurnedAway, car, nil, nil)
+		g.done(car)
+		return
+	}
+	if car.Prepaid {
+		g.prepay(car)
+		return
+	}
+	if len(g.freeStations[car.Fuel]) == 0 && g.queueFull(car.Fuel, len(g.refuelQueues[car.Fuel])) {
+		g.balk(car, g.sched.Now())
+		g.trace(g.sched.now, EventBalked, car, nil, nil)
+		g.done(car)
+		return
+	}
+	g.waitForStation(car)
+}
+
+// prepay sends the arrived car to pay at a cash register first, it balks while the checkout queue is full
+func (g *virtualGasStation) prepay(car *Car) {
+	if g.checkoutQueueLength() >= g.config.CheckoutQueueCapacity {
+		g.balk(car, g.sched.Now())
+		g.trace(g.sched.now, EventBalked, car, nil, nil)
+		g.done(car)
+		return
+	}
+
+	g.joinPrepayQueue(car, g.sched.Now())
+	if car.CheckoutWaitTime > 0 {
+		g.keep(car)
+		g.schedule(secondsToDuration(car.CheckoutWaitTime), event{kind: eventDriveOff, car: car})
+	}
+	g.enterCheckout(car)
+}
+
+// waitForStation queues the car for a station of its fuel
+func (g *virtualGasStation) waitForStation(car *Car) {
+	// car is waiting for a station to free up
+	g.joinRefuelQueue(car, g.sched.Now())
+	g.trace(g.sched.now, EventJoinedRefuelQueue, car, nil, nil)
+
+	if free := g.freeStations[car.Fuel]; len(free) > 0 {
+		g.freeStations[car.Fuel] = free[:len(free)-1]
+		g.refuel(car, free[len(free)-1])
+		return
+	}
+
+	g.refuelQueues[car.Fuel] = append(g.refuelQueues[car.Fuel], car)
+	g.keep(car)
+	g.schedule(secondsToDuration(car.WaitTime), event{kind: eventRenege, car: car})
+}
+
+// renege removes the car from its refuel queue if it is still waiting there
+func (g *virtualGasStation) renege(car *Car) {
+	defer g.release(car)
+	queue := g.refuelQueues[car.Fuel]
+	for i, c := range queue {
+		if c == car {
+			g.refuelQueues[car.Fuel] = append(queue[:i], queue[i+1:]...)
+			g.leaveUnserved(car, g.sched.Now())
+			g.trace(g.sched.now, EventLeftUnserved, car, nil, nil)
+			g.done(car)
+			return
+		}
+	}
+}
+
+func (g *virtualGasStation) refuel(car *Car, station Station) {
+	station, next := g.enterLane(station)
+	if next != nil {
+		defer g.releaseStation(*next)
+	}
+	// car moves from queue to station
+	g.startFueling(car, station, g.sched.Now())
+	if !station.Attended {
+		g.fuel(car, station, -1)
+		return
+	}
+
+	if len(g.freeAttendants) == 0 {
+		g.attendantQueue = append(g.attendantQueue, blockedCar{car, station})
+		return
+	}
+	attendant := g.freeAttendants[0]
+	g.freeAttendants = g.freeAttendants[1:]
+	g.attendantArrived(car, attendant, g.sched.Now(), false)
+	g.fuel(car, station, attendant)
+}
+
+// releaseAttendant frees the attendant once the car is refueled
+func (g *virtualGasStation) releaseAttendant(attendant int) {
+	g.attendantLeft(attendant, g.sched.Now())
+	if g.attendantPool.stayOnDuty(attendant) {
+		g.dispatchAttendant(attendant)
+	}
+}
+
+// dispatchAttendant sends the attendant to the next car waiting at an attended station or marks them free
+func (g *virtualGasStation) dispatchAttendant(attendant int) {
+	if len(g.attendantQueue) == 0 {
+		g.freeAttendants = append(g.freeAttendants, attendant)
+		return
+	}
+
+	b := g.attendantQueue[0]
+	g.attendantQueue = g.attendantQueue[1:]
+	g.attendantArrived(b.car, attendant, g.sched.Now(), true)
+	g.fuel(b.car, b.station, attendant)
+}
+
+// fueling is a car refueling at its station, until its eventFueled or the end of its charging session
+type fueling struct {
+	car        *Car
+	station    Station
+	attendant  int // -1 at stations without attendants
+	refuelTime float32
+	unitPrice  float32       // in effect when fueling started
+	start      time.Duration // of fueling
+}
+
+// fuel refuels the car at its station, served by the attendant at attended stations and -1 otherwise
+func (g *virtualGasStation) fuel(car *Car, station Station, attendant int) {
+	f := &fueling{car: car, station: station, attendant: attendant, start: g.sched.now}
+	f.refuelTime = g.drawRefuelTime(car, station)
+	f.unitPrice = g.fuelPrice(car.Fuel, g.sched.now)
+	g.trace(g.sched.now, EventStartedFueling, car, &station, nil)
+
+	if bank := g.powerBanks[car.Fuel]; bank != nil {
+		g.charge(bank, f)
+		return
+	}
+	g.schedule(secondsToDuration(f.refuelTime), event{kind: eventFueled, fueling: f})
+}
+
+// finishFueling sends the refueled car on to pay, fuelingTime is longer than its refuel time when
+// it shared the power of its charger
+func (g *virtualGasStation) finishFueling(f *fueling, fuelingTime float32) {
+	car, station := f.car, f.station
+	g.chargeRefuel(car, station, f.refuelTime, fuelingTime, f.unitPrice)
+	g.trace(g.sched.now, EventFinishedFueling, car, &station, nil)
+	if f.attendant >= 0 {
+		g.releaseAttendant(f.attendant)
+	}
+	if car.PayAtPump {
+		g.payAtPump(car, station)
+		return
+	}
+	if car.Prepaid {
+		g.settlePrepaid(car, g.sched.Now())
+		g.vacate(station)
+		g.done(car)
+		return
+	}
+	g.waitForCheckout(car, g.sched.Now())
+	if car.CheckoutWaitTime > 0 {
+		g.keep(car)
+		g.schedule(secondsToDuration(car.CheckoutWaitTime), event{kind: eventDriveOff, car: car})
+	}
+
+	if g.checkoutQueueLength() >= g.config.CheckoutQueueCapacity {
+		g.blocked = append(g.blocked, blockedCar{car, station})
+		g.blockPump(car)
+		return
+	}
+
+	if g.config.HoldPump {
+		g.holdPump(car, station)
+		g.enterCheckout(car)
+		return
+	}
+	g.enterCheckout(car)
+	g.vacate(station)
+}
+
+// payAtPump lets the refueled car pay at its station before it drives away
+func (g *virtualGasStation) payAtPump(car *Car, station Station) {
+	car.CheckoutQueueStart = g.sched.Now() // no queue, paying starts right away
+	payTime := g.randomInRange(car.rng, g.config.PayAtPump.Time)
+	g.schedule(secondsToDuration(payTime), event{kind: eventPaidAtPump, car: car, id: station.ID, seconds: payTime})
+}
+
+// finishPayAtPump lets the car that paid at its station for payTime seconds drive away
+func (g *virtualGasStation) finishPayAtPump(car *Car, station Station, payTime float32) {
+	g.paidAtPump(car, payTime, g.sched.Now())
+	g.trace(g.sched.now, EventPaid, car, &station, nil)
+	g.vacate(station)
+	g.done(car)
+}
+
+// scheduleFailure breaks down a station of the fuel type after it has worked for a while
+func (g *virtualGasStation) scheduleFailure(fuel FuelType) {
+	g.schedule(g.timeToFailure(g.rng), event{kind: eventFailure, id: int(fuel)})
+}
+
+// breakDown takes a free station of the fuel type out of service, a busy one once its car is gone
+func (g *virtualGasStation) breakDown(fuel FuelType) {
+	if g.draining {
+		return
+	}
+	if free := g.freeStations[fuel]; len(free) > 0 {
+		g.freeStations[fuel] = free[:len(free)-1]
+		g.repair(free[len(free)-1])
+		return
+	}
+	g.failures[fuel]++
+}
+
+// repair takes the station out of service until it is repaired
+func (g *virtualGasStation) repair(station Station) {
+	repairTime := g.breakStation(g.rng, station.Fuel, g.sched.now)
+	g.schedule(repairTime, event{kind: eventRepaired, id: station.ID})
+}
+
+// finishRepair puts the repaired station back in service
+func (g *virtualGasStation) finishRepair(station Station) {
+	g.repaired(station.Fuel)
+	g.releaseStation(station)
+	g.scheduleFailure(station.Fuel)
+}
+
+// enterLane takes the car with the token of a lane to its station and returns the token to hand on
+// while the lane has room, stations without lanes are taken as they are
+func (g *virtualGasStation) enterLane(token Station) (Station, *Station) {
+	f := g.forecourts[token.Fuel]
+	if f == nil {
+		return token, nil
+	}
+	return f.enter(token)
+}
+
+// vacate lets the car at the station drive away, in a lane once the cars in front of it left
+func (g *virtualGasStation) vacate(station Station) {
+	f := g.forecourts[station.Fuel]
+	if f == nil {
+		g.leaveStation(g.shared(), station, g.sched.Now())
+		g.releaseStation(station)
+		return
+	}
+	if f.finish(station, g.sched.Now()) {
+		g.leaveLane(f, station)
+	}
+}
+
+// leaveLane frees the station in its lane, the refueled cars behind it that waited for it leave along
+func (g *virtualGasStation) leaveLane(f *forecourt, station Station) {
+	g.leaveStation(g.shared(), station, g.sched.Now())
+	blocked, unblocked, token := f.leave(station, g.sched.Now())
+	g.leftLane(blocked)
+	for _, behind := range unblocked {
+		g.leaveLane(f, behind)
+	}
+	if token != nil {
+		g.releaseStation(*token)
+	}
+}
+
+// releaseStation hands the station to the next waiting car or marks it free
+func (g *virtualGasStation) releaseStation(station Station) {
+	if g.failures[station.Fuel] > 0 {
+		g.failures[station.Fuel]--
+		g.repair(station)
+		return
+	}
+
+	if queue := g.refuelQueues[station.Fuel]; len(queue) > 0 {
+		g.refuelQueues[station.Fuel] = queue[1:]
+		g.refuel(queue[0], station)
+		return
+	}
+
+	g.freeStations[station.Fuel] = append(g.freeStations[station.Fuel], station)
+}
+
+func (g *virtualGasStation) enterCheckout(car *Car) {
+	g.queueForRegister(car)
+	atomic.AddInt32(&car.stats.CarsInCheckoutQueue, 1)
+	g.trace(g.sched.now, EventJoinedCheckout, car, nil, nil)
+	g.dispatchCheckout()
+}
+
+// admitBlocked moves the first car blocking its station into the checkout queue once a spot opened up
+func (g *virtualGasStation) admitBlocked() {
+	if len(g.blocked) == 0 {
+		return
+	}
+
+	b := g.blocked[0]
+	g.blocked = g.blocked[1:]
+	g.queueForRegister(b.car)
+	g.unblockPump(b.car, g.sched.Now())
+	atomic.AddInt32(&b.car.stats.CarsInCheckoutQueue, 1)
+	g.trace(g.sched.now, EventJoinedCheckout, b.car, nil, nil)
+	if g.config.HoldPump {
+		g.holdPump(b.car, b.station)
+		return
+	}
+	g.vacate(b.station)
+}
+
+// releaseHeldPump frees the pump the car held until it paid or drove off, if it held one
+func (g *virtualGasStation) releaseHeldPump(car *Car) {
+	if station := g.releasePump(car, g.sched.Now()); station != nil {
+		g.vacate(*station)
+	}
+}
+
+// keep keeps the car from being reused until the event scheduled for it has run and released it
+func (g *virtualGasStation) keep(car *Car) {
+	car.events++
+}
+
+func (g *virtualGasStation) release(car *Car) {
+	car.events--
+	g.recycle(car)
+}
+
+// done marks the car as gone from the site
+func (g *virtualGasStation) done(car *Car) {
+	car.gone = true
+	g.recycle(car)
+}
+
+// recycle returns a gone car no event refers to anymore to the pool for the arrivals to come
+func (g *virtualGasStation) recycle(car *Car) {
+	if car.gone && car.events == 0 && g.carPool != nil {
+		*car = Car{}
+		g.carPool.Put(car)
+	}
+}
+
+// driveOffImpatient lets the car drive off without paying if it is still waiting in the checkout queue
+// or at its station for room in it, a prepaid car leaves without fuel instead
+func (g *virtualGasStation) driveOffImpatient(car *Car) {
+	defer g.release(car)
+	if g.leaveCheckoutQueue(car) {
+		if car.Prepaid {
+			g.leaveUnpaid(car, g.sched.Now())
+			g.trace(g.sched.now, EventLeftUnserved, car, nil, nil)
+		} else {
+			g.driveOff(car, g.sched.Now(), true)
+			g.trace(g.sched.now, EventDroveOff, car, nil, nil)
+			g.releaseHeldPump(car)
+		}
+		g.done(car)
+		g.admitBlocked()
+		return
+	}
+	for i, b := range g.blocked {
+		if b.car == car {
+			g.blocked = append(g.blocked[:i], g.blocked[i+1:]...)
+			g.unblockPump(car, g.sched.Now())
+			g.driveOff(car, g.sched.Now(), false)
+			g.trace(g.sched.now, EventDroveOff, car, &b.station, nil)
+			g.vacate(b.station)
+			g.done(car)
+			return
+		}
+	}
+}
+
+// checkoutQueueLength returns the cars waiting in the checkout queue, in the queues of all registers
+// when they have their own
+func (g *virtualGasStation) checkoutQueueLength() int {
+	length := len(g.checkoutQueue)
+	for _, queue := range g.registerQueues {
+		length += len(queue)
+	}
+	return length
+}
+
+// queueForRegister puts the car at the end of the checkout queue, of the register it picked when
+// every register has a queue of its own
+func (g *virtualGasStation) queueForRegister(car *Car) {
+	g.joinCheckoutLine(car)
+	if g.registerQueues != nil {
+		g.registerQueues[car.Register] = append(g.registerQueues[car.Register], car)
+		return
+	}
+	g.checkoutQueue = append(g.checkoutQueue, car)
+}
+
+// leaveCheckoutQueue takes the car out of the checkout queue, it reports false if the car isn't in it
+func (g *virtualGasStation) leaveCheckoutQueue(car *Car) bool {
+	queue := &g.checkoutQueue
+	if g.registerQueues != nil {
+		queue = &g.registerQueues[car.Register]
+	}
+	for i, c := range *queue {
+		if c == car {
+			*queue = append((*queue)[:i], (*queue)[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// nextCheckout takes the next car to check out out of the checkout queue along with the index of the free
+// register in freeRegisters it goes to, the car is nil when none waits for a free register
+func (g *virtualGasStation) nextCheckout() (int, *Car) {
+	if g.registerQueues == nil {
+		if len(g.checkoutQueue) == 0 {
+			return 0, nil
+		}
+		car := g.checkoutQueue[0]
+		g.checkoutQueue = g.checkoutQueue[1:]
+		return 0, car
+	}
+
+	for i, cashReg := range g.freeRegisters {
+		if queue := g.registerQueues[cashReg.ID]; len(queue) > 0 {
+			g.registerQueues[cashReg.ID] = queue[1:]
+			return i, queue[0]
+		}
+	}
+	return 0, nil
+}
+
+// dispatchCheckout pairs free cash registers with cars waiting in the checkout queue
+func (g *virtualGasStation) dispatchCheckout() {
+	for len(g.freeRegisters) > 0 {
+		i, car := g.nextCheckout()
+		if car == nil {
+			return
+		}
+		cashReg := g.freeRegisters[i]
+		g.freeRegisters = append(g.freeRegisters[:i], g.freeRegisters[i+1:]...)
+
+		g.admitBlocked()
+
+		checkoutTime := g.beginCheckout(car, cashReg, g.sched.Now())
+		g.trace(g.sched.now, EventStartedCheckout, car, nil, &cashReg)
+		g.occupyRegister(car, 1)
+		g.schedule(secondsToDuration(checkoutTime), event{kind: eventCheckedOut, car: car, id: cashReg.ID})
+	}
+}
+
+// finishCheckout frees the cash register the car paid at, a prepaid car goes on to queue for a station
+func (g *virtualGasStation) finishCheckout(car *Car, cashReg CashRegister) {
+	g.checkedOut(car, cashReg, g.sched.Now())
+	g.occupyRegister(car, -1)
+	g.trace(g.sched.now, EventPaid, car, nil, &cashReg)
+	if g.registerPool.stayOnDuty(cashReg.ID) {
+		g.freeRegisters = append(g.freeRegisters, cashReg)
+	}
+	if car.Prepaid {
+		g.waitForStation(car)
+	}
+	g.releaseHeldPump(car)
+	if !car.Prepaid {
+		g.done(car)
+	}
+	g.dispatchCheckout()
+}
@@ -0,0 +1,168 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Weather is a random process of weather states, each lasting an exponentially distributed time
+// before the next one is drawn by the shares
+type Weather struct {
+	MeanDuration Duration                `json:"mean_duration" yaml:"mean_duration"`
+	States       map[string]WeatherState `json:"states" yaml:"states"` // keyed by name
+}
+
+// WeatherState changes the demand while it lasts
+type WeatherState struct {
+	Share  float32 `json:"share" yaml:"share"`   // chance of being drawn, all shares sum to 1
+	Demand float32 `json:"demand" yaml:"demand"` // multiplier of the arrival rate
+	// multipliers of the chances of the fuels by name, such as more EV charging in the cold, other fuels keep theirs
+	FuelMix map[string]float32 `json:"fuel_mix,omitempty" yaml:"fuel_mix,omitempty"`
+}
+
+// WeatherStats are the stats of a single weather state
+type WeatherStats struct {
+	Name        string  `json:"name"`
+	Time        float32 `json:"time"`         // seconds within the observed part of the run
+	Changes     int32   `json:"changes"`      // times the weather set in, the first one included
+	CarsArrived int32   `json:"cars_arrived"` // including the ones that balked
+}
+
+// Names returns the names of the weather states in alphabetical order, the order of their stats
+func (w *Weather) Names() []string {
+	names := make([]string, 0, len(w.States))
+	for name := range w.States {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func validateWeather(c *Config, invalid func(key, format string, args ...interface{})) {
+	w := c.Weather
+	if w == nil {
+		return
+	}
+	if w.MeanDuration <= 0 {
+		invalid("weather.mean_duration", "must be greater than 0, got %v", time.Duration(w.MeanDuration))
+	}
+	if len(w.States) == 0 {
+		invalid("weather.states", "at least one weather state is needed")
+		return
+	}
+
+	var shareTotal float32
+	for _, name := range w.Names() {
+		state := w.States[name]
+		key := "weather.states." + name
+		if state.Share < 0 {
+			invalid(key+".share", "must not be negative, got %v", state.Share)
+		}
+		shareTotal += state.Share
+		if state.Demand <= 0 {
+			invalid(key+".demand", "must be greater than 0, got %v", state.Demand)
+		}
+		var chanceTotal float32
+		for fuel, fc := range c.Fuels {
+			mix, ok := state.FuelMix[fuel]
+			if !ok {
+				mix = 1
+			}
+			chanceTotal += fc.Chance * mix
+		}
+		for fuel, mix := range state.FuelMix {
+			if _, ok := c.Fuels[fuel]; !ok {
+				invalid(key+".fuel_mix."+fuel, "unknown fuel type")
+			}
+			if mix < 0 {
+				invalid(key+".fuel_mix."+fuel, "must not be negative, got %v", mix)
+			}
+		}
+		if chanceTotal <= 0 {
+			invalid(key+".fuel_mix", "leaves no fuel type with a chance")
+		}
+	}
+	if math.Abs(float64(shareTotal)-1) > 0.01 {
+		invalid("weather.states", "shares must sum to 1, got %v", shareTotal)
+	}
+}
+
+// weatherState returns the current weather state
+func (s *Simulation) weatherState() WeatherState {
+	return s.config.Weather.States[s.weathers[atomic.LoadInt32(&s.weather)]]
+}
+
+// weatherDemand returns the multiplier of the arrival rate of the current weather, 1 without weather
+func (s *Simulation) weatherDemand() float32 {
+	if s.config.Weather == nil {
+		return 1
+	}
+	return s.weatherState().Demand
+}
+
+// fuelMix returns the multiplier of the chance of the fuel in the current weather, 1 without weather
+func (s *Simulation) fuelMix(fuel FuelType) float32 {
+	if s.config.Weather == nil {
+		return 1
+	}
+	if mix, ok := s.weatherState().FuelMix[s.fuelNames[fuel]]; ok {
+		return mix
+	}
+	return 1
+}
+
+// changeWeather draws the weather from elapsed into the run on and returns how long it lasts
+func (s *Simulation) changeWeather(rng *rand.Rand, elapsed time.Duration) time.Duration {
+	w := s.config.Weather
+	chance := rng.Float32()
+	next := 0
+	for i, name := range s.weathers {
+		next = i
+		if chance -= w.States[name].Share; chance < 0 {
+			break
+		}
+	}
+
+	current := atomic.LoadInt32(&s.weather)
+	s.endBusy(s.weatherSince, int(current), &s.stats.Weather[current].Time, elapsed)
+	atomic.StoreInt32(&s.weather, int32(next))
+	s.startBusy(s.weatherSince, next, elapsed)
+	atomic.AddInt32(&s.shared().Weather[next].Changes, 1)
+
+	return time.Duration(rng.ExpFloat64() * float64(w.MeanDuration))
+}
+
+// runWeather changes the weather of a realtime run once the current one lasted for until, and so on
+// until ctx is cancelled
+func (s *Simulation) runWeather(ctx context.Context, rng *rand.Rand, until time.Duration) {
+	for s.clock.wait(ctx, until) {
+		until = s.changeWeather(rng, s.realtimeElapsed())
+	}
+}
+
+// scheduleWeather changes the weather of a virtual run and schedules the next change
+func (g *virtualGasStation) scheduleWeather() {
+	g.schedule(g.changeWeather(g.rng, g.sched.now), event{kind: eventWeather})
+}
+
+// printWeather writes the time share and arrivals of every weather state
+func printWeather(w io.Writer, config Config, stats *Stats) {
+	observed := config.observedTime()
+	var states []string
+	for _, ws := range stats.Weather {
+		perHour := 0.0
+		if ws.Time > 0 {
+			perHour = float64(ws.CarsArrived) / float64(ws.Time) * 3600
+		}
+		states = append(states, fmt.Sprintf("%s %.1f %% of the time, %d cars (%.0f/h)", ws.Name, float64(ws.Time)/observed*100, ws.CarsArrived, perHour))
+	}
+	fmt.Fprintln(w, "Weather: ", strings.Join(states, ", "))
+}
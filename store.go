@@ -0,0 +1,301 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+
+	"pump/sim"
+)
+
+// storeMigrations bring a results database up to date, each applied once in order and recorded
+// in schema_migrations; they are written for SQLite and translated by postgresTypes
+var storeMigrations = []string{`
+CREATE TABLE IF NOT EXISTS runs (
+	id INTEGER PRIMARY KEY,
+	started_at TEXT NOT NULL,
+	seed INTEGER NOT NULL,
+	config_path TEXT NOT NULL,
+	config TEXT NOT NULL,
+	cars_spawned INTEGER NOT NULL,
+	cars_checked_out INTEGER NOT NULL,
+	cars_not_served INTEGER NOT NULL,
+	cars_balked INTEGER NOT NULL,
+	cars_drove_off INTEGER NOT NULL,
+	cash REAL NOT NULL,
+	checked_out_rate REAL,
+	receipt REAL,
+	time_in_refuel_queue REAL,
+	time_in_checkout_queue REAL,
+	utilization REAL,
+	register_utilization REAL,
+	report TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS cars (
+	run_id INTEGER NOT NULL REFERENCES runs (id),
+	car INTEGER NOT NULL,
+	fuel TEXT NOT NULL,
+	class TEXT,
+	arrived REAL NOT NULL,
+	station INTEGER,
+	fueling_started REAL,
+	fueling_finished REAL,
+	register INTEGER,
+	checkout_started REAL,
+	left_at REAL,
+	outcome TEXT,
+	amount REAL,
+	PRIMARY KEY (run_id, car)
+);`, `
+CREATE TABLE events (
+	run_id INTEGER NOT NULL REFERENCES runs (id),
+	seq INTEGER NOT NULL,
+	time REAL NOT NULL,
+	event TEXT NOT NULL,
+	car INTEGER NOT NULL,
+	station INTEGER,
+	register INTEGER,
+	amount REAL,
+	PRIMARY KEY (run_id, seq)
+);`, `
+ALTER TABLE runs ADD COLUMN run TEXT;
+ALTER TABLE runs ADD COLUMN version TEXT;
+ALTER TABLE runs ADD COLUMN config_hash TEXT;`,
+}
+
+// postgresTypes translates the migrations to PostgreSQL, with generated run IDs and doubles
+var postgresTypes = strings.NewReplacer("INTEGER PRIMARY KEY", "BIGSERIAL PRIMARY KEY", "seed INTEGER", "seed BIGINT", "run_id INTEGER", "run_id BIGINT", "REAL", "DOUBLE PRECISION")
+
+// storeBatch is the number of rows inserted by a statement
+const storeBatch = 500
+
+// carRecord is the journey of a car through a run, the times in simulated seconds since the start;
+// the steps a car didn't get to are nil, the outcome too for cars still inside at the end
+type carRecord struct {
+	car             int
+	fuel            string
+	class           *string
+	arrived         float64
+	station         *int
+	fuelingStarted  *float64
+	fuelingFinished *float64
+	register        *int
+	checkoutStarted *float64
+	left            *float64
+	outcome         *string
+	amount          *float32
+}
+
+// runRecorder keeps the trace events of a run and builds the records of its cars from them
+type runRecorder struct {
+	events []sim.TraceEvent
+	inside map[int]*carRecord
+	done   []*carRecord
+}
+
+func newRunRecorder() *runRecorder {
+	return &runRecorder{inside: map[int]*carRecord{}}
+}
+
+// record keeps an event and adds it to the record of its car, which is done once the car is gone
+func (r *runRecorder) record(e sim.TraceEvent) {
+	r.events = append(r.events, e)
+	if e.Event == sim.EventSpawned {
+		car := &carRecord{car: e.Car, fuel: e.Fuel, arrived: e.Time}
+		if e.Class != "" {
+			class := e.Class
+			car.class = &class
+		}
+		r.inside[e.Car] = car
+		return
+	}
+
+	car := r.inside[e.Car]
+	if car == nil {
+		return
+	}
+	at := e.Time
+	switch e.Event {
+	case sim.EventStartedFueling:
+		station := *e.Station
+		car.station, car.fuelingStarted = &station, &at
+	case sim.EventFinishedFueling:
+		car.fuelingFinished = &at
+	case sim.EventStartedCheckout:
+		car.checkoutStarted = &at
+		if e.Register != nil {
+			register := *e.Register
+			car.register = &register
+		}
+	case sim.EventPaid, sim.EventLeftUnserved, sim.EventBalked, sim.EventTurnedAway, sim.EventDroveOff:
+		outcome := e.Event
+		car.left, car.outcome = &at, &outcome
+		if e.Amount != nil {
+			amount := *e.Amount
+			car.amount = &amount
+		}
+		r.done = append(r.done, car)
+		delete(r.inside, e.Car)
+	}
+}
+
+// records returns the records of every car of the run by car ID, the ones still inside included
+func (r *runRecorder) records() []*carRecord {
+	records := append([]*carRecord(nil), r.done...)
+	for _, car := range r.inside {
+		records = append(records, car)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].car < records[j].car })
+	return records
+}
+
+// openStore opens the results database of a postgres:// URL or else the SQLite file at target,
+// creating the file and applying the migrations it misses
+func openStore(target string) (*sql.DB, error) {
+	driver, dialect := "sqlite", strings.NewReplacer()
+	if strings.HasPrefix(target, "postgres://") || strings.HasPrefix(target, "postgresql://") {
+		driver, dialect = "pgx", postgresTypes
+	}
+	db, err := sql.Open(driver, target)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrateStore(db, dialect); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// migrateStore applies the migrations newer than the version of the database, each in a
+// transaction of its own
+func migrateStore(db *sql.DB, dialect *strings.Replacer) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY, applied_at TEXT NOT NULL)`)
+	if err != nil {
+		return err
+	}
+	var version int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return err
+	}
+
+	for i := version; i < len(storeMigrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(dialect.Replace(storeMigrations[i]))
+		if err == nil {
+			_, err = tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES ($1, $2)`, i+1, time.Now().Format(time.RFC3339))
+		}
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migrating to version %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// storeRun appends a run with its final stats, the records of its cars and its events to the
+// database in a single transaction, returning the ID of the run
+func storeRun(db *sql.DB, configPath string, results sim.Results, recorder *runRecorder) (int64, error) {
+	config, err := json.Marshal(results.Config)
+	if err != nil {
+		return 0, err
+	}
+	report := results.Report()
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stats, averages, run := results.Stats, report.Averages, results.Metadata
+	total := stats.Total()
+	var id int64
+	err = tx.QueryRow(`INSERT INTO runs (run, started_at, version, config_hash, seed, config_path, config, cars_spawned,
+		cars_checked_out, cars_not_served, cars_balked, cars_drove_off, cash, checked_out_rate, receipt,
+		time_in_refuel_queue, time_in_checkout_queue, utilization, register_utilization, report)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20) RETURNING id`,
+		run.ID, run.Started.Format(time.RFC3339), run.Version, run.ConfigHash, run.Seed, configPath, string(config), stats.CarsSpawnedTotal,
+		total.CarsCheckedOut, stats.CarsNotServed, stats.CarsBalked, stats.CarsDroveOff, total.Cash,
+		nullable(averages.CheckedOutRate), nullable(averages.Receipt), nullable(averages.TimeInRefuel),
+		nullable(averages.TimeInCheckout), nullable(averages.Utilization), nullable(averages.RegisterUtilization),
+		string(reportJSON)).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	var cars [][]interface{}
+	for _, car := range recorder.records() {
+		cars = append(cars, []interface{}{id, car.car, car.fuel, car.class, car.arrived, car.station, car.fuelingStarted,
+			car.fuelingFinished, car.register, car.checkoutStarted, car.left, car.outcome, car.amount})
+	}
+	err = insertRows(tx, "cars (run_id, car, fuel, class, arrived, station, fueling_started, fueling_finished, register, checkout_started, left_at, outcome, amount)", cars)
+	if err != nil {
+		return 0, err
+	}
+
+	var events [][]interface{}
+	for i, e := range recorder.events {
+		events = append(events, []interface{}{id, i, e.Time, e.Event, e.Car, e.Station, e.Register, e.Amount})
+	}
+	err = insertRows(tx, "events (run_id, seq, time, event, car, station, register, amount)", events)
+	if err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// insertRows inserts the rows into the table and columns of into, storeBatch rows per statement
+func insertRows(tx *sql.Tx, into string, rows [][]interface{}) error {
+	for len(rows) > 0 {
+		batch := rows[:min(storeBatch, len(rows))]
+		rows = rows[len(batch):]
+
+		var query strings.Builder
+		var args []interface{}
+		fmt.Fprintf(&query, "INSERT INTO %s VALUES ", into)
+		for i, row := range batch {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			query.WriteString("(")
+			for j, value := range row {
+				if j > 0 {
+					query.WriteString(", ")
+				}
+				args = append(args, value)
+				fmt.Fprintf(&query, "$%d", len(args))
+			}
+			query.WriteString(")")
+		}
+		if _, err := tx.Exec(query.String(), args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nullable stores undefined averages as NULL
+func nullable(n sim.Number) interface{} {
+	if math.IsNaN(float64(n)) || math.IsInf(float64(n), 0) {
+		return nil
+	}
+	return float64(n)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"path/filepath"
+	"strconv"
+
+	"pump/sim"
+)
+
+// runSample is a queue sample in the JSON of --timeseries, with the ID of its run
+type runSample struct {
+	Run string `json:"run"`
+	sim.QueueSample
+}
+
+// writeTimeSeries writes the queue samples of the run to the file at path, as JSON for a .json file and CSV otherwise
+func writeTimeSeries(path, run string, samples []sim.QueueSample) error {
+	return writeOutput(path, func(w io.Writer) error {
+		if filepath.Ext(path) == ".json" {
+			rows := make([]runSample, len(samples))
+			for i, s := range samples {
+				rows[i] = runSample{run, s}
+			}
+			return writeJSON(w, rows)
+		}
+		return writeTimeSeriesCSV(w, run, samples)
+	})
+}
+
+// writeTimeSeriesCSV writes a row per sample with the ID of the run and the totals followed by the columns
+// of every fuel type
+func writeTimeSeriesCSV(w io.Writer, run string, samples []sim.QueueSample) error {
+	out := csv.NewWriter(w)
+
+	header := []string{"run", "time", "cars_in_refuel_queue", "cars_in_checkout_queue", "registers_busy"}
+	if len(samples) > 0 {
+		for _, f := range samples[0].Fuels {
+			header = append(header, f.Name+"_refuel_queue", f.Name+"_stations_busy", f.Name+"_stations_in_repair")
+		}
+	}
+	out.Write(header)
+
+	itoa := func(n int32) string { return strconv.Itoa(int(n)) }
+	for _, s := range samples {
+		row := []string{
+			run, strconv.FormatFloat(s.Time, 'f', -1, 64),
+			itoa(s.CarsInRefuelQueue), itoa(s.CarsInCheckoutQueue), itoa(s.RegistersBusy),
+		}
+		for _, f := range s.Fuels {
+			row = append(row, itoa(f.CarsInRefuelQueue), itoa(f.StationsBusy), itoa(f.StationsInRepair))
+		}
+		out.Write(row)
+	}
+
+	out.Flush()
+	return out.Error()
+}
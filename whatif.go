@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"pump/sim"
+)
+
+// overrideList collects repeated key=value flags
+type overrideList []override
+
+func (l *overrideList) String() string { return "" }
+
+func (l *overrideList) Set(value string) error {
+	o, err := parseOverride(value)
+	if err != nil {
+		return err
+	}
+
+	*l = append(*l, o)
+	return nil
+}
+
+// runWhatIf implements the whatif subcommand
+func runWhatIf(args []string) error {
+	var changes overrideList
+	flags := flag.NewFlagSet("whatif", flag.ExitOnError)
+	configPath := flags.String("config", "", "base config file (default $CTC_CONFIG or "+strings.Join(defaultConfigPaths, ", ")+")")
+	flags.StringVar(configPath, "c", "", "shorthand for --config")
+	flags.Var(&changes, "set", "change a config key of the what-if run as key=value, e.g. fuels.gas.station_count=5 (repeatable)")
+	seed := flags.Int64("seed", 0, "first seed of the runs, overriding random_seed")
+	replications := flags.Int("replications", 5, "runs of both configs with the same consecutive seeds")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: whatif [--config path] [--replications N] --set key=value...")
+		fmt.Fprintln(flags.Output(), "Runs the base config and a copy with the changes on the same seeds and prints the change of the key metrics.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if len(changes) == 0 || flags.NArg() > 0 || *replications < 1 {
+		flags.Usage()
+		return errUsage
+	}
+
+	path := findConfig(*configPath)
+	base, err := readConfig(path, nil)
+	if err != nil {
+		return err
+	}
+	whatIf, err := readConfig(path, changes)
+	if err != nil {
+		return err
+	}
+	if *seed != 0 {
+		base.RandomSeed = *seed
+	}
+	if base.RandomSeed == 0 {
+		base.RandomSeed = time.Now().UnixNano()
+	}
+	whatIf.RandomSeed = base.RandomSeed
+	base.Realtime, whatIf.Realtime = false, false
+
+	var scenarios [][]sim.Results
+	for _, config := range []*sim.Config{base, whatIf} {
+		results, err := sim.Replicate(context.Background(), *config, *replications)
+		if err != nil {
+			return fmt.Errorf("running replications: %w", err)
+		}
+		scenarios = append(scenarios, results)
+	}
+
+	var set []string
+	for _, o := range changes {
+		set = append(set, o.key+"="+o.value)
+	}
+	fmt.Printf("What if %s, seeds %d to %d\n", strings.Join(set, ", "), base.RandomSeed, base.RandomSeed+int64(*replications)-1)
+	sim.PrintComparison(os.Stdout, []string{"base", "what if"}, scenarios)
+	return nil
+}